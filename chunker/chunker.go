@@ -1,15 +1,69 @@
 package chunker
 
+import (
+	"bytes"
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/MaxIvanyshen/local-rag/config"
+)
+
 type ChunkResult struct {
 	Data      []byte
 	StartLine int
 	EndLine   int
+	// StartByte and EndByte are the byte offsets of Data in the chunked data,
+	// EndByte is exclusive.
+	StartByte int
+	EndByte   int
+	// StartColumn and EndColumn are the 1-based rune columns of StartByte and
+	// EndByte within StartLine and EndLine.
+	StartColumn int
+	EndColumn   int
+	// Children holds smaller chunks cut from Data. When set, the children are
+	// embedded for matching and this chunk is only returned as their context.
+	Children []ChunkResult
 }
 
 type Chunker interface {
 	Chunk(data []byte) []ChunkResult
 }
 
+// Formats of text that a FormatChunker splits differently from prose, as
+// extractors set them on the sections of a document.
+const (
+	FormatCSV      = "csv"
+	FormatTSV      = "tsv"
+	FormatMarkdown = "markdown"
+)
+
+// FormatChunker is a Chunker that splits some formats of text differently.
+type FormatChunker interface {
+	Chunker
+	// ChunkFormat chunks data in the given format. Data in an empty or
+	// unknown format is chunked as prose, as Chunk does.
+	ChunkFormat(data []byte, format string) []ChunkResult
+}
+
+// New returns the chunker the configuration selects.
+func New(cfg config.ChunkerConfig) (Chunker, error) {
+	var textChunker Chunker
+	switch cfg.Type {
+	case "paragraph":
+		textChunker = NewSizedParagraphChunker(cfg.OverlapBytes, cfg.MinChunkSize, cfg.MaxChunkSize)
+	case "fixed":
+		textChunker = &FixedSizeChunker{ChunkSize: cfg.ChunkSize}
+	case "parent_child":
+		textChunker = NewParentChildChunker(&HeadingChunker{}, NewSizedParagraphChunker(cfg.OverlapBytes, cfg.MinChunkSize, cfg.MaxChunkSize))
+	default:
+		return nil, fmt.Errorf("unknown chunker type: %s", cfg.Type)
+	}
+	if cfg.TableRows > 0 {
+		return NewTableChunker(cfg.TableRows, textChunker), nil
+	}
+	return textChunker, nil
+}
+
 // countLines counts the number of lines in the given data
 func countLines(data []byte) int {
 	count := 1
@@ -33,6 +87,26 @@ func calculateLines(fullData []byte, chunkStart, chunkEnd int) (int, int) {
 	return startLine, endLine
 }
 
+// calculateColumn returns the 1-based rune column of the byte offset within its line
+func calculateColumn(fullData []byte, offset int) int {
+	lineStart := bytes.LastIndexByte(fullData[:offset], '\n') + 1
+	return utf8.RuneCount(fullData[lineStart:offset]) + 1
+}
+
+// newChunkResult builds the result for the chunk fullData[chunkStart:chunkEnd]
+func newChunkResult(fullData []byte, chunkStart, chunkEnd int) ChunkResult {
+	startLine, endLine := calculateLines(fullData, chunkStart, chunkEnd)
+	return ChunkResult{
+		Data:        fullData[chunkStart:chunkEnd],
+		StartLine:   startLine,
+		EndLine:     endLine,
+		StartByte:   chunkStart,
+		EndByte:     chunkEnd,
+		StartColumn: calculateColumn(fullData, chunkStart),
+		EndColumn:   calculateColumn(fullData, chunkEnd),
+	}
+}
+
 // FixedSizeChunker splits data into chunks of a fixed size.
 type FixedSizeChunker struct {
 	ChunkSize int
@@ -45,12 +119,7 @@ func (f *FixedSizeChunker) Chunk(data []byte) []ChunkResult {
 		if end > len(data) {
 			end = len(data)
 		}
-		startLine, endLine := calculateLines(data, i, end)
-		chunks = append(chunks, ChunkResult{
-			Data:      data[i:end],
-			StartLine: startLine,
-			EndLine:   endLine,
-		})
+		chunks = append(chunks, newChunkResult(data, i, end))
 	}
 	return chunks
 }
@@ -65,22 +134,12 @@ func (d *DelimiterChunker) Chunk(data []byte) []ChunkResult {
 	start := 0
 	for i := range len(data) {
 		if data[i] == d.Delimiter {
-			startLine, endLine := calculateLines(data, start, i)
-			chunks = append(chunks, ChunkResult{
-				Data:      data[start:i],
-				StartLine: startLine,
-				EndLine:   endLine,
-			})
+			chunks = append(chunks, newChunkResult(data, start, i))
 			start = i + 1
 		}
 	}
 	if start < len(data) {
-		startLine, endLine := calculateLines(data, start, len(data))
-		chunks = append(chunks, ChunkResult{
-			Data:      data[start:],
-			StartLine: startLine,
-			EndLine:   endLine,
-		})
+		chunks = append(chunks, newChunkResult(data, start, len(data)))
 	}
 	return chunks
 }
@@ -107,12 +166,7 @@ func (s *SentenceChunker) Chunk(data []byte) []ChunkResult {
 			if end > len(data) {
 				end = len(data)
 			}
-			startLine, endLine := calculateLines(data, start, end)
-			chunks = append(chunks, ChunkResult{
-				Data:      data[start:end],
-				StartLine: startLine,
-				EndLine:   endLine,
-			})
+			chunks = append(chunks, newChunkResult(data, start, end))
 			start = end - s.OverlapSize
 			if start < 0 {
 				start = 0
@@ -120,19 +174,18 @@ func (s *SentenceChunker) Chunk(data []byte) []ChunkResult {
 		}
 	}
 	if start < len(data) {
-		startLine, endLine := calculateLines(data, start, len(data))
-		chunks = append(chunks, ChunkResult{
-			Data:      data[start:],
-			StartLine: startLine,
-			EndLine:   endLine,
-		})
+		chunks = append(chunks, newChunkResult(data, start, len(data)))
 	}
 	return chunks
 }
 
 // ParagraphChunker splits data into paragraphs based on double newline characters.
+// When MinSize or MaxSize are set, adjacent paragraphs smaller than MinSize
+// bytes are merged and paragraphs larger than MaxSize bytes are split on lines.
 type ParagraphChunker struct {
 	OverlapSize int
+	MinSize     int
+	MaxSize     int
 }
 
 func NewParagraphChunker(overlap int) *ParagraphChunker {
@@ -141,11 +194,23 @@ func NewParagraphChunker(overlap int) *ParagraphChunker {
 	}
 }
 
+func NewSizedParagraphChunker(overlap, minSize, maxSize int) *ParagraphChunker {
+	return &ParagraphChunker{
+		OverlapSize: overlap,
+		MinSize:     minSize,
+		MaxSize:     maxSize,
+	}
+}
+
 func (p *ParagraphChunker) SetOverlap(size int) {
 	p.OverlapSize = size
 }
 
 func (p *ParagraphChunker) Chunk(data []byte) []ChunkResult {
+	if p.MinSize > 0 || p.MaxSize > 0 {
+		return p.chunkSized(data)
+	}
+
 	var chunks []ChunkResult
 	start := 0
 	for i := range len(data) - 1 {
@@ -154,12 +219,7 @@ func (p *ParagraphChunker) Chunk(data []byte) []ChunkResult {
 			if end > len(data) {
 				end = len(data)
 			}
-			startLine, endLine := calculateLines(data, start, end)
-			chunks = append(chunks, ChunkResult{
-				Data:      data[start:end],
-				StartLine: startLine,
-				EndLine:   endLine,
-			})
+			chunks = append(chunks, newChunkResult(data, start, end))
 			start = end - p.OverlapSize
 			if start < 0 {
 				start = 0
@@ -167,12 +227,202 @@ func (p *ParagraphChunker) Chunk(data []byte) []ChunkResult {
 		}
 	}
 	if start < len(data) {
-		startLine, endLine := calculateLines(data, start, len(data))
-		chunks = append(chunks, ChunkResult{
-			Data:      data[start:],
-			StartLine: startLine,
-			EndLine:   endLine,
-		})
+		chunks = append(chunks, newChunkResult(data, start, len(data)))
 	}
 	return chunks
 }
+
+// span is a half-open byte range of the chunked data.
+type span struct {
+	start, end int
+}
+
+func (p *ParagraphChunker) chunkSized(data []byte) []ChunkResult {
+	// Find paragraph boundaries the same way as the unsized chunker
+	var paragraphs []span
+	start := 0
+	for i := range len(data) - 1 {
+		if data[i] == '\n' && data[i+1] == '\n' {
+			paragraphs = append(paragraphs, span{start, i + 2})
+			start = i + 2
+		}
+	}
+	if start < len(data) {
+		paragraphs = append(paragraphs, span{start, len(data)})
+	}
+
+	var sized []span
+	for _, paragraph := range paragraphs {
+		sized = append(sized, p.splitOversized(data, paragraph)...)
+	}
+	merged := p.mergeUndersized(sized)
+
+	var chunks []ChunkResult
+	for i, m := range merged {
+		chunkStart := m.start
+		if i > 0 {
+			chunkStart = max(m.start-p.OverlapSize, 0)
+		}
+		chunks = append(chunks, newChunkResult(data, chunkStart, m.end))
+	}
+	return chunks
+}
+
+// splitOversized splits a paragraph larger than MaxSize into runs of whole
+// lines. A single line longer than MaxSize is cut at a rune boundary.
+func (p *ParagraphChunker) splitOversized(data []byte, paragraph span) []span {
+	if p.MaxSize <= 0 || paragraph.end-paragraph.start <= p.MaxSize {
+		return []span{paragraph}
+	}
+
+	var spans []span
+	current := span{paragraph.start, paragraph.start}
+	for lineStart := paragraph.start; lineStart < paragraph.end; {
+		lineEnd := bytes.IndexByte(data[lineStart:paragraph.end], '\n')
+		if lineEnd < 0 {
+			lineEnd = paragraph.end
+		} else {
+			lineEnd += lineStart + 1
+		}
+
+		if lineEnd-current.start > p.MaxSize && current.end > current.start {
+			spans = append(spans, current)
+			current = span{lineStart, lineStart}
+		}
+		for lineEnd-current.start > p.MaxSize {
+			cut := current.start + p.MaxSize
+			for cut > current.start && !utf8.RuneStart(data[cut]) {
+				cut--
+			}
+			if cut == current.start {
+				cut = current.start + p.MaxSize
+			}
+			spans = append(spans, span{current.start, cut})
+			current = span{cut, cut}
+		}
+		current.end = lineEnd
+		lineStart = lineEnd
+	}
+	if current.end > current.start {
+		spans = append(spans, current)
+	}
+	return spans
+}
+
+// mergeUndersized joins adjacent spans while the current one is smaller than
+// MinSize and the result stays within MaxSize.
+func (p *ParagraphChunker) mergeUndersized(spans []span) []span {
+	if p.MinSize <= 0 {
+		return spans
+	}
+
+	var merged []span
+	for _, next := range spans {
+		if len(merged) > 0 {
+			last := &merged[len(merged)-1]
+			fits := p.MaxSize <= 0 || next.end-last.start <= p.MaxSize
+			if last.end-last.start < p.MinSize && fits {
+				last.end = next.end
+				continue
+			}
+		}
+		merged = append(merged, next)
+	}
+
+	// A small trailing span is folded into the one before it when possible
+	if n := len(merged); n > 1 && merged[n-1].end-merged[n-1].start < p.MinSize {
+		if p.MaxSize <= 0 || merged[n-1].end-merged[n-2].start <= p.MaxSize {
+			merged[n-2].end = merged[n-1].end
+			merged = merged[:n-1]
+		}
+	}
+	return merged
+}
+
+// HeadingChunker splits Markdown data into sections, starting a new chunk at
+// every ATX heading ("# Title") that is not inside a fenced code block.
+type HeadingChunker struct{}
+
+func (h *HeadingChunker) Chunk(data []byte) []ChunkResult {
+	var chunks []ChunkResult
+	start := 0
+	inFence := false
+	for lineStart := 0; lineStart < len(data); {
+		lineEnd := bytes.IndexByte(data[lineStart:], '\n')
+		if lineEnd < 0 {
+			lineEnd = len(data)
+		} else {
+			lineEnd += lineStart + 1
+		}
+		line := bytes.TrimLeft(data[lineStart:lineEnd], " ")
+		if bytes.HasPrefix(line, []byte("```")) || bytes.HasPrefix(line, []byte("~~~")) {
+			inFence = !inFence
+		} else if !inFence && isHeading(line) && lineStart > start {
+			chunks = append(chunks, newChunkResult(data, start, lineStart))
+			start = lineStart
+		}
+		lineStart = lineEnd
+	}
+	if start < len(data) {
+		chunks = append(chunks, newChunkResult(data, start, len(data)))
+	}
+	return chunks
+}
+
+// shiftChunkResult moves the position of a chunk cut from parent.Data so it
+// is relative to the data the parent was cut from.
+func shiftChunkResult(chunk, parent ChunkResult) ChunkResult {
+	// Columns only shift on the parent's first line
+	if chunk.StartLine == 1 {
+		chunk.StartColumn += parent.StartColumn - 1
+	}
+	if chunk.EndLine == 1 {
+		chunk.EndColumn += parent.StartColumn - 1
+	}
+	chunk.StartLine += parent.StartLine - 1
+	chunk.EndLine += parent.StartLine - 1
+	chunk.StartByte += parent.StartByte
+	chunk.EndByte += parent.StartByte
+	return chunk
+}
+
+// isHeading reports whether the line is a Markdown ATX heading: one to six
+// '#' characters followed by a space or the end of the line.
+func isHeading(line []byte) bool {
+	level := 0
+	for level < len(line) && line[level] == '#' {
+		level++
+	}
+	if level == 0 || level > 6 {
+		return false
+	}
+	return level == len(line) || line[level] == ' ' || line[level] == '\t' || line[level] == '\n' || line[level] == '\r'
+}
+
+// ParentChildChunker splits data into large parent chunks and splits every
+// parent again into small child chunks. Children are small enough to embed
+// well, while their parent gives search results the surrounding section.
+type ParentChildChunker struct {
+	Parent Chunker
+	Child  Chunker
+}
+
+func NewParentChildChunker(parent, child Chunker) *ParentChildChunker {
+	return &ParentChildChunker{
+		Parent: parent,
+		Child:  child,
+	}
+}
+
+func (pc *ParentChildChunker) Chunk(data []byte) []ChunkResult {
+	parents := pc.Parent.Chunk(data)
+	for i := range parents {
+		children := pc.Child.Chunk(parents[i].Data)
+		// Child positions are relative to the parent, shift them to the document
+		for j := range children {
+			children[j] = shiftChunkResult(children[j], parents[i])
+		}
+		parents[i].Children = children
+	}
+	return parents
+}
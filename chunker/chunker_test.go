@@ -24,15 +24,15 @@ func TestFixedSizeChunker_Chunk(t *testing.T) {
 			name:      "data smaller than chunk size",
 			data:      []byte("abc"),
 			chunkSize: 5,
-			expected:  []ChunkResult{{Data: []byte("abc"), StartLine: 1, EndLine: 1}},
+			expected:  []ChunkResult{{Data: []byte("abc"), StartLine: 1, EndLine: 1, StartByte: 0, EndByte: 3, StartColumn: 1, EndColumn: 4}},
 		},
 		{
 			name:      "exact multiple",
 			data:      []byte("abcdef"),
 			chunkSize: 3,
 			expected: []ChunkResult{
-				{Data: []byte("abc"), StartLine: 1, EndLine: 1},
-				{Data: []byte("def"), StartLine: 1, EndLine: 1},
+				{Data: []byte("abc"), StartLine: 1, EndLine: 1, StartByte: 0, EndByte: 3, StartColumn: 1, EndColumn: 4},
+				{Data: []byte("def"), StartLine: 1, EndLine: 1, StartByte: 3, EndByte: 6, StartColumn: 4, EndColumn: 7},
 			},
 		},
 		{
@@ -40,9 +40,9 @@ func TestFixedSizeChunker_Chunk(t *testing.T) {
 			data:      []byte("abcdefg"),
 			chunkSize: 3,
 			expected: []ChunkResult{
-				{Data: []byte("abc"), StartLine: 1, EndLine: 1},
-				{Data: []byte("def"), StartLine: 1, EndLine: 1},
-				{Data: []byte("g"), StartLine: 1, EndLine: 1},
+				{Data: []byte("abc"), StartLine: 1, EndLine: 1, StartByte: 0, EndByte: 3, StartColumn: 1, EndColumn: 4},
+				{Data: []byte("def"), StartLine: 1, EndLine: 1, StartByte: 3, EndByte: 6, StartColumn: 4, EndColumn: 7},
+				{Data: []byte("g"), StartLine: 1, EndLine: 1, StartByte: 6, EndByte: 7, StartColumn: 7, EndColumn: 8},
 			},
 		},
 	}
@@ -77,15 +77,15 @@ func TestDelimiterChunker_Chunk(t *testing.T) {
 			name:      "no delimiter",
 			data:      []byte("abc"),
 			delimiter: ',',
-			expected:  []ChunkResult{{Data: []byte("abc"), StartLine: 1, EndLine: 1}},
+			expected:  []ChunkResult{{Data: []byte("abc"), StartLine: 1, EndLine: 1, StartByte: 0, EndByte: 3, StartColumn: 1, EndColumn: 4}},
 		},
 		{
 			name:      "one delimiter",
 			data:      []byte("a,b"),
 			delimiter: ',',
 			expected: []ChunkResult{
-				{Data: []byte("a"), StartLine: 1, EndLine: 1},
-				{Data: []byte("b"), StartLine: 1, EndLine: 1},
+				{Data: []byte("a"), StartLine: 1, EndLine: 1, StartByte: 0, EndByte: 1, StartColumn: 1, EndColumn: 2},
+				{Data: []byte("b"), StartLine: 1, EndLine: 1, StartByte: 2, EndByte: 3, StartColumn: 3, EndColumn: 4},
 			},
 		},
 		{
@@ -93,9 +93,9 @@ func TestDelimiterChunker_Chunk(t *testing.T) {
 			data:      []byte("a,b,c"),
 			delimiter: ',',
 			expected: []ChunkResult{
-				{Data: []byte("a"), StartLine: 1, EndLine: 1},
-				{Data: []byte("b"), StartLine: 1, EndLine: 1},
-				{Data: []byte("c"), StartLine: 1, EndLine: 1},
+				{Data: []byte("a"), StartLine: 1, EndLine: 1, StartByte: 0, EndByte: 1, StartColumn: 1, EndColumn: 2},
+				{Data: []byte("b"), StartLine: 1, EndLine: 1, StartByte: 2, EndByte: 3, StartColumn: 3, EndColumn: 4},
+				{Data: []byte("c"), StartLine: 1, EndLine: 1, StartByte: 4, EndByte: 5, StartColumn: 5, EndColumn: 6},
 			},
 		},
 		{
@@ -103,8 +103,8 @@ func TestDelimiterChunker_Chunk(t *testing.T) {
 			data:      []byte(",a,"),
 			delimiter: ',',
 			expected: []ChunkResult{
-				{Data: []byte(""), StartLine: 1, EndLine: 1},
-				{Data: []byte("a"), StartLine: 1, EndLine: 1},
+				{Data: []byte(""), StartLine: 1, EndLine: 1, StartByte: 0, EndByte: 0, StartColumn: 1, EndColumn: 1},
+				{Data: []byte("a"), StartLine: 1, EndLine: 1, StartByte: 1, EndByte: 2, StartColumn: 2, EndColumn: 3},
 			},
 		},
 	}
@@ -145,21 +145,21 @@ func TestSentenceChunker_Chunk(t *testing.T) {
 			name:        "no sentence endings",
 			data:        []byte("hello world"),
 			overlapSize: 0,
-			expected:    []ChunkResult{{Data: []byte("hello world"), StartLine: 1, EndLine: 1}},
+			expected:    []ChunkResult{{Data: []byte("hello world"), StartLine: 1, EndLine: 1, StartByte: 0, EndByte: 11, StartColumn: 1, EndColumn: 12}},
 		},
 		{
 			name:        "one sentence",
 			data:        []byte("Hello."),
 			overlapSize: 0,
-			expected:    []ChunkResult{{Data: []byte("Hello."), StartLine: 1, EndLine: 1}},
+			expected:    []ChunkResult{{Data: []byte("Hello."), StartLine: 1, EndLine: 1, StartByte: 0, EndByte: 6, StartColumn: 1, EndColumn: 7}},
 		},
 		{
 			name:        "multiple sentences",
 			data:        []byte("Hi. How are you?"),
 			overlapSize: 0,
 			expected: []ChunkResult{
-				{Data: []byte("Hi."), StartLine: 1, EndLine: 1},
-				{Data: []byte(" How are you?"), StartLine: 1, EndLine: 1},
+				{Data: []byte("Hi."), StartLine: 1, EndLine: 1, StartByte: 0, EndByte: 3, StartColumn: 1, EndColumn: 4},
+				{Data: []byte(" How are you?"), StartLine: 1, EndLine: 1, StartByte: 3, EndByte: 16, StartColumn: 4, EndColumn: 17},
 			},
 		},
 		{
@@ -167,9 +167,9 @@ func TestSentenceChunker_Chunk(t *testing.T) {
 			data:        []byte("Hi. Hello!"),
 			overlapSize: 2,
 			expected: []ChunkResult{
-				{Data: []byte("Hi."), StartLine: 1, EndLine: 1},
-				{Data: []byte("i. Hello!"), StartLine: 1, EndLine: 1},
-				{Data: []byte("o!"), StartLine: 1, EndLine: 1},
+				{Data: []byte("Hi."), StartLine: 1, EndLine: 1, StartByte: 0, EndByte: 3, StartColumn: 1, EndColumn: 4},
+				{Data: []byte("i. Hello!"), StartLine: 1, EndLine: 1, StartByte: 1, EndByte: 10, StartColumn: 2, EndColumn: 11},
+				{Data: []byte("o!"), StartLine: 1, EndLine: 1, StartByte: 8, EndByte: 10, StartColumn: 9, EndColumn: 11},
 			},
 		},
 		{
@@ -177,9 +177,9 @@ func TestSentenceChunker_Chunk(t *testing.T) {
 			data:        []byte("Hi. Hello!"),
 			overlapSize: 10,
 			expected: []ChunkResult{
-				{Data: []byte("Hi."), StartLine: 1, EndLine: 1},
-				{Data: []byte("Hi. Hello!"), StartLine: 1, EndLine: 1},
-				{Data: []byte("Hi. Hello!"), StartLine: 1, EndLine: 1},
+				{Data: []byte("Hi."), StartLine: 1, EndLine: 1, StartByte: 0, EndByte: 3, StartColumn: 1, EndColumn: 4},
+				{Data: []byte("Hi. Hello!"), StartLine: 1, EndLine: 1, StartByte: 0, EndByte: 10, StartColumn: 1, EndColumn: 11},
+				{Data: []byte("Hi. Hello!"), StartLine: 1, EndLine: 1, StartByte: 0, EndByte: 10, StartColumn: 1, EndColumn: 11},
 			},
 		},
 	}
@@ -226,21 +226,21 @@ func TestParagraphChunker_Chunk(t *testing.T) {
 			name:        "no paragraphs",
 			data:        []byte("hello world"),
 			overlapSize: 0,
-			expected:    []ChunkResult{{Data: []byte("hello world"), StartLine: 1, EndLine: 1}},
+			expected:    []ChunkResult{{Data: []byte("hello world"), StartLine: 1, EndLine: 1, StartByte: 0, EndByte: 11, StartColumn: 1, EndColumn: 12}},
 		},
 		{
 			name:        "one paragraph",
 			data:        []byte("This is a paragraph.\n\n"),
 			overlapSize: 0,
-			expected:    []ChunkResult{{Data: []byte("This is a paragraph.\n\n"), StartLine: 1, EndLine: 3}},
+			expected:    []ChunkResult{{Data: []byte("This is a paragraph.\n\n"), StartLine: 1, EndLine: 3, StartByte: 0, EndByte: 22, StartColumn: 1, EndColumn: 1}},
 		},
 		{
 			name:        "multiple paragraphs",
 			data:        []byte("First para.\n\nSecond para.\n\n"),
 			overlapSize: 0,
 			expected: []ChunkResult{
-				{Data: []byte("First para.\n\n"), StartLine: 1, EndLine: 3},
-				{Data: []byte("Second para.\n\n"), StartLine: 3, EndLine: 5},
+				{Data: []byte("First para.\n\n"), StartLine: 1, EndLine: 3, StartByte: 0, EndByte: 13, StartColumn: 1, EndColumn: 1},
+				{Data: []byte("Second para.\n\n"), StartLine: 3, EndLine: 5, StartByte: 13, EndByte: 27, StartColumn: 1, EndColumn: 1},
 			},
 		},
 		{
@@ -248,9 +248,9 @@ func TestParagraphChunker_Chunk(t *testing.T) {
 			data:        []byte("Para one.\n\nPara two.\n\n"),
 			overlapSize: 5,
 			expected: []ChunkResult{
-				{Data: []byte("Para one.\n\n"), StartLine: 1, EndLine: 3},
-				{Data: []byte("ne.\n\nPara two.\n\n"), StartLine: 1, EndLine: 5},
-				{Data: []byte("wo.\n\n"), StartLine: 3, EndLine: 5},
+				{Data: []byte("Para one.\n\n"), StartLine: 1, EndLine: 3, StartByte: 0, EndByte: 11, StartColumn: 1, EndColumn: 1},
+				{Data: []byte("ne.\n\nPara two.\n\n"), StartLine: 1, EndLine: 5, StartByte: 6, EndByte: 22, StartColumn: 7, EndColumn: 1},
+				{Data: []byte("wo.\n\n"), StartLine: 3, EndLine: 5, StartByte: 17, EndByte: 22, StartColumn: 7, EndColumn: 1},
 			},
 		},
 		{
@@ -258,9 +258,9 @@ func TestParagraphChunker_Chunk(t *testing.T) {
 			data:        []byte("Short.\n\nLong paragraph here.\n\n"),
 			overlapSize: 20,
 			expected: []ChunkResult{
-				{Data: []byte("Short.\n\n"), StartLine: 1, EndLine: 3},
-				{Data: []byte("Short.\n\nLong paragraph here.\n\n"), StartLine: 1, EndLine: 5},
-				{Data: []byte("ng paragraph here.\n\n"), StartLine: 3, EndLine: 5},
+				{Data: []byte("Short.\n\n"), StartLine: 1, EndLine: 3, StartByte: 0, EndByte: 8, StartColumn: 1, EndColumn: 1},
+				{Data: []byte("Short.\n\nLong paragraph here.\n\n"), StartLine: 1, EndLine: 5, StartByte: 0, EndByte: 30, StartColumn: 1, EndColumn: 1},
+				{Data: []byte("ng paragraph here.\n\n"), StartLine: 3, EndLine: 5, StartByte: 10, EndByte: 30, StartColumn: 3, EndColumn: 1},
 			},
 		},
 		{
@@ -268,8 +268,8 @@ func TestParagraphChunker_Chunk(t *testing.T) {
 			data:        []byte("First.\n\nSecond."),
 			overlapSize: 0,
 			expected: []ChunkResult{
-				{Data: []byte("First.\n\n"), StartLine: 1, EndLine: 3},
-				{Data: []byte("Second."), StartLine: 3, EndLine: 3},
+				{Data: []byte("First.\n\n"), StartLine: 1, EndLine: 3, StartByte: 0, EndByte: 8, StartColumn: 1, EndColumn: 1},
+				{Data: []byte("Second."), StartLine: 3, EndLine: 3, StartByte: 8, EndByte: 15, StartColumn: 1, EndColumn: 8},
 			},
 		},
 		{
@@ -277,8 +277,8 @@ func TestParagraphChunker_Chunk(t *testing.T) {
 			data:        []byte("First para.\n\nSecond para.\n\n"),
 			overlapSize: 0,
 			expected: []ChunkResult{
-				{Data: []byte("First para.\n\n"), StartLine: 1, EndLine: 3},
-				{Data: []byte("Second para.\n\n"), StartLine: 3, EndLine: 5},
+				{Data: []byte("First para.\n\n"), StartLine: 1, EndLine: 3, StartByte: 0, EndByte: 13, StartColumn: 1, EndColumn: 1},
+				{Data: []byte("Second para.\n\n"), StartLine: 3, EndLine: 5, StartByte: 13, EndByte: 27, StartColumn: 1, EndColumn: 1},
 			},
 		},
 		{
@@ -286,9 +286,9 @@ func TestParagraphChunker_Chunk(t *testing.T) {
 			data:        []byte("Para one.\n\nPara two.\n\n"),
 			overlapSize: 5,
 			expected: []ChunkResult{
-				{Data: []byte("Para one.\n\n"), StartLine: 1, EndLine: 3},
-				{Data: []byte("ne.\n\nPara two.\n\n"), StartLine: 1, EndLine: 5},
-				{Data: []byte("wo.\n\n"), StartLine: 3, EndLine: 5},
+				{Data: []byte("Para one.\n\n"), StartLine: 1, EndLine: 3, StartByte: 0, EndByte: 11, StartColumn: 1, EndColumn: 1},
+				{Data: []byte("ne.\n\nPara two.\n\n"), StartLine: 1, EndLine: 5, StartByte: 6, EndByte: 22, StartColumn: 7, EndColumn: 1},
+				{Data: []byte("wo.\n\n"), StartLine: 3, EndLine: 5, StartByte: 17, EndByte: 22, StartColumn: 7, EndColumn: 1},
 			},
 		},
 		{
@@ -296,9 +296,9 @@ func TestParagraphChunker_Chunk(t *testing.T) {
 			data:        []byte("Short.\n\nLong paragraph here.\n\n"),
 			overlapSize: 20,
 			expected: []ChunkResult{
-				{Data: []byte("Short.\n\n"), StartLine: 1, EndLine: 3},
-				{Data: []byte("Short.\n\nLong paragraph here.\n\n"), StartLine: 1, EndLine: 5},
-				{Data: []byte("ng paragraph here.\n\n"), StartLine: 3, EndLine: 5},
+				{Data: []byte("Short.\n\n"), StartLine: 1, EndLine: 3, StartByte: 0, EndByte: 8, StartColumn: 1, EndColumn: 1},
+				{Data: []byte("Short.\n\nLong paragraph here.\n\n"), StartLine: 1, EndLine: 5, StartByte: 0, EndByte: 30, StartColumn: 1, EndColumn: 1},
+				{Data: []byte("ng paragraph here.\n\n"), StartLine: 3, EndLine: 5, StartByte: 10, EndByte: 30, StartColumn: 3, EndColumn: 1},
 			},
 		},
 		{
@@ -306,8 +306,8 @@ func TestParagraphChunker_Chunk(t *testing.T) {
 			data:        []byte("First.\n\nSecond."),
 			overlapSize: 0,
 			expected: []ChunkResult{
-				{Data: []byte("First.\n\n"), StartLine: 1, EndLine: 3},
-				{Data: []byte("Second."), StartLine: 3, EndLine: 3},
+				{Data: []byte("First.\n\n"), StartLine: 1, EndLine: 3, StartByte: 0, EndByte: 8, StartColumn: 1, EndColumn: 1},
+				{Data: []byte("Second."), StartLine: 3, EndLine: 3, StartByte: 8, EndByte: 15, StartColumn: 1, EndColumn: 8},
 			},
 		},
 	}
@@ -376,3 +376,179 @@ func TestSentenceChunker_OverlapVerification(t *testing.T) {
 		require.Equal(t, expectedOverlap, actualOverlap, "Overlap mismatch between chunk %d and %d", i, i+1)
 	}
 }
+
+func TestHeadingChunker_Chunk(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     []byte
+		expected []ChunkResult
+	}{
+		{
+			name:     "empty data",
+			data:     []byte{},
+			expected: nil,
+		},
+		{
+			name:     "no headings",
+			data:     []byte("just text\nmore text"),
+			expected: []ChunkResult{{Data: []byte("just text\nmore text"), StartLine: 1, EndLine: 2, StartByte: 0, EndByte: 19, StartColumn: 1, EndColumn: 10}},
+		},
+		{
+			name: "preamble and sections",
+			data: []byte("intro\n# One\nfirst\n## Two\nsecond\n"),
+			expected: []ChunkResult{
+				{Data: []byte("intro\n"), StartLine: 1, EndLine: 2, StartByte: 0, EndByte: 6, StartColumn: 1, EndColumn: 1},
+				{Data: []byte("# One\nfirst\n"), StartLine: 2, EndLine: 4, StartByte: 6, EndByte: 18, StartColumn: 1, EndColumn: 1},
+				{Data: []byte("## Two\nsecond\n"), StartLine: 4, EndLine: 6, StartByte: 18, EndByte: 32, StartColumn: 1, EndColumn: 1},
+			},
+		},
+		{
+			name: "hash inside code fence and tags are not headings",
+			data: []byte("# Title\n```sh\n# comment\n```\n#tag\n"),
+			expected: []ChunkResult{
+				{Data: []byte("# Title\n```sh\n# comment\n```\n#tag\n"), StartLine: 1, EndLine: 6, StartByte: 0, EndByte: 33, StartColumn: 1, EndColumn: 1},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chunker := &HeadingChunker{}
+			result := chunker.Chunk(tt.data)
+			if tt.expected == nil {
+				require.Nil(t, result)
+			} else {
+				require.Equal(t, tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestParentChildChunker_Chunk(t *testing.T) {
+	data := []byte("# One\n\nfirst para\n\n# Two\n\nsecond para\n")
+	chunker := NewParentChildChunker(&HeadingChunker{}, NewParagraphChunker(0))
+	result := chunker.Chunk(data)
+
+	require.Equal(t, []ChunkResult{
+		{
+			Data:        []byte("# One\n\nfirst para\n\n"),
+			StartLine:   1,
+			EndLine:     5,
+			StartByte:   0,
+			EndByte:     19,
+			StartColumn: 1,
+			EndColumn:   1,
+			Children: []ChunkResult{
+				{Data: []byte("# One\n\n"), StartLine: 1, EndLine: 3, StartByte: 0, EndByte: 7, StartColumn: 1, EndColumn: 1},
+				{Data: []byte("first para\n\n"), StartLine: 3, EndLine: 5, StartByte: 7, EndByte: 19, StartColumn: 1, EndColumn: 1},
+			},
+		},
+		{
+			Data:        []byte("# Two\n\nsecond para\n"),
+			StartLine:   5,
+			EndLine:     8,
+			StartByte:   19,
+			EndByte:     38,
+			StartColumn: 1,
+			EndColumn:   1,
+			Children: []ChunkResult{
+				{Data: []byte("# Two\n\n"), StartLine: 5, EndLine: 7, StartByte: 19, EndByte: 26, StartColumn: 1, EndColumn: 1},
+				{Data: []byte("second para\n"), StartLine: 7, EndLine: 8, StartByte: 26, EndByte: 38, StartColumn: 1, EndColumn: 1},
+			},
+		},
+	}, result)
+}
+
+func TestParentChildChunker_ChildPositionsMidLine(t *testing.T) {
+	data := []byte("abcdéf\nghij")
+	chunker := NewParentChildChunker(&FixedSizeChunker{ChunkSize: 4}, &FixedSizeChunker{ChunkSize: 3})
+	result := chunker.Chunk(data)
+
+	require.Len(t, result, 3)
+	require.Equal(t, []ChunkResult{
+		{Data: []byte("éf"), StartLine: 1, EndLine: 1, StartByte: 4, EndByte: 7, StartColumn: 5, EndColumn: 7},
+		{Data: []byte("\n"), StartLine: 1, EndLine: 2, StartByte: 7, EndByte: 8, StartColumn: 7, EndColumn: 1},
+	}, result[1].Children)
+	require.Equal(t, []ChunkResult{
+		{Data: []byte("d"), StartLine: 1, EndLine: 1, StartByte: 3, EndByte: 4, StartColumn: 4, EndColumn: 5},
+	}, result[0].Children[1:])
+}
+
+func TestParagraphChunker_SizedChunk(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     []byte
+		overlap  int
+		minSize  int
+		maxSize  int
+		expected []string
+	}{
+		{
+			name:     "merges tiny paragraphs",
+			data:     []byte("# Title\n\n---\n\nA real paragraph.\n\n"),
+			minSize:  15,
+			expected: []string{"# Title\n\n---\n\nA real paragraph.\n\n"},
+		},
+		{
+			name:     "stops merging at min size",
+			data:     []byte("aaaaaaaaaa\n\nbbbbbbbbbb\n\nc\n\nd\n\neeeeeeeeee\n\n"),
+			minSize:  10,
+			expected: []string{"aaaaaaaaaa\n\n", "bbbbbbbbbb\n\n", "c\n\nd\n\neeeeeeeeee\n\n"},
+		},
+		{
+			name:     "merging respects max size",
+			data:     []byte("a\n\nb\n\ncccccccccc\n\n"),
+			minSize:  10,
+			maxSize:  8,
+			expected: []string{"a\n\nb\n\n", "cccccccc", "cc\n\n"},
+		},
+		{
+			name:     "splits huge paragraph on lines",
+			data:     []byte("line one\nline two\nline three\n\nnext\n\n"),
+			maxSize:  20,
+			expected: []string{"line one\nline two\n", "line three\n\n", "next\n\n"},
+		},
+		{
+			name:     "cuts a single long line at rune boundary",
+			data:     []byte("ééééé"),
+			maxSize:  3,
+			expected: []string{"é", "é", "é", "é", "é"},
+		},
+		{
+			name:     "small trailing paragraph joins previous",
+			data:     []byte("long enough paragraph\n\nx"),
+			minSize:  5,
+			expected: []string{"long enough paragraph\n\nx"},
+		},
+		{
+			name:     "overlap applies between sized chunks",
+			data:     []byte("aaaa\n\nbbbb\n\n"),
+			overlap:  2,
+			maxSize:  6,
+			expected: []string{"aaaa\n\n", "\n\nbbbb\n\n"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chunker := NewSizedParagraphChunker(tt.overlap, tt.minSize, tt.maxSize)
+			var result []string
+			for _, chunk := range chunker.Chunk(tt.data) {
+				result = append(result, string(chunk.Data))
+			}
+			require.Equal(t, tt.expected, result)
+		})
+	}
+}
+
+func TestParagraphChunker_SizedChunkLineNumbers(t *testing.T) {
+	data := []byte("# Title\n\nshort\n\nline one\nline two\nline three\n")
+	chunker := NewSizedParagraphChunker(0, 10, 20)
+	result := chunker.Chunk(data)
+
+	require.Equal(t, []ChunkResult{
+		{Data: []byte("# Title\n\nshort\n\n"), StartLine: 1, EndLine: 5, StartByte: 0, EndByte: 16, StartColumn: 1, EndColumn: 1},
+		{Data: []byte("line one\nline two\n"), StartLine: 5, EndLine: 7, StartByte: 16, EndByte: 34, StartColumn: 1, EndColumn: 1},
+		{Data: []byte("line three\n"), StartLine: 7, EndLine: 8, StartByte: 34, EndByte: 45, StartColumn: 1, EndColumn: 1},
+	}, result)
+}
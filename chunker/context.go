@@ -0,0 +1,122 @@
+package chunker
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+// DefaultContextHeaderTemplate is used when context headers are enabled
+// without a custom template.
+const DefaultContextHeaderTemplate = "Document: {{.Title}}\n{{if .HeadingPath}}Section: {{.HeadingPath}}\n{{end}}\n"
+
+// ContextHeaderData is the data available to a context header template.
+type ContextHeaderData struct {
+	DocumentName string
+	// Title is the first top-level heading of the document, or its name if there is none.
+	Title string
+	// Headings are the headings the chunk is nested under, outermost first.
+	Headings []string
+	// HeadingPath is Headings joined with " > ".
+	HeadingPath string
+}
+
+// ContextHeader renders a header that is prepended to a chunk before it is
+// embedded, so that chunks carry the topic of the section they come from.
+type ContextHeader struct {
+	tmpl *template.Template
+}
+
+func NewContextHeader(text string) (*ContextHeader, error) {
+	if text == "" {
+		text = DefaultContextHeaderTemplate
+	}
+	tmpl, err := template.New("context_header").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse context header template: %w", err)
+	}
+	return &ContextHeader{tmpl: tmpl}, nil
+}
+
+// Prepend returns the rendered header followed by the chunk data.
+func (c *ContextHeader) Prepend(data ContextHeaderData, chunk []byte) ([]byte, error) {
+	data.HeadingPath = strings.Join(data.Headings, " > ")
+	var buf bytes.Buffer
+	if err := c.tmpl.Execute(&buf, data); err != nil {
+		return nil, fmt.Errorf("failed to render context header: %w", err)
+	}
+	buf.Write(chunk)
+	return buf.Bytes(), nil
+}
+
+type heading struct {
+	line  int
+	level int
+	text  string
+}
+
+// HeadingIndex records the Markdown headings of a document so the heading
+// path of any line can be looked up.
+type HeadingIndex struct {
+	headings []heading
+	// inFence tells whether the text indexed so far ends in a code block.
+	inFence bool
+}
+
+func NewHeadingIndex(data []byte) *HeadingIndex {
+	index := &HeadingIndex{}
+	index.Add(data, 0)
+	return index
+}
+
+// Add records the headings of data, which continues the text indexed so far
+// after its first lineOffset lines, such as the next window of a large text.
+func (h *HeadingIndex) Add(data []byte, lineOffset int) {
+	for i, line := range bytes.Split(data, []byte("\n")) {
+		line = bytes.TrimLeft(line, " ")
+		if bytes.HasPrefix(line, []byte("```")) || bytes.HasPrefix(line, []byte("~~~")) {
+			h.inFence = !h.inFence
+			continue
+		}
+		if h.inFence || !isHeading(line) {
+			continue
+		}
+		level := bytes.IndexFunc(line, func(r rune) bool { return r != '#' })
+		if level < 0 {
+			level = len(line)
+		}
+		text := strings.TrimSpace(strings.TrimRight(strings.TrimSpace(string(line[level:])), "#"))
+		h.headings = append(h.headings, heading{line: lineOffset + i + 1, level: level, text: text})
+	}
+}
+
+// Title returns the text of the first top-level heading, or "" if there is none.
+func (h *HeadingIndex) Title() string {
+	for _, hd := range h.headings {
+		if hd.level == 1 {
+			return hd.text
+		}
+	}
+	return ""
+}
+
+// Path returns the headings in effect at the given line, outermost first.
+// A heading on the line itself is included.
+func (h *HeadingIndex) Path(line int) []string {
+	var stack []heading
+	for _, hd := range h.headings {
+		if hd.line > line {
+			break
+		}
+		for len(stack) > 0 && stack[len(stack)-1].level >= hd.level {
+			stack = stack[:len(stack)-1]
+		}
+		stack = append(stack, hd)
+	}
+	path := make([]string, 0, len(stack))
+	for _, hd := range stack {
+		path = append(path, hd.text)
+	}
+	return path
+}
@@ -0,0 +1,50 @@
+package chunker
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHeadingIndex_Path(t *testing.T) {
+	data := []byte("# Guide\n\nintro\n\n## Install\n\n```sh\n# not a heading\n```\n\n### Linux ###\n\nsteps\n\n## Usage\n\nrun it\n")
+	index := NewHeadingIndex(data)
+
+	require.Equal(t, "Guide", index.Title())
+	require.Equal(t, []string{}, NewHeadingIndex([]byte("no headings")).Path(1))
+	require.Equal(t, []string{"Guide"}, index.Path(3))
+	require.Equal(t, []string{"Guide", "Install"}, index.Path(8))
+	require.Equal(t, []string{"Guide", "Install", "Linux"}, index.Path(13))
+	require.Equal(t, []string{"Guide", "Usage"}, index.Path(15))
+}
+
+func TestHeadingIndex_Add(t *testing.T) {
+	// The text is added in windows, the second starting inside a code block
+	index := NewHeadingIndex([]byte("# Guide\n\n## Install\n\n```sh\n"))
+	index.Add([]byte("# not a heading\n```\n\n### Linux\n\nsteps\n"), 5)
+
+	require.Equal(t, []string{"Guide", "Install"}, index.Path(6))
+	require.Equal(t, []string{"Guide", "Install", "Linux"}, index.Path(11))
+}
+
+func TestContextHeader_Prepend(t *testing.T) {
+	header, err := NewContextHeader("")
+	require.NoError(t, err)
+
+	result, err := header.Prepend(ContextHeaderData{
+		DocumentName: "guide.md",
+		Title:        "Guide",
+		Headings:     []string{"Guide", "Install"},
+	}, []byte("Run the command below."))
+	require.NoError(t, err)
+	require.Equal(t, "Document: Guide\nSection: Guide > Install\n\nRun the command below.", string(result))
+
+	header, err = NewContextHeader("[{{.DocumentName}}] ")
+	require.NoError(t, err)
+	result, err = header.Prepend(ContextHeaderData{DocumentName: "notes.txt"}, []byte("text"))
+	require.NoError(t, err)
+	require.Equal(t, "[notes.txt] text", string(result))
+
+	_, err = NewContextHeader("{{.Broken")
+	require.Error(t, err)
+}
@@ -0,0 +1,169 @@
+package chunker
+
+import (
+	"bytes"
+	"encoding/csv"
+	"io"
+	"regexp"
+)
+
+// TableChunker splits tabular data into chunks of RowsPerChunk rows, each
+// prefixed with the header row so every chunk describes itself. CSV and TSV
+// data is treated as a single table. In Markdown, tables are cut out of the
+// text and the text around them is split by Text, as is text in any other
+// format.
+type TableChunker struct {
+	RowsPerChunk int
+	Text         Chunker
+}
+
+func NewTableChunker(rowsPerChunk int, text Chunker) *TableChunker {
+	return &TableChunker{
+		RowsPerChunk: rowsPerChunk,
+		Text:         text,
+	}
+}
+
+// Chunk splits data of an unknown format, which holds no tables, with Text.
+func (tc *TableChunker) Chunk(data []byte) []ChunkResult {
+	return tc.ChunkFormat(data, "")
+}
+
+func (tc *TableChunker) ChunkFormat(data []byte, format string) []ChunkResult {
+	switch format {
+	case FormatCSV, FormatTSV:
+		delimiter := ','
+		if format == FormatTSV {
+			delimiter = '\t'
+		}
+		if chunks, err := tc.chunkDelimited(data, delimiter); err == nil {
+			return chunks
+		}
+	case FormatMarkdown:
+		return tc.chunkMarkdown(data)
+	}
+	return tc.Text.Chunk(data)
+}
+
+func (tc *TableChunker) chunkDelimited(data []byte, delimiter rune) ([]ChunkResult, error) {
+	r := csv.NewReader(bytes.NewReader(data))
+	r.Comma = delimiter
+	r.FieldsPerRecord = -1
+	r.LazyQuotes = true
+
+	if _, err := r.Read(); err != nil {
+		return nil, err
+	}
+	headerEnd := int(r.InputOffset())
+	header := data[:headerEnd]
+
+	var rowEnds []int
+	for {
+		_, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		rowEnds = append(rowEnds, int(r.InputOffset()))
+	}
+	if len(rowEnds) == 0 {
+		return []ChunkResult{newChunkResult(data, 0, len(data))}, nil
+	}
+	// Keep anything after the last record, e.g. a trailing blank line
+	rowEnds[len(rowEnds)-1] = len(data)
+
+	return tc.chunkRows(data, 0, header, rowEnds), nil
+}
+
+// chunkRows groups rows into chunks. The table starts at tableStart with the
+// header, rowEnds holds the end offset of every row after it.
+func (tc *TableChunker) chunkRows(data []byte, tableStart int, header []byte, rowEnds []int) []ChunkResult {
+	rowsPerChunk := tc.RowsPerChunk
+	if rowsPerChunk <= 0 {
+		rowsPerChunk = len(rowEnds)
+	}
+
+	var chunks []ChunkResult
+	start := tableStart
+	for i := 0; i < len(rowEnds); i += rowsPerChunk {
+		end := rowEnds[min(i+rowsPerChunk, len(rowEnds))-1]
+		chunk := newChunkResult(data, start, end)
+		// The first chunk already starts with the header
+		if start != tableStart {
+			chunk.Data = append(append([]byte{}, header...), chunk.Data...)
+		}
+		chunks = append(chunks, chunk)
+		start = end
+	}
+	return chunks
+}
+
+var markdownTableSeparator = regexp.MustCompile(`^\s*\|?\s*:?-+:?\s*(\|\s*:?-+:?\s*)*\|?\s*$`)
+
+// splitLineSpans returns the span of every line including its trailing newline.
+func splitLineSpans(data []byte) []span {
+	var lines []span
+	for start := 0; start < len(data); {
+		end := bytes.IndexByte(data[start:], '\n')
+		if end < 0 {
+			end = len(data)
+		} else {
+			end += start + 1
+		}
+		lines = append(lines, span{start: start, end: end})
+		start = end
+	}
+	return lines
+}
+
+func isTableRow(line []byte) bool {
+	return bytes.IndexByte(line, '|') >= 0 && len(bytes.TrimSpace(line)) > 0
+}
+
+func (tc *TableChunker) chunkMarkdown(data []byte) []ChunkResult {
+	var chunks []ChunkResult
+	lines := splitLineSpans(data)
+	textStart := 0
+	for i := 0; i+1 < len(lines); i++ {
+		headerLine := data[lines[i].start:lines[i].end]
+		separatorLine := bytes.TrimRight(data[lines[i+1].start:lines[i+1].end], "\r\n")
+		if !isTableRow(headerLine) || !bytes.ContainsRune(separatorLine, '-') || !markdownTableSeparator.Match(separatorLine) {
+			continue
+		}
+
+		tableStart := lines[i].start
+		header := data[tableStart:lines[i+1].end]
+		var rowEnds []int
+		j := i + 2
+		for ; j < len(lines) && isTableRow(data[lines[j].start:lines[j].end]); j++ {
+			rowEnds = append(rowEnds, lines[j].end)
+		}
+		if len(rowEnds) == 0 {
+			continue
+		}
+
+		chunks = append(chunks, tc.chunkText(data, textStart, tableStart)...)
+		chunks = append(chunks, tc.chunkRows(data, tableStart, header, rowEnds)...)
+		textStart = rowEnds[len(rowEnds)-1]
+		i = j - 1
+	}
+	return append(chunks, tc.chunkText(data, textStart, len(data))...)
+}
+
+// chunkText splits the text between tables with the Text chunker.
+func (tc *TableChunker) chunkText(data []byte, start, end int) []ChunkResult {
+	if start >= end {
+		return nil
+	}
+	segment := newChunkResult(data, start, end)
+	chunks := tc.Text.Chunk(segment.Data)
+	for i := range chunks {
+		chunks[i] = shiftChunkResult(chunks[i], segment)
+		for j := range chunks[i].Children {
+			chunks[i].Children[j] = shiftChunkResult(chunks[i].Children[j], segment)
+		}
+	}
+	return chunks
+}
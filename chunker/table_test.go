@@ -0,0 +1,62 @@
+package chunker
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTableChunker_CSV(t *testing.T) {
+	data := []byte("id,name\n1,alpha\n2,\"beta, quoted\"\n3,gamma\n")
+	chunker := NewTableChunker(2, NewParagraphChunker(0))
+	result := chunker.ChunkFormat(data, FormatCSV)
+
+	require.Equal(t, []ChunkResult{
+		{Data: []byte("id,name\n1,alpha\n2,\"beta, quoted\"\n"), StartLine: 1, EndLine: 4, StartByte: 0, EndByte: 33, StartColumn: 1, EndColumn: 1},
+		{Data: []byte("id,name\n3,gamma\n"), StartLine: 4, EndLine: 5, StartByte: 33, EndByte: 41, StartColumn: 1, EndColumn: 1},
+	}, result)
+}
+
+func TestTableChunker_TSV(t *testing.T) {
+	data := []byte("a\tb\n1\t2\n3\t4\n5\t6")
+	chunker := NewTableChunker(1, NewParagraphChunker(0))
+	result := chunker.ChunkFormat(data, FormatTSV)
+
+	require.Len(t, result, 3)
+	require.Equal(t, "a\tb\n1\t2\n", string(result[0].Data))
+	require.Equal(t, "a\tb\n3\t4\n", string(result[1].Data))
+	require.Equal(t, "a\tb\n5\t6", string(result[2].Data))
+	require.Equal(t, 4, result[2].StartLine)
+	require.Equal(t, 4, result[2].EndLine)
+}
+
+func TestTableChunker_MarkdownTable(t *testing.T) {
+	data := []byte("Intro text.\n\n| Name | Age |\n| --- | ---: |\n| Ann | 30 |\n| Bob | 40 |\n| Cid | 50 |\n\nOutro.\n")
+	chunker := NewTableChunker(2, NewParagraphChunker(0))
+	result := chunker.ChunkFormat(data, FormatMarkdown)
+
+	require.Len(t, result, 4)
+	require.Equal(t, ChunkResult{Data: []byte("Intro text.\n\n"), StartLine: 1, EndLine: 3, StartByte: 0, EndByte: 13, StartColumn: 1, EndColumn: 1}, result[0])
+	require.Equal(t, "| Name | Age |\n| --- | ---: |\n| Ann | 30 |\n| Bob | 40 |\n", string(result[1].Data))
+	require.Equal(t, 3, result[1].StartLine)
+	require.Equal(t, 7, result[1].EndLine)
+	require.Equal(t, "| Name | Age |\n| --- | ---: |\n| Cid | 50 |\n", string(result[2].Data))
+	require.Equal(t, 7, result[2].StartLine)
+	require.Equal(t, 8, result[2].EndLine)
+	require.Equal(t, ChunkResult{Data: []byte("\nOutro.\n"), StartLine: 8, EndLine: 10, StartByte: 82, EndByte: 90, StartColumn: 1, EndColumn: 1}, result[3])
+}
+
+func TestTableChunker_PlainTextUsesTextChunker(t *testing.T) {
+	data := []byte("First para.\n\nSecond para, with a comma.\n\n")
+	chunker := NewTableChunker(10, NewParagraphChunker(0))
+	require.Equal(t, NewParagraphChunker(0).Chunk(data), chunker.Chunk(data))
+
+	// Prose that parses as CSV, or holds a Markdown table, isn't a table
+	// unless its format says so
+	data = []byte("Hello, world.\n\nGoodbye, friends.\n")
+	chunker = NewTableChunker(1, NewParagraphChunker(0))
+	require.Equal(t, NewParagraphChunker(0).Chunk(data), chunker.Chunk(data))
+	data = []byte("| a | b |\n| --- | --- |\n| 1 | 2 |\n| 3 | 4 |\n")
+	require.Equal(t, NewParagraphChunker(0).Chunk(data), chunker.ChunkFormat(data, ""))
+	require.Len(t, chunker.ChunkFormat(data, FormatMarkdown), 2)
+}
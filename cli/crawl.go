@@ -0,0 +1,44 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/MaxIvanyshen/local-rag/service"
+)
+
+func crawl(serverURL string, args []string) {
+	flags := flag.NewFlagSet("crawl", flag.ExitOnError)
+	depth := flags.Int("depth", -1, "How many links away from the start page to go, the server's setting when negative")
+	maxPages := flags.Int("max-pages", 0, "Stop after this many pages, the server's setting when zero")
+	flags.Usage = func() {
+		fmt.Println("Usage: rag crawl [flags] <url>")
+		fmt.Println("The URL is a page or a sitemap.xml.")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		flags.Usage()
+		os.Exit(1)
+	}
+
+	req := service.CrawlSiteRequest{URL: flags.Arg(0), MaxPages: *maxPages}
+	if *depth >= 0 {
+		req.MaxDepth = depth
+	}
+	var result service.CrawlSiteResponse
+	if err := postJSON(serverURL+"/api/crawl_site", req, &result); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("Crawled %s.\n", req.URL)
+	fmt.Printf("Processed: %d, unchanged: %d, deleted: %d, skipped: %d, failed: %d\n", result.Processed, result.Unchanged, result.Deleted, result.Skipped, len(result.FailedDocuments))
+	for _, name := range result.FailedDocuments {
+		fmt.Printf("  failed: %s\n", name)
+	}
+	if len(result.FailedDocuments) > 0 {
+		os.Exit(1)
+	}
+}
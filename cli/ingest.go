@@ -0,0 +1,297 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/MaxIvanyshen/local-rag/extractor"
+	"github.com/MaxIvanyshen/local-rag/ignore"
+	"github.com/MaxIvanyshen/local-rag/service"
+)
+
+// documentFormats are the binary formats the server extracts text from.
+// Other binary files aren't sent.
+var documentFormats = extractor.NewRegistry(
+	&extractor.PDFExtractor{},
+	&extractor.DOCXExtractor{},
+	&extractor.ODTExtractor{},
+	&extractor.PPTXExtractor{},
+	&extractor.XLSXExtractor{},
+	&extractor.EPUBExtractor{},
+	&extractor.ArchiveExtractor{},
+)
+
+// listFlag collects the values of a repeated flag.
+type listFlag []string
+
+func (l *listFlag) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *listFlag) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+// ingester walks a directory tree and sends its files to the server in
+// batches bounded by file count and size.
+type ingester struct {
+	serverURL  string
+	root       string
+	filter     *ignore.Filter
+	batchFiles int
+	batchBytes int
+	force      bool
+
+	batch     []*service.ProcessDocumentRequest
+	size      int
+	indexed   int
+	unchanged int
+	skipped   int
+	failed    []string
+	serverErr error
+}
+
+func ingest(serverURL string, args []string) {
+	flags := flag.NewFlagSet("ingest", flag.ExitOnError)
+	var includes, excludes listFlag
+	flags.Var(&includes, "include", "Only ingest files matching this glob, in .gitignore syntax. Can be repeated")
+	flags.Var(&excludes, "exclude", "Skip files matching this glob, in .gitignore syntax. Can be repeated")
+	batchFiles := flags.Int("batch-files", 20, "Maximum number of files sent in one request")
+	batchBytes := flags.Int("batch-bytes", 16<<20, "Maximum size of the files sent in one request, a larger file is sent on its own")
+	force := flags.Bool("force", false, "Send and re-embed all files, also the ones indexed with the same content")
+	flags.Usage = func() {
+		fmt.Println("Usage: rag ingest [flags] <dir>")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		flags.Usage()
+		os.Exit(1)
+	}
+
+	// Documents are named by absolute paths, as filesystem sources name them
+	root, err := filepath.Abs(flags.Arg(0))
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	in := &ingester{
+		serverURL:  serverURL,
+		root:       root,
+		filter:     ignore.NewFilter(root, includes, excludes),
+		batchFiles: max(*batchFiles, 1),
+		batchBytes: *batchBytes,
+		force:      *force,
+	}
+	if err := in.run(); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("Indexed: %d, unchanged: %d, skipped: %d, failed: %d\n", in.indexed, in.unchanged, in.skipped, len(in.failed))
+	for _, name := range in.failed {
+		fmt.Printf("  failed: %s\n", name)
+	}
+	if len(in.failed) > 0 {
+		os.Exit(1)
+	}
+}
+
+func (in *ingester) run() error {
+	info, err := os.Stat(in.root)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("%s is not a directory", in.root)
+	}
+
+	err = in.filter.Walk(in.root, func(path, rel string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if path == in.root {
+				return err
+			}
+			in.failed = append(in.failed, path)
+			return nil
+		}
+		if in.serverErr != nil {
+			return in.serverErr
+		}
+		if d.IsDir() {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			in.failed = append(in.failed, path)
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			in.failed = append(in.failed, path)
+			return nil
+		}
+		if extractor.IsBinary(data) && !documentFormats.Supports(path, data) {
+			in.skipped++
+			return nil
+		}
+		in.add(path, data, info.ModTime())
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	in.flush()
+	return in.serverErr
+}
+
+// add queues a file, sending the queued files first when it doesn't fit in the batch.
+func (in *ingester) add(name string, data []byte, modTime time.Time) {
+	if len(in.batch) > 0 && (len(in.batch) >= in.batchFiles || in.size+len(data) > in.batchBytes) {
+		in.flush()
+	}
+	in.batch = append(in.batch, &service.ProcessDocumentRequest{
+		DocumentName: name,
+		DocumentData: data,
+		ModTime:      &modTime,
+		Force:        in.force,
+	})
+	in.size += len(data)
+}
+
+// flush sends the queued files, leaving out the ones the server has indexed
+// with the same content.
+func (in *ingester) flush() {
+	if len(in.batch) == 0 || in.serverErr != nil {
+		return
+	}
+	batch := in.batch
+	in.batch, in.size = nil, 0
+
+	if !in.force {
+		changed, err := changedDocuments(in.serverURL, batch)
+		if errors.Is(err, errServiceNotRunning) {
+			in.serverErr = err
+			return
+		}
+		// Without the check every file is sent, and the server skips unchanged ones
+		if err != nil {
+			fmt.Printf("Error checking batch: %v\n", err)
+		} else {
+			in.unchanged += len(batch) - len(changed)
+			batch = changed
+		}
+		if len(batch) == 0 {
+			return
+		}
+	}
+
+	result, err := sendBatch(in.serverURL, batch)
+	if errors.Is(err, errServiceNotRunning) {
+		in.serverErr = err
+		return
+	}
+	if err != nil {
+		fmt.Printf("Error sending batch: %v\n", err)
+		for _, req := range batch {
+			in.failed = append(in.failed, req.DocumentName)
+		}
+		return
+	}
+	in.indexed += len(batch) - len(result.FailedDocuments) - len(result.UnchangedDocuments) - len(result.SkippedDocuments)
+	in.unchanged += len(result.UnchangedDocuments)
+	in.skipped += len(result.SkippedDocuments)
+	in.failed = append(in.failed, result.FailedDocuments...)
+	for _, doc := range append(result.SkippedDocuments, result.SkippedEntries...) {
+		fmt.Printf("Skipped %s: %s\n", doc.DocumentName, doc.Reason)
+	}
+	for _, warning := range result.Warnings {
+		fmt.Printf("Warning for %s: %s\n", warning.DocumentName, warning.Warning)
+	}
+	fmt.Printf("Sent %d files, %d failed\n", len(batch), len(result.FailedDocuments))
+}
+
+var errServiceNotRunning = errors.New("service appears to be not running. Please start the server first")
+
+// changedDocuments returns the documents of the batch that the server hasn't
+// indexed with the same content, without uploading them.
+func changedDocuments(serverURL string, batch []*service.ProcessDocumentRequest) ([]*service.ProcessDocumentRequest, error) {
+	manifest := service.CheckDocumentsRequest{Documents: make(map[string]string, len(batch))}
+	for _, req := range batch {
+		manifest.Documents[req.DocumentName] = service.ContentHash(req.DocumentData)
+	}
+	var result service.CheckDocumentsResponse
+	if err := postJSON(serverURL+"/api/check_documents", manifest, &result); err != nil {
+		return nil, err
+	}
+
+	changed := make(map[string]bool, len(result.Changed))
+	for _, name := range result.Changed {
+		changed[name] = true
+	}
+	var docs []*service.ProcessDocumentRequest
+	for _, req := range batch {
+		if changed[req.DocumentName] {
+			docs = append(docs, req)
+		}
+	}
+	return docs, nil
+}
+
+// sendBatch processes documents in one request.
+func sendBatch(serverURL string, batch []*service.ProcessDocumentRequest) (*service.BatchProcessResponse, error) {
+	var result service.BatchProcessResponse
+	if err := postJSON(serverURL+"/api/batch_process_documents", service.BatchProcessDocumentsRequest{Documents: batch}, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// postJSON sends a request to the server and decodes its response into res.
+func postJSON(url string, req, res any) error {
+	return postJSONContext(context.Background(), url, req, res)
+}
+
+// postJSONContext is postJSON with a context that cancels the request.
+func postJSONContext(ctx context.Context, url string, req, res any) error {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewBuffer(body))
+	if err != nil {
+		return err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(httpReq)
+	if err != nil {
+		if strings.Contains(err.Error(), "connection refused") || strings.Contains(err.Error(), "dial tcp") {
+			return errServiceNotRunning
+		}
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("server error: %s - %s", resp.Status, string(body))
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(res); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
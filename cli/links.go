@@ -0,0 +1,33 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/MaxIvanyshen/local-rag/service"
+)
+
+func links(serverURL, name string) {
+	var result service.DocumentLinksResponse
+	if err := postJSON(serverURL+"/api/document_links", service.DocumentLinksRequest{DocumentName: name}, &result); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("Links (%d):\n", len(result.Links))
+	for _, link := range result.Links {
+		prefix := ""
+		if link.Embed {
+			prefix = "!"
+		}
+		target := link.DocumentName
+		if target == "" {
+			target = "not indexed"
+		}
+		fmt.Printf("  %s[[%s]] -> %s\n", prefix, link.Target, target)
+	}
+	fmt.Printf("Backlinks (%d):\n", len(result.Backlinks))
+	for _, link := range result.Backlinks {
+		fmt.Printf("  %s\n", link.DocumentName)
+	}
+}
@@ -8,8 +8,11 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"os"
+	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/MaxIvanyshen/local-rag/config"
 	"github.com/MaxIvanyshen/local-rag/db"
@@ -23,16 +26,25 @@ func main() {
 
 	var serverURL string
 	flag.StringVar(&serverURL, "url", url, "URL of the local RAG service")
+	filters := make(filterFlag)
+	flag.Var(filters, "filter", "Only search documents with this metadata, as key=value. Can be repeated")
+	boostLinks := flag.Bool("boost-links", false, "Rank documents linked from the top search hits higher")
 	flag.Parse()
 
 	args := flag.Args()
 	if len(args) < 1 {
 		fmt.Println("Usage: rag <command> [args...]")
 		fmt.Println("Commands:")
-		fmt.Println("  search <query>           - Search for documents")
-		fmt.Println("  process <filename>       - Process a single document")
+		fmt.Println("  search <query>           - Search for documents, see -filter and -boost-links")
+		fmt.Println("  process <filename>       - Process a single document or a zip/tar(.gz) archive")
 		fmt.Println("  delete <name>            - Delete a document by name")
 		fmt.Println("  batch <filename>...      - Process multiple documents")
+		fmt.Println("  ingest [flags] <dir>     - Process the files of a directory tree, see rag ingest -h")
+		fmt.Println("  git-sync <repo> [ref]    - Index a git repository at a ref, HEAD by default")
+		fmt.Println("  watch [flags] <dir>...   - Re-index files of directories as they change, see rag watch -h")
+		fmt.Println("  crawl [flags] <url>      - Index the pages of a website, see rag crawl -h")
+		fmt.Println("  links <name>             - Show the links and backlinks of a document")
+		fmt.Println("  sync [source]...         - Sync the configured sources, all of them by default")
 		os.Exit(1)
 	}
 
@@ -44,7 +56,7 @@ func main() {
 			os.Exit(1)
 		}
 		query := args[1]
-		search(serverURL, query)
+		search(serverURL, query, filters, *boostLinks)
 	case "process":
 		if len(args) < 2 {
 			fmt.Println("Usage: rag process <filename>")
@@ -66,14 +78,77 @@ func main() {
 		}
 		filenames := args[1:]
 		batchProcess(serverURL, filenames)
+	case "ingest":
+		ingest(serverURL, args[1:])
+	case "git-sync":
+		if len(args) < 2 {
+			fmt.Println("Usage: rag git-sync <repo> [ref]")
+			os.Exit(1)
+		}
+		ref := ""
+		if len(args) > 2 {
+			ref = args[2]
+		}
+		gitSync(serverURL, args[1], ref)
+	case "watch":
+		watch(serverURL, args[1:])
+	case "crawl":
+		crawl(serverURL, args[1:])
+	case "sync":
+		syncSources(serverURL, args[1:])
+	case "links":
+		if len(args) < 2 {
+			fmt.Println("Usage: rag links <name>")
+			os.Exit(1)
+		}
+		links(serverURL, args[1])
 	default:
 		fmt.Printf("Unknown command: %s\n", command)
 		os.Exit(1)
 	}
 }
 
-func search(serverURL, query string) {
-	req := service.SearchRequest{Query: query}
+// filterFlag collects repeated key=value search filters. Repeating a key
+// requires all of its values, e.g. two tags.
+type filterFlag map[string]any
+
+func (f filterFlag) String() string {
+	return fmt.Sprint(map[string]any(f))
+}
+
+func (f filterFlag) Set(value string) error {
+	key, raw, ok := strings.Cut(value, "=")
+	if !ok || key == "" {
+		return fmt.Errorf("filter must be key=value, got %q", value)
+	}
+	v := filterValue(raw)
+	switch existing := f[key].(type) {
+	case nil:
+		f[key] = v
+	case []any:
+		f[key] = append(existing, v)
+	default:
+		f[key] = []any{existing, v}
+	}
+	return nil
+}
+
+// filterValue reads a filter value as a JSON number, boolean or string, so
+// draft=false and priority=2 match the front matter values they stand for,
+// and "2" in quotes matches a string. Anything else is taken as a string.
+func filterValue(value string) any {
+	var parsed any
+	if err := json.Unmarshal([]byte(value), &parsed); err == nil {
+		switch parsed.(type) {
+		case bool, float64, string:
+			return parsed
+		}
+	}
+	return value
+}
+
+func search(serverURL, query string, filters filterFlag, boostLinks bool) {
+	req := service.SearchRequest{Query: query, Filters: filters, BoostLinks: boostLinks}
 	body, err := json.Marshal(req)
 	if err != nil {
 		fmt.Printf("Error marshaling request: %v\n", err)
@@ -121,23 +196,24 @@ func search(serverURL, query string) {
 }
 
 func process(serverURL, filename string) {
-	data, err := os.ReadFile(filename)
+	// Stream the file, so large documents aren't held in memory
+	f, err := os.Open(filename)
 	if err != nil {
 		fmt.Printf("Error reading file: %v\n", err)
 		os.Exit(1)
 	}
-
-	req := service.ProcessDocumentRequest{
-		DocumentName: filename,
-		DocumentData: data,
-	}
-	body, err := json.Marshal(req)
+	defer f.Close()
+	info, err := f.Stat()
 	if err != nil {
-		fmt.Printf("Error marshaling request: %v\n", err)
+		fmt.Printf("Error reading file: %v\n", err)
 		os.Exit(1)
 	}
 
-	resp, err := http.Post(serverURL+"/api/process_document", "application/json", bytes.NewBuffer(body))
+	query := url.Values{
+		"name":     {filename},
+		"mod_time": {info.ModTime().Format(time.RFC3339Nano)},
+	}
+	resp, err := http.Post(serverURL+"/api/upload_document?"+query.Encode(), "application/octet-stream", f)
 	if err != nil {
 		if strings.Contains(err.Error(), "connection refused") || strings.Contains(err.Error(), "dial tcp") {
 			fmt.Printf("Error: Service appears to be not running. Please start the server first.\n")
@@ -154,13 +230,23 @@ func process(serverURL, filename string) {
 		os.Exit(1)
 	}
 
-	var success service.SuccessResponse
+	var success service.ProcessDocumentResponse
 	if err := json.NewDecoder(resp.Body).Decode(&success); err != nil {
 		fmt.Printf("Error decoding response: %v\n", err)
 		os.Exit(1)
 	}
 
-	if success.Success {
+	for _, warning := range success.Warnings {
+		fmt.Printf("Warning: %s\n", warning)
+	}
+	for _, doc := range success.SkippedDocuments {
+		fmt.Printf("Skipped %s: %s\n", doc.DocumentName, doc.Reason)
+	}
+	if success.Success && success.Status == service.StatusUnchanged {
+		fmt.Println("Document is unchanged.")
+	} else if success.Success && success.Status == service.StatusSkipped {
+		fmt.Printf("Document skipped: %s\n", success.Reason)
+	} else if success.Success {
 		fmt.Println("Document processed successfully.")
 	} else {
 		fmt.Println("Document processing failed.")
@@ -246,16 +332,74 @@ func batchProcess(serverURL string, filenames []string) {
 		os.Exit(1)
 	}
 
-	var success service.SuccessResponse
-	if err := json.NewDecoder(resp.Body).Decode(&success); err != nil {
+	var result service.BatchProcessResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
 		fmt.Printf("Error decoding response: %v\n", err)
 		os.Exit(1)
 	}
 
-	if success.Success {
-		fmt.Printf("Batch processed %d documents successfully.\n", len(filenames))
-	} else {
-		fmt.Println("Batch processing failed.")
+	processed := len(filenames) - len(result.FailedDocuments) - len(result.UnchangedDocuments) - len(result.SkippedDocuments)
+	fmt.Printf("Processed: %d, unchanged: %d, skipped: %d, failed: %d\n", processed, len(result.UnchangedDocuments), len(result.SkippedDocuments), len(result.FailedDocuments))
+	for _, doc := range result.SkippedDocuments {
+		fmt.Printf("  skipped: %s (%s)\n", doc.DocumentName, doc.Reason)
+	}
+	for _, doc := range result.SkippedEntries {
+		fmt.Printf("  skipped: %s (%s)\n", doc.DocumentName, doc.Reason)
+	}
+	for _, warning := range result.Warnings {
+		fmt.Printf("  warning: %s (%s)\n", warning.DocumentName, warning.Warning)
+	}
+	for _, name := range result.FailedDocuments {
+		fmt.Printf("  failed: %s\n", name)
+	}
+	if len(result.FailedDocuments) > 0 {
+		os.Exit(1)
+	}
+}
+
+func gitSync(serverURL, repoPath, ref string) {
+	// The server resolves the path, so it must not depend on our working directory
+	absPath, err := filepath.Abs(repoPath)
+	if err != nil {
+		fmt.Printf("Error resolving path: %v\n", err)
+		os.Exit(1)
+	}
+
+	body, err := json.Marshal(service.SyncGitRepositoryRequest{RepoPath: absPath, Ref: ref})
+	if err != nil {
+		fmt.Printf("Error marshaling request: %v\n", err)
+		os.Exit(1)
+	}
+
+	resp, err := http.Post(serverURL+"/api/sync_git_repository", "application/json", bytes.NewBuffer(body))
+	if err != nil {
+		if strings.Contains(err.Error(), "connection refused") || strings.Contains(err.Error(), "dial tcp") {
+			fmt.Printf("Error: Service appears to be not running. Please start the server first.\n")
+		} else {
+			fmt.Printf("Error making request: %v\n", err)
+		}
+		os.Exit(1)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		fmt.Printf("Server error: %s - %s\n", resp.Status, string(body))
+		os.Exit(1)
+	}
+
+	var result service.SyncGitRepositoryResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		fmt.Printf("Error decoding response: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("Synced %s at %.12s.\n", absPath, result.Commit)
+	fmt.Printf("Processed: %d, unchanged: %d, deleted: %d, skipped: %d, failed: %d\n", result.Processed, result.Unchanged, result.Deleted, result.Skipped, len(result.FailedDocuments))
+	for _, name := range result.FailedDocuments {
+		fmt.Printf("  failed: %s\n", name)
+	}
+	if len(result.FailedDocuments) > 0 {
 		os.Exit(1)
 	}
 }
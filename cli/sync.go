@@ -0,0 +1,37 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/MaxIvanyshen/local-rag/service"
+)
+
+func syncSources(serverURL string, names []string) {
+	var result service.SyncResponse
+	if err := postJSON(serverURL+"/api/sync", service.SyncRequest{Sources: names}, &result); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	if len(result.Sources) == 0 {
+		fmt.Println("No sources are configured.")
+		return
+	}
+
+	failed := false
+	for _, res := range result.Sources {
+		if res.Error != "" {
+			fmt.Printf("%s: error: %s\n", res.Source, res.Error)
+			failed = true
+			continue
+		}
+		fmt.Printf("%s: added: %d, updated: %d, unchanged: %d, deleted: %d, skipped: %d, failed: %d\n", res.Source, res.Added, res.Updated, res.Unchanged, res.Deleted, res.Skipped, len(res.FailedDocuments))
+		for _, name := range res.FailedDocuments {
+			fmt.Printf("  failed: %s\n", name)
+		}
+		failed = failed || len(res.FailedDocuments) > 0
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
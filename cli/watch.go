@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+
+	"github.com/MaxIvanyshen/local-rag/service"
+	"github.com/MaxIvanyshen/local-rag/watcher"
+)
+
+func watch(serverURL string, args []string) {
+	flags := flag.NewFlagSet("watch", flag.ExitOnError)
+	debounce := flags.Duration("debounce", watcher.DefaultDebounce, "How long a file has to go without changes before it is processed")
+	flags.Usage = func() {
+		fmt.Println("Usage: rag watch [flags] <dir>...")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() == 0 {
+		flags.Usage()
+		os.Exit(1)
+	}
+
+	// Documents are named by absolute paths, as rag ingest and filesystem
+	// sources name them
+	roots := make([]string, flags.NArg())
+	for i, dir := range flags.Args() {
+		root, err := filepath.Abs(dir)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		roots[i] = root
+	}
+
+	w, err := watcher.New(roots, &remoteIndexer{serverURL: serverURL}, watcher.Options{
+		Debounce: *debounce,
+		Supports: documentFormats.Supports,
+	})
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	fmt.Printf("Watching %s for changes, press Ctrl+C to stop\n", strings.Join(roots, ", "))
+	if err := w.Run(ctx); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// remoteIndexer sends the files reported by a watcher to the server.
+type remoteIndexer struct {
+	serverURL string
+}
+
+func (r *remoteIndexer) ProcessFile(ctx context.Context, name string, data []byte) error {
+	return r.post(ctx, "/api/process_document", service.ProcessDocumentRequest{
+		DocumentName: name,
+		DocumentData: data,
+	})
+}
+
+func (r *remoteIndexer) DeleteFile(ctx context.Context, name string) error {
+	return r.post(ctx, "/api/delete_document", service.DeleteDocumentRequest{
+		DocumentName: name,
+	})
+}
+
+func (r *remoteIndexer) post(ctx context.Context, path string, req any) error {
+	var success service.SuccessResponse
+	if err := postJSONContext(ctx, r.serverURL+path, req, &success); err != nil {
+		return err
+	}
+	if !success.Success {
+		return errors.New("server reported failure")
+	}
+	return nil
+}
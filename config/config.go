@@ -23,8 +23,24 @@ type Config struct {
 
 	Chunker ChunkerConfig `yaml:"chunker"`
 
+	Extractor ExtractorConfig `yaml:"extractor"`
+
+	Junk JunkConfig `yaml:"junk"`
+
 	BatchProcessing BatchProcessingConfig `yaml:"batch_processing"`
 
+	Upload UploadConfig `yaml:"upload"`
+
+	Git GitConfig `yaml:"git"`
+
+	Watch WatchConfig `yaml:"watch"`
+
+	Crawler CrawlerConfig `yaml:"crawler"`
+
+	// Sources are the places kept in sync with the index by a sync request,
+	// such as `rag sync`.
+	Sources []SourceConfig `yaml:"sources"`
+
 	Extensions ExtensionsConfig `yaml:"extensions"`
 }
 
@@ -32,6 +48,70 @@ type ExtensionsConfig struct {
 	Host string `yaml:"host" env:"LOCAL_RAG_HOST" env-default:"http://localhost"`
 }
 
+// GitConfig selects the files indexed from git repositories.
+type GitConfig struct {
+	// SkipDirs are directory names whose files are never indexed, wherever
+	// they are in the repository, such as vendored dependencies.
+	SkipDirs []string `yaml:"skip_dirs" env:"GIT_SKIP_DIRS" env-separator:"," env-default:"vendor,node_modules,third_party,bower_components"`
+	// MaxFileSize skips files larger than this many bytes, which are usually
+	// generated. Zero disables the limit.
+	MaxFileSize int64 `yaml:"max_file_size" env:"GIT_MAX_FILE_SIZE" env-default:"1048576"`
+}
+
+// WatchConfig makes the server re-index the files of directories as they
+// change.
+type WatchConfig struct {
+	// Dirs are the directories to watch, with their subdirectories. Watching
+	// is off when empty.
+	Dirs []string `yaml:"dirs" env:"WATCH_DIRS" env-separator:","`
+	// DebounceMillis is how long a file has to go without changes before it
+	// is processed, so a burst of saves is processed once.
+	DebounceMillis int `yaml:"debounce_ms" env:"WATCH_DEBOUNCE_MS" env-default:"500"`
+}
+
+// CrawlerConfig bounds the crawls of websites. Requests may lower or raise
+// the depth and page limits of a crawl.
+type CrawlerConfig struct {
+	UserAgent string `yaml:"user_agent" env:"CRAWLER_USER_AGENT" env-default:"local-rag"`
+	// MaxDepth is how many links away from the start page a crawl goes.
+	MaxDepth int `yaml:"max_depth" env:"CRAWLER_MAX_DEPTH" env-default:"3"`
+	// MaxPages stops a crawl after this many pages. Zero disables the limit.
+	MaxPages int `yaml:"max_pages" env:"CRAWLER_MAX_PAGES" env-default:"500"`
+	// MaxPageSize skips pages larger than this many bytes. Zero disables the
+	// limit.
+	MaxPageSize int64 `yaml:"max_page_size" env:"CRAWLER_MAX_PAGE_SIZE" env-default:"10485760"`
+}
+
+// SourceConfig is a place documents are synced from.
+type SourceConfig struct {
+	// Name identifies the source in its sync state and in sync requests.
+	Name string `yaml:"name"`
+	// Type is filesystem, git or http.
+	Type string `yaml:"type"`
+	// Path is the directory of a filesystem source, or any directory in the
+	// repository of a git source, which is indexed as a whole.
+	Path string `yaml:"path"`
+	// Ref is the branch, tag or commit of a git source. It defaults to HEAD.
+	Ref string `yaml:"ref"`
+	// Include and Exclude select the files of a filesystem source with globs
+	// in .gitignore syntax.
+	Include []string `yaml:"include"`
+	Exclude []string `yaml:"exclude"`
+	// URLs are the pages and files of an http source.
+	URLs []string `yaml:"urls"`
+}
+
+// UploadConfig bounds documents streamed to the upload endpoint.
+type UploadConfig struct {
+	// MaxSize rejects uploads larger than this many bytes. Zero disables the
+	// limit. It also bounds the memory taken by uploads in formats that are
+	// read whole, such as PDFs.
+	MaxSize int64 `yaml:"max_size" env:"UPLOAD_MAX_SIZE" env-default:"1073741824"`
+	// TempDir is where uploads are spooled while they are processed. It
+	// defaults to the system temporary directory.
+	TempDir string `yaml:"temp_dir" env:"UPLOAD_TEMP_DIR"`
+}
+
 type BatchProcessingConfig struct {
 	WorkerCount int `yaml:"worker_count" env:"BATCH_WORKER_COUNT" env-default:"4"`
 }
@@ -40,6 +120,76 @@ type ChunkerConfig struct {
 	Type         string `yaml:"type" env:"CHUNKER_TYPE" env-default:"paragraph"`
 	OverlapBytes int    `yaml:"overlap_bytes" env:"CHUNKER_OVERLAP_BYTES" env-default:"0"`
 	ChunkSize    int    `yaml:"chunk_size" env:"CHUNKER_CHUNK_SIZE" env-default:"1000"`
+
+	// MinChunkSize and MaxChunkSize bound paragraph chunks in bytes. Smaller
+	// paragraphs are merged with their neighbours and larger ones are split on
+	// lines. Zero disables the bound.
+	MinChunkSize int `yaml:"min_chunk_size" env:"CHUNKER_MIN_CHUNK_SIZE" env-default:"0"`
+	MaxChunkSize int `yaml:"max_chunk_size" env:"CHUNKER_MAX_CHUNK_SIZE" env-default:"0"`
+
+	// TableRows enables table-aware chunking when greater than zero. CSV, TSV
+	// and Markdown tables are split into chunks of this many rows, each
+	// prefixed with the header row.
+	TableRows int `yaml:"table_rows" env:"CHUNKER_TABLE_ROWS" env-default:"0"`
+
+	// ContextHeaders prepends a header rendered from ContextHeaderTemplate to
+	// every chunk before it is embedded. The stored chunk text is unchanged.
+	ContextHeaders        bool   `yaml:"context_headers" env:"CHUNKER_CONTEXT_HEADERS" env-default:"false"`
+	ContextHeaderTemplate string `yaml:"context_header_template" env:"CHUNKER_CONTEXT_HEADER_TEMPLATE"`
+}
+
+type ExtractorConfig struct {
+	// NotebookOutputs includes the text outputs of Jupyter notebook code cells.
+	NotebookOutputs bool `yaml:"notebook_outputs" env:"EXTRACTOR_NOTEBOOK_OUTPUTS" env-default:"false"`
+
+	// EmailStripQuotes removes quoted replies from email messages.
+	EmailStripQuotes bool `yaml:"email_strip_quotes" env:"EXTRACTOR_EMAIL_STRIP_QUOTES" env-default:"false"`
+
+	JSON JSONExtractorConfig `yaml:"json"`
+
+	Archive ArchiveExtractorConfig `yaml:"archive"`
+}
+
+// JunkConfig selects the documents that are skipped instead of indexed,
+// because their text would only add noise to search results.
+type JunkConfig struct {
+	Enabled bool `yaml:"enabled" env:"JUNK_ENABLED" env-default:"true"`
+	// MaxNonPrintableRatio is the share of control and undecodable
+	// characters above which text is taken as binary. Zero disables the
+	// check, text with NUL bytes is binary anyway.
+	MaxNonPrintableRatio float64 `yaml:"max_non_printable_ratio" env:"JUNK_MAX_NON_PRINTABLE_RATIO" env-default:"0.1"`
+	// MinifiedExtensions are the extensions of code checked for
+	// minification.
+	MinifiedExtensions []string `yaml:"minified_extensions" env:"JUNK_MINIFIED_EXTENSIONS" env-separator:"," env-default:".js,.mjs,.cjs,.css"`
+	// MinifiedLineLength is the average line length from which code is
+	// taken as minified. Zero disables the check.
+	MinifiedLineLength int `yaml:"minified_line_length" env:"JUNK_MINIFIED_LINE_LENGTH" env-default:"300"`
+	// Lockfiles are the file names of dependency lockfiles.
+	Lockfiles []string `yaml:"lockfiles" env:"JUNK_LOCKFILES" env-separator:"," env-default:"package-lock.json,npm-shrinkwrap.json,yarn.lock,pnpm-lock.yaml,bun.lockb,Cargo.lock,Gemfile.lock,Pipfile.lock,poetry.lock,uv.lock,composer.lock,go.sum,mix.lock,Podfile.lock,flake.lock,packages.lock.json"`
+	// GeneratedPatterns are globs matched against the file names of
+	// generated files.
+	GeneratedPatterns []string `yaml:"generated_patterns" env:"JUNK_GENERATED_PATTERNS" env-separator:"," env-default:"*.pb.go,*_pb2.py,*.pb.h,*.pb.cc,*.g.dart,*.Designer.cs,*.map"`
+	// GeneratedMarkers are phrases that mark a file as generated in its
+	// first five lines.
+	GeneratedMarkers []string `yaml:"generated_markers" env:"JUNK_GENERATED_MARKERS" env-separator:"," env-default:"Code generated,@generated,<auto-generated,This file was automatically generated,This file is automatically generated"`
+}
+
+// ArchiveExtractorConfig limits what is unpacked from zip and tar archives.
+// Sizes are uncompressed bytes, zero disables a limit.
+type ArchiveExtractorConfig struct {
+	MaxEntries   int   `yaml:"max_entries" env:"EXTRACTOR_ARCHIVE_MAX_ENTRIES" env-default:"1000"`
+	MaxEntrySize int64 `yaml:"max_entry_size" env:"EXTRACTOR_ARCHIVE_MAX_ENTRY_SIZE" env-default:"52428800"`
+	MaxTotalSize int64 `yaml:"max_total_size" env:"EXTRACTOR_ARCHIVE_MAX_TOTAL_SIZE" env-default:"524288000"`
+}
+
+// JSONExtractorConfig maps the fields of JSON and JSONL records with
+// dot-separated paths such as "fields.summary" or "comments.*.body".
+type JSONExtractorConfig struct {
+	// TextPaths select the indexed fields. When empty the whole record is indexed.
+	TextPaths     []string `yaml:"text_paths" env:"EXTRACTOR_JSON_TEXT_PATHS" env-separator:","`
+	TitlePath     string   `yaml:"title_path" env:"EXTRACTOR_JSON_TITLE_PATH" env-default:"title"`
+	IDPath        string   `yaml:"id_path" env:"EXTRACTOR_JSON_ID_PATH" env-default:"id"`
+	MetadataPaths []string `yaml:"metadata_paths" env:"EXTRACTOR_JSON_METADATA_PATHS" env-separator:","`
 }
 
 type LoggingConfig struct {
@@ -49,6 +199,11 @@ type LoggingConfig struct {
 
 type SearchConfig struct {
 	TopK int `yaml:"top_k" env:"SEARCH_TOP_K" env-default:"5"`
+	// LinkBoost is the share by which the distance of documents linked from
+	// the top hits is lowered, when a search asks for it.
+	LinkBoost float64 `yaml:"link_boost" env:"SEARCH_LINK_BOOST" env-default:"0.2"`
+	// LinkBoostHits is how many of the top hits have their links boosted.
+	LinkBoostHits int `yaml:"link_boost_hits" env:"SEARCH_LINK_BOOST_HITS" env-default:"3"`
 }
 
 type EmbedderConfig struct {
@@ -128,6 +283,13 @@ func LoadConfig() *Config {
 			slog.Error("failed to read config file", slog.String("error", err.Error()))
 		}
 	}
+	cfg.Upload.TempDir = expandHome(cfg.Upload.TempDir)
+	for i, dir := range cfg.Watch.Dirs {
+		cfg.Watch.Dirs[i] = expandHome(dir)
+	}
+	for i := range cfg.Sources {
+		cfg.Sources[i].Path = expandHome(cfg.Sources[i].Path)
+	}
 	return cfg
 }
 
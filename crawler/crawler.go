@@ -0,0 +1,403 @@
+// Package crawler fetches the pages of a website, starting from a page or a
+// sitemap and following links on the same host. It respects robots.txt and
+// makes conditional requests for pages fetched before.
+package crawler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// DefaultUserAgent is sent when Options.UserAgent is empty. Its name is the
+// token looked up in robots.txt.
+const DefaultUserAgent = "local-rag"
+
+const (
+	// maxSitemapDepth bounds how deep sitemap indexes are followed.
+	maxSitemapDepth = 3
+	// maxFileSize bounds robots.txt and sitemaps, which the sitemap protocol
+	// limits to 50MiB.
+	maxFileSize = 50 << 20
+	// maxRedirects is how many redirects a page request follows, as the
+	// default http.Client does.
+	maxRedirects = 10
+)
+
+// ErrPageLimit is returned by Crawl when it stopped at Options.MaxPages with
+// pages left to fetch, so the pages visited are not all the site has.
+var ErrPageLimit = errors.New("crawl stopped at the page limit")
+
+type Options struct {
+	// MaxDepth is how many links away from the start pages the crawler
+	// goes. Zero fetches only the start pages.
+	MaxDepth int
+	// MaxPages stops the crawl after fetching this many pages. Zero
+	// disables the limit.
+	MaxPages int
+	// MaxPageSize skips pages larger than this many bytes. Zero disables
+	// the limit.
+	MaxPageSize int64
+	UserAgent   string
+	// Client defaults to a client with a 30 second timeout.
+	Client *http.Client
+}
+
+// Previous is what an earlier crawl recorded about a page.
+type Previous struct {
+	ETag         string
+	LastModified string
+	Links        []string
+}
+
+// Page is a fetched page.
+type Page struct {
+	URL   string
+	Depth int
+	// StatusCode is the HTTP status of the response, http.StatusNotModified
+	// when the page didn't change since the previous crawl.
+	StatusCode   int
+	ContentType  string
+	Data         []byte
+	ETag         string
+	LastModified string
+	// Links are the pages on the same host the page links to. For a page
+	// that wasn't modified, they are the links recorded before.
+	Links []string
+	// NoIndex is set when a robots meta tag asks not to index the page.
+	NoIndex bool
+	// Err is set when the page couldn't be fetched.
+	Err error
+}
+
+type Crawler struct {
+	opts Options
+}
+
+func New(opts Options) *Crawler {
+	if opts.UserAgent == "" {
+		opts.UserAgent = DefaultUserAgent
+	}
+	if opts.Client == nil {
+		opts.Client = &http.Client{Timeout: 30 * time.Second}
+	}
+	return &Crawler{opts: opts}
+}
+
+type queued struct {
+	url   *url.URL
+	depth int
+}
+
+// Crawl fetches pages breadth first from start, a page or a sitemap.xml, and
+// calls visit for every page fetched, including pages that weren't modified,
+// are gone or failed. previous returns what was recorded for a URL on an
+// earlier crawl, or nil. An error returned by visit stops the crawl.
+// ErrPageLimit is returned after visiting the pages fetched when the page
+// limit left pages unfetched.
+func (c *Crawler) Crawl(ctx context.Context, start string, previous func(string) *Previous, visit func(*Page) error) error {
+	startURL, err := url.Parse(start)
+	if err != nil {
+		return fmt.Errorf("invalid start URL: %w", err)
+	}
+	if (startURL.Scheme != "http" && startURL.Scheme != "https") || startURL.Host == "" {
+		return fmt.Errorf("invalid start URL %q: must be an absolute http or https URL", start)
+	}
+	host := startURL.Host
+
+	rules, err := c.fetchRobots(ctx, startURL)
+	if err != nil {
+		return err
+	}
+
+	seen := make(map[string]bool)
+	var queue []queued
+	enqueue := func(u *url.URL, depth int) {
+		u = normalize(u)
+		if u == nil || u.Host != host || seen[u.String()] {
+			return
+		}
+		seen[u.String()] = true
+		if !rules.allowed(u.RequestURI()) {
+			slog.Debug("skipping page disallowed by robots.txt", slog.String("url", u.String()))
+			return
+		}
+		queue = append(queue, queued{url: u, depth: depth})
+	}
+
+	if isSitemap(startURL.Path) {
+		pages, err := c.sitemapPages(ctx, startURL, 0)
+		if err != nil {
+			return err
+		}
+		for _, p := range pages {
+			if u, err := url.Parse(p); err == nil {
+				enqueue(u, 0)
+			}
+		}
+	} else {
+		enqueue(startURL, 0)
+	}
+
+	fetched := 0
+	for len(queue) > 0 {
+		if c.opts.MaxPages > 0 && fetched >= c.opts.MaxPages {
+			slog.Info("stopping crawl at the page limit", slog.String("url", start), slog.Int("max_pages", c.opts.MaxPages))
+			return ErrPageLimit
+		}
+		next := queue[0]
+		queue = queue[1:]
+
+		if fetched > 0 && rules.delay > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(rules.delay):
+			}
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		var prev *Previous
+		if previous != nil {
+			prev = previous(next.url.String())
+		}
+		page := c.fetch(ctx, next.url, prev, rules)
+		page.Depth = next.depth
+		fetched++
+		// A redirect to a page crawled on its own is left to that page
+		if page.URL != next.url.String() {
+			if seen[page.URL] {
+				continue
+			}
+			seen[page.URL] = true
+		}
+
+		if err := visit(page); err != nil {
+			return err
+		}
+		if next.depth < c.opts.MaxDepth {
+			for _, link := range page.Links {
+				if u, err := url.Parse(link); err == nil {
+					enqueue(u, next.depth+1)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// fetch requests a page, conditionally when it was fetched before. Redirects
+// are followed only to pages on the same host that robots.txt allows.
+func (c *Crawler) fetch(ctx context.Context, u *url.URL, prev *Previous, rules *robots) *Page {
+	page := &Page{URL: u.String()}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, page.URL, nil)
+	if err != nil {
+		page.Err = err
+		return page
+	}
+	req.Header.Set("User-Agent", c.opts.UserAgent)
+	if prev != nil {
+		if prev.ETag != "" {
+			req.Header.Set("If-None-Match", prev.ETag)
+		}
+		if prev.LastModified != "" {
+			req.Header.Set("If-Modified-Since", prev.LastModified)
+		}
+	}
+
+	client := *c.opts.Client
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if len(via) >= maxRedirects {
+			return fmt.Errorf("stopped after %d redirects", maxRedirects)
+		}
+		target := normalize(req.URL)
+		if target == nil || target.Host != u.Host {
+			return fmt.Errorf("redirected to another host: %s", req.URL)
+		}
+		if !rules.allowed(target.RequestURI()) {
+			return fmt.Errorf("redirected to a page disallowed by robots.txt: %s", target)
+		}
+		return nil
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		page.Err = err
+		return page
+	}
+	defer resp.Body.Close()
+
+	// Name the page by where redirects led
+	if final := normalize(resp.Request.URL); final != nil {
+		page.URL = final.String()
+	}
+	page.StatusCode = resp.StatusCode
+	page.ETag = resp.Header.Get("ETag")
+	page.LastModified = resp.Header.Get("Last-Modified")
+
+	switch {
+	case resp.StatusCode == http.StatusNotModified && prev != nil:
+		if page.ETag == "" {
+			page.ETag = prev.ETag
+		}
+		if page.LastModified == "" {
+			page.LastModified = prev.LastModified
+		}
+		page.Links = prev.Links
+		return page
+	case resp.StatusCode < 200 || resp.StatusCode > 299:
+		return page
+	}
+
+	page.Data, err = readBody(resp.Body, c.opts.MaxPageSize)
+	if err != nil {
+		page.Err = err
+		return page
+	}
+	page.ContentType = resp.Header.Get("Content-Type")
+	if mediaType, _, _ := mime.ParseMediaType(page.ContentType); mediaType == "text/html" || mediaType == "application/xhtml+xml" {
+		links, err := parseLinks(resp.Request.URL, page.Data)
+		if err != nil {
+			slog.Warn("failed to parse links of page", slog.String("error", err.Error()), slog.String("url", page.URL))
+			return page
+		}
+		page.NoIndex = links.noIndex
+		if !links.noFollow {
+			page.Links = sameHostLinks(u.Host, links.links)
+		}
+	}
+	return page
+}
+
+// readBody reads a response body of at most limit bytes, or any size when
+// limit is zero.
+func readBody(body io.Reader, limit int64) ([]byte, error) {
+	if limit <= 0 {
+		return io.ReadAll(body)
+	}
+	data, err := io.ReadAll(io.LimitReader(body, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > limit {
+		return nil, fmt.Errorf("response is larger than %d bytes", limit)
+	}
+	return data, nil
+}
+
+// fetchRobots reads the robots.txt rules of the start URL's host. A missing
+// robots.txt allows everything, while a server error stops the crawl, as the
+// rules are unknown.
+func (c *Crawler) fetchRobots(ctx context.Context, start *url.URL) (*robots, error) {
+	robotsURL := &url.URL{Scheme: start.Scheme, Host: start.Host, Path: "/robots.txt"}
+	data, status, err := c.get(ctx, robotsURL.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch robots.txt: %w", err)
+	}
+	switch {
+	case status >= 200 && status <= 299:
+		return parseRobots(data, c.agentToken()), nil
+	case status >= 400 && status <= 499:
+		return &robots{}, nil
+	default:
+		return nil, fmt.Errorf("failed to fetch robots.txt: status %d", status)
+	}
+}
+
+// sitemapPages returns the page URLs of a sitemap, following sitemap indexes
+// on the same host.
+func (c *Crawler) sitemapPages(ctx context.Context, u *url.URL, depth int) ([]string, error) {
+	data, status, err := c.get(ctx, u.String())
+	if err == nil && (status < 200 || status > 299) {
+		err = fmt.Errorf("status %d", status)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch sitemap %s: %w", u, err)
+	}
+	pages, sitemaps, err := parseSitemap(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read sitemap %s: %w", u, err)
+	}
+
+	for _, loc := range sitemaps {
+		nested, err := u.Parse(loc)
+		if err != nil || nested.Host != u.Host {
+			continue
+		}
+		if depth >= maxSitemapDepth {
+			slog.Warn("skipping deeply nested sitemap", slog.String("url", nested.String()))
+			continue
+		}
+		more, err := c.sitemapPages(ctx, nested, depth+1)
+		if err != nil {
+			slog.Warn("failed to read nested sitemap", slog.String("error", err.Error()))
+			continue
+		}
+		pages = append(pages, more...)
+	}
+	return pages, nil
+}
+
+// get fetches a URL that isn't indexed itself, such as robots.txt.
+func (c *Crawler) get(ctx context.Context, rawURL string) ([]byte, int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	req.Header.Set("User-Agent", c.opts.UserAgent)
+	resp, err := c.opts.Client.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+	data, err := readBody(resp.Body, maxFileSize)
+	if err != nil {
+		return nil, 0, err
+	}
+	return data, resp.StatusCode, nil
+}
+
+// agentToken is the product name of the user agent, as matched against the
+// User-agent lines of robots.txt.
+func (c *Crawler) agentToken() string {
+	token, _, _ := strings.Cut(c.opts.UserAgent, "/")
+	return strings.TrimSpace(token)
+}
+
+// normalize drops the fragment of an http or https URL, or returns nil for
+// other URLs.
+func normalize(u *url.URL) *url.URL {
+	if u == nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil
+	}
+	n := *u
+	n.Fragment, n.RawFragment = "", ""
+	if n.Path == "" {
+		n.Path = "/"
+	}
+	return &n
+}
+
+// sameHostLinks returns the distinct links to pages on the host.
+func sameHostLinks(host string, links []*url.URL) []string {
+	seen := make(map[string]bool)
+	var res []string
+	for _, link := range links {
+		u := normalize(link)
+		if u == nil || u.Host != host || seen[u.String()] {
+			continue
+		}
+		seen[u.String()] = true
+		res = append(res, u.String())
+	}
+	return res
+}
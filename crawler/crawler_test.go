@@ -0,0 +1,255 @@
+package crawler
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// testSite serves pages from a map of paths to HTML bodies, with {base}
+// replaced by the server URL. Pages answer conditional requests by their ETag.
+// A body "redirect:<url>" redirects to the URL.
+func testSite(t *testing.T, robotsTxt string, pages map[string]string) (*httptest.Server, *[]string) {
+	var requests []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			if robotsTxt == "" {
+				http.NotFound(w, r)
+				return
+			}
+			fmt.Fprint(w, robotsTxt)
+			return
+		}
+		requests = append(requests, r.URL.RequestURI())
+		body, ok := pages[r.URL.RequestURI()]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		body = strings.ReplaceAll(body, "{base}", "http://"+r.Host)
+		if target, ok := strings.CutPrefix(body, "redirect:"); ok {
+			http.Redirect(w, r, target, http.StatusFound)
+			return
+		}
+		etag := fmt.Sprintf(`"%x"`, len(body))
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", etag)
+		if strings.HasSuffix(r.URL.Path, ".xml") {
+			w.Header().Set("Content-Type", "application/xml")
+		} else {
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		}
+		fmt.Fprint(w, body)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &requests
+}
+
+// crawl returns the pages visited by a crawl, by URL.
+func crawl(t *testing.T, c *Crawler, start string, previous func(string) *Previous) map[string]*Page {
+	pages := make(map[string]*Page)
+	err := c.Crawl(t.Context(), start, previous, func(p *Page) error {
+		pages[p.URL] = p
+		return nil
+	})
+	require.NoError(t, err)
+	return pages
+}
+
+func TestCrawl_FollowsSameHostLinks(t *testing.T) {
+	srv, _ := testSite(t, "User-agent: *\nDisallow: /private/\n", map[string]string{
+		"/":          `<a href="/a">A</a> <a href="b#section">B</a> <a href="/private/x">X</a> <a href="http://other.example/">Other</a> <a href="mailto:me@example.com">Mail</a>`,
+		"/a":         `<a href="/c">C</a> <a href="/" >Home</a>`,
+		"/b":         `<p>B</p>`,
+		"/c":         `<a href="/d">D</a>`,
+		"/d":         `<p>Too deep</p>`,
+		"/private/x": `<p>Private</p>`,
+	})
+
+	pages := crawl(t, New(Options{MaxDepth: 2}), srv.URL+"/", nil)
+	require.ElementsMatch(t, []string{srv.URL + "/", srv.URL + "/a", srv.URL + "/b", srv.URL + "/c"}, keys(pages))
+
+	home := pages[srv.URL+"/"]
+	require.Equal(t, http.StatusOK, home.StatusCode)
+	require.Equal(t, 0, home.Depth)
+	require.Equal(t, []string{srv.URL + "/a", srv.URL + "/b", srv.URL + "/private/x"}, home.Links)
+	require.Equal(t, 2, pages[srv.URL+"/c"].Depth)
+	require.Contains(t, string(pages[srv.URL+"/b"].Data), "<p>B</p>")
+}
+
+func TestCrawl_PageLimit(t *testing.T) {
+	srv, _ := testSite(t, "", map[string]string{
+		"/":  `<a href="/a">A</a> <a href="/b">B</a>`,
+		"/a": `<p>A</p>`,
+		"/b": `<p>B</p>`,
+	})
+	var visited []string
+	err := New(Options{MaxDepth: 5, MaxPages: 2}).Crawl(t.Context(), srv.URL, nil, func(p *Page) error {
+		visited = append(visited, p.URL)
+		return nil
+	})
+	require.ErrorIs(t, err, ErrPageLimit)
+	require.Equal(t, []string{srv.URL + "/", srv.URL + "/a"}, visited)
+
+	// A limit the site fits in isn't reported
+	pages := crawl(t, New(Options{MaxDepth: 5, MaxPages: 3}), srv.URL, nil)
+	require.Len(t, pages, 3)
+}
+
+func TestCrawl_Redirects(t *testing.T) {
+	srv, requests := testSite(t, "User-agent: *\nDisallow: /private/\n", map[string]string{
+		"/":          `<a href="/moved">Moved</a> <a href="/sneaky">Sneaky</a> <a href="/away">Away</a>`,
+		"/moved":     "redirect:/new",
+		"/new":       `<p>New</p>`,
+		"/sneaky":    "redirect:/private/x",
+		"/private/x": `<p>Private</p>`,
+		"/away":      "redirect:http://other.example/",
+	})
+	pages := crawl(t, New(Options{MaxDepth: 1}), srv.URL+"/", nil)
+	require.ElementsMatch(t, []string{srv.URL + "/", srv.URL + "/new", srv.URL + "/sneaky", srv.URL + "/away"}, keys(pages))
+	require.Contains(t, string(pages[srv.URL+"/new"].Data), "<p>New</p>")
+
+	// Redirects to pages robots.txt disallows or to other hosts aren't
+	// followed
+	require.ErrorContains(t, pages[srv.URL+"/sneaky"].Err, "disallowed by robots.txt")
+	require.ErrorContains(t, pages[srv.URL+"/away"].Err, "another host")
+	require.NotContains(t, *requests, "/private/x")
+}
+
+func TestCrawl_RobotsMeta(t *testing.T) {
+	srv, _ := testSite(t, "", map[string]string{
+		"/":         `<a href="/hidden">Hidden</a> <a href="/nofollow">No follow</a> <a rel="nofollow" href="/skipped">Skipped</a>`,
+		"/hidden":   `<meta name="robots" content="noindex"><a href="/deeper">Deeper</a>`,
+		"/nofollow": `<meta name="robots" content="nofollow"><a href="/never">Never</a>`,
+		"/deeper":   `<p>Deeper</p>`,
+	})
+	pages := crawl(t, New(Options{MaxDepth: 5}), srv.URL+"/", nil)
+	require.ElementsMatch(t, []string{srv.URL + "/", srv.URL + "/hidden", srv.URL + "/nofollow", srv.URL + "/deeper"}, keys(pages))
+	require.True(t, pages[srv.URL+"/hidden"].NoIndex)
+	require.False(t, pages[srv.URL+"/nofollow"].NoIndex)
+}
+
+func TestCrawl_Sitemap(t *testing.T) {
+	srv, _ := testSite(t, "User-agent: *\nDisallow: /drafts\n", map[string]string{
+		"/sitemap.xml": `<?xml version="1.0" encoding="UTF-8"?>
+<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <sitemap><loc>/sitemap-docs.xml</loc></sitemap>
+  <sitemap><loc>http://other.example/sitemap.xml</loc></sitemap>
+</sitemapindex>`,
+		"/sitemap-docs.xml": `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <url><loc>{base}/guide</loc></url>
+  <url><loc>{base}/drafts/next</loc></url>
+</urlset>`,
+		"/guide":         `<a href="/guide/install">Install</a>`,
+		"/guide/install": `<p>Install</p>`,
+		"/drafts/next":   `<p>Draft</p>`,
+	})
+
+	pages := crawl(t, New(Options{MaxDepth: 0}), srv.URL+"/sitemap.xml", nil)
+	require.ElementsMatch(t, []string{srv.URL + "/guide"}, keys(pages))
+
+	pages = crawl(t, New(Options{MaxDepth: 1}), srv.URL+"/sitemap.xml", nil)
+	require.ElementsMatch(t, []string{srv.URL + "/guide", srv.URL + "/guide/install"}, keys(pages))
+}
+
+func TestCrawl_ConditionalRequests(t *testing.T) {
+	srv, requests := testSite(t, "", map[string]string{
+		"/":  `<a href="/a">A</a>`,
+		"/a": `<p>A</p>`,
+	})
+	c := New(Options{MaxDepth: 1})
+	first := crawl(t, c, srv.URL+"/", nil)
+	require.Len(t, first, 2)
+	require.NotEmpty(t, first[srv.URL+"/"].ETag)
+
+	// Unmodified pages are reported with the links recorded before, so the
+	// pages they link to are still crawled
+	*requests = nil
+	second := crawl(t, c, srv.URL+"/", func(u string) *Previous {
+		p := first[u]
+		return &Previous{ETag: p.ETag, LastModified: p.LastModified, Links: p.Links}
+	})
+	require.Len(t, second, 2)
+	for _, p := range second {
+		require.Equal(t, http.StatusNotModified, p.StatusCode)
+		require.Nil(t, p.Data)
+	}
+	require.Equal(t, []string{srv.URL + "/a"}, second[srv.URL+"/"].Links)
+	require.Equal(t, []string{"/", "/a"}, *requests)
+}
+
+func TestCrawl_MissingPage(t *testing.T) {
+	srv, _ := testSite(t, "", map[string]string{
+		"/": `<a href="/gone">Gone</a>`,
+	})
+	pages := crawl(t, New(Options{MaxDepth: 1}), srv.URL+"/", nil)
+	require.Equal(t, http.StatusNotFound, pages[srv.URL+"/gone"].StatusCode)
+	require.Nil(t, pages[srv.URL+"/gone"].Err)
+}
+
+func TestCrawl_Errors(t *testing.T) {
+	err := New(Options{}).Crawl(t.Context(), "ftp://example.com/", nil, func(*Page) error { return nil })
+	require.Error(t, err)
+
+	// Unknown robots.txt rules stop the crawl
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+	err = New(Options{}).Crawl(t.Context(), srv.URL, nil, func(*Page) error { return nil })
+	require.ErrorContains(t, err, "robots.txt")
+
+	// Oversized pages are reported as failed
+	big, _ := testSite(t, "", map[string]string{"/": "0123456789"})
+	pages := crawl(t, New(Options{MaxPageSize: 5}), big.URL, nil)
+	require.Error(t, pages[big.URL+"/"].Err)
+}
+
+func TestParseRobots(t *testing.T) {
+	data := []byte(`# comments are ignored
+User-agent: other-bot
+Disallow: /
+
+User-agent: local-rag
+User-agent: another
+Disallow: /private
+Allow: /private/public
+Disallow: /*.pdf$
+Crawl-delay: 0.5
+
+User-agent: *
+Disallow: /everything-else
+`)
+	r := parseRobots(data, "local-rag")
+	require.True(t, r.allowed("/"))
+	require.False(t, r.allowed("/private/notes"))
+	require.True(t, r.allowed("/private/public/page"))
+	require.False(t, r.allowed("/docs/manual.pdf"))
+	require.True(t, r.allowed("/docs/manual.pdf?download=1"))
+	require.True(t, r.allowed("/everything-else"))
+	require.Equal(t, 500*time.Millisecond, r.delay)
+
+	// Agents without a group of their own get the "*" group
+	r = parseRobots(data, "unknown")
+	require.False(t, r.allowed("/everything-else"))
+	require.True(t, r.allowed("/private"))
+
+	require.True(t, parseRobots(nil, "local-rag").allowed("/anything"))
+}
+
+func keys(pages map[string]*Page) []string {
+	var res []string
+	for u := range pages {
+		res = append(res, u)
+	}
+	return res
+}
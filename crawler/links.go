@@ -0,0 +1,91 @@
+package crawler
+
+import (
+	"bytes"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// pageLinks holds what the crawler reads from an HTML page besides its text.
+type pageLinks struct {
+	links []*url.URL
+	// noIndex and noFollow come from a robots meta tag.
+	noIndex  bool
+	noFollow bool
+}
+
+// parseLinks returns the links of an HTML page resolved against its URL, or
+// the page's <base>. Links marked rel="nofollow" are left out.
+func parseLinks(page *url.URL, data []byte) (*pageLinks, error) {
+	root, err := html.Parse(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	res := &pageLinks{}
+	base := page
+	var hrefs []string
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			switch n.DataAtom {
+			case atom.Base:
+				if u, err := page.Parse(attr(n, "href")); err == nil && attr(n, "href") != "" {
+					base = u
+				}
+			case atom.Meta:
+				if strings.EqualFold(attr(n, "name"), "robots") {
+					for _, directive := range strings.Split(strings.ToLower(attr(n, "content")), ",") {
+						switch strings.TrimSpace(directive) {
+						case "noindex":
+							res.noIndex = true
+						case "nofollow":
+							res.noFollow = true
+						case "none":
+							res.noIndex, res.noFollow = true, true
+						}
+					}
+				}
+			case atom.A:
+				if !hasToken(attr(n, "rel"), "nofollow") {
+					if href := attr(n, "href"); href != "" {
+						hrefs = append(hrefs, href)
+					}
+				}
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(root)
+
+	for _, href := range hrefs {
+		if u, err := base.Parse(strings.TrimSpace(href)); err == nil {
+			res.links = append(res.links, u)
+		}
+	}
+	return res, nil
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+// hasToken reports whether a space-separated attribute value holds the token.
+func hasToken(value, token string) bool {
+	for _, field := range strings.Fields(value) {
+		if strings.EqualFold(field, token) {
+			return true
+		}
+	}
+	return false
+}
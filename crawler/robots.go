@@ -0,0 +1,142 @@
+package crawler
+
+import (
+	"bufio"
+	"bytes"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// robots holds the robots.txt rules that apply to the crawler.
+type robots struct {
+	rules []robotsRule
+	delay time.Duration
+}
+
+type robotsRule struct {
+	allow   bool
+	length  int
+	pattern *regexp.Regexp
+}
+
+// parseRobots reads the rules of the group for the user agent token, or of
+// the "*" group when no group names it. Groups naming the same agent are
+// merged.
+func parseRobots(data []byte, agent string) *robots {
+	agent = strings.ToLower(agent)
+
+	type group struct {
+		agents []string
+		rules  []robotsRule
+		delay  time.Duration
+	}
+	var groups []*group
+	var current *group
+	inAgents := false
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+
+		switch key {
+		case "user-agent":
+			// Consecutive user-agent lines start one group
+			if !inAgents {
+				current = &group{}
+				groups = append(groups, current)
+				inAgents = true
+			}
+			current.agents = append(current.agents, strings.ToLower(value))
+		case "allow", "disallow":
+			inAgents = false
+			if current == nil || value == "" {
+				continue
+			}
+			current.rules = append(current.rules, robotsRule{
+				allow:   key == "allow",
+				length:  len(value),
+				pattern: compileRobotsPattern(value),
+			})
+		case "crawl-delay":
+			inAgents = false
+			if current == nil {
+				continue
+			}
+			if seconds, err := strconv.ParseFloat(value, 64); err == nil && seconds > 0 {
+				current.delay = time.Duration(seconds * float64(time.Second))
+			}
+		default:
+			inAgents = false
+		}
+	}
+
+	match := func(name string) *robots {
+		var r *robots
+		for _, g := range groups {
+			for _, a := range g.agents {
+				if a == name {
+					if r == nil {
+						r = &robots{}
+					}
+					r.rules = append(r.rules, g.rules...)
+					r.delay = max(r.delay, g.delay)
+					break
+				}
+			}
+		}
+		return r
+	}
+	if r := match(agent); r != nil {
+		return r
+	}
+	if r := match("*"); r != nil {
+		return r
+	}
+	return &robots{}
+}
+
+// compileRobotsPattern converts a robots.txt path pattern, which may use "*"
+// for any characters and end in "$", into a regular expression.
+func compileRobotsPattern(pattern string) *regexp.Regexp {
+	anchored := strings.HasSuffix(pattern, "$")
+	pattern = strings.TrimSuffix(pattern, "$")
+
+	var sb strings.Builder
+	sb.WriteString("^")
+	for i, part := range strings.Split(pattern, "*") {
+		if i > 0 {
+			sb.WriteString(".*")
+		}
+		sb.WriteString(regexp.QuoteMeta(part))
+	}
+	if anchored {
+		sb.WriteString("$")
+	}
+	return regexp.MustCompile(sb.String())
+}
+
+// allowed reports whether the path, with its query, may be crawled. The
+// longest matching rule wins, and Allow wins a tie.
+func (r *robots) allowed(path string) bool {
+	best, allow := -1, true
+	for _, rule := range r.rules {
+		if !rule.pattern.MatchString(path) {
+			continue
+		}
+		if rule.length > best || (rule.length == best && rule.allow) {
+			best, allow = rule.length, rule.allow
+		}
+	}
+	return allow
+}
@@ -0,0 +1,48 @@
+package crawler
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strings"
+)
+
+// sitemap is either a list of pages or an index of other sitemaps.
+type sitemap struct {
+	XMLName  xml.Name
+	URLs     []sitemapLoc `xml:"url"`
+	Sitemaps []sitemapLoc `xml:"sitemap"`
+}
+
+type sitemapLoc struct {
+	Loc string `xml:"loc"`
+}
+
+// parseSitemap returns the page URLs of a sitemap and the sitemaps listed by
+// a sitemap index.
+func parseSitemap(data []byte) (pages, sitemaps []string, err error) {
+	var sm sitemap
+	if err := xml.Unmarshal(data, &sm); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse sitemap: %w", err)
+	}
+	switch sm.XMLName.Local {
+	case "urlset", "sitemapindex":
+	default:
+		return nil, nil, fmt.Errorf("not a sitemap: unexpected root element %q", sm.XMLName.Local)
+	}
+	for _, u := range sm.URLs {
+		if loc := strings.TrimSpace(u.Loc); loc != "" {
+			pages = append(pages, loc)
+		}
+	}
+	for _, s := range sm.Sitemaps {
+		if loc := strings.TrimSpace(s.Loc); loc != "" {
+			sitemaps = append(sitemaps, loc)
+		}
+	}
+	return pages, sitemaps, nil
+}
+
+// isSitemap reports whether a start URL names a sitemap rather than a page.
+func isSitemap(path string) bool {
+	return strings.HasSuffix(strings.ToLower(path), ".xml")
+}
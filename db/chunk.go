@@ -9,16 +9,12 @@ import (
 	"gorm.io/gorm"
 )
 
-func SaveChunk(ctx context.Context, db *gorm.DB, documentID string, chunkIndex int, startLine, endLine int, data []byte, embedding []float32) error {
-	chunk := Chunk{
-		ID:         uuid.New().String(),
-		DocumentID: documentID,
-		ChunkIndex: chunkIndex,
-		StartLine:  startLine,
-		EndLine:    endLine,
-		Data:       data,
-	}
-	if err := db.WithContext(ctx).Create(&chunk).Error; err != nil {
+// SaveChunk saves a chunk and its embedding. A new ID is generated if the chunk has none.
+func SaveChunk(ctx context.Context, db *gorm.DB, chunk *Chunk, embedding []float32) error {
+	if chunk.ID == "" {
+		chunk.ID = uuid.New().String()
+	}
+	if err := db.WithContext(ctx).Create(chunk).Error; err != nil {
 		return fmt.Errorf("failed to insert chunk: %w", err)
 	}
 
@@ -41,25 +37,108 @@ func SaveChunk(ctx context.Context, db *gorm.DB, documentID string, chunkIndex i
 	}
 
 	// Update the chunk with the embedding rowid
-	if err := db.WithContext(ctx).Model(&chunk).Update("embedding_rowid", embeddingRowID).Error; err != nil {
+	if err := db.WithContext(ctx).Model(chunk).Update("embedding_rowid", embeddingRowID).Error; err != nil {
 		return fmt.Errorf("failed to update chunk with embedding rowid: %w", err)
 	}
 
 	return nil
 }
 
+// SaveParentChunk saves a chunk without an embedding of its own. It is only
+// returned as the context of child chunks that reference it by ParentID.
+func SaveParentChunk(ctx context.Context, db *gorm.DB, chunk *Chunk) error {
+	if chunk.ID == "" {
+		chunk.ID = uuid.New().String()
+	}
+	if err := db.WithContext(ctx).Create(chunk).Error; err != nil {
+		return fmt.Errorf("failed to insert parent chunk: %w", err)
+	}
+	return nil
+}
+
+// GetChunksByDocumentID retrieves the chunks of a document in order.
+func GetChunksByDocumentID(ctx context.Context, db *gorm.DB, documentID string) ([]Chunk, error) {
+	var chunks []Chunk
+	err := db.WithContext(ctx).Raw("SELECT * FROM chunks WHERE document_id = ? ORDER BY chunk_index", documentID).Scan(&chunks).Error
+	if err != nil {
+		return nil, err
+	}
+	return chunks, nil
+}
+
+// UpdateChunk updates where a stored chunk is in its document, keeping its
+// data and embedding.
+func UpdateChunk(ctx context.Context, db *gorm.DB, chunk *Chunk) error {
+	err := db.WithContext(ctx).Model(&Chunk{ID: chunk.ID}).
+		Select("parent_id", "chunk_index", "start_line", "end_line", "start_byte", "end_byte", "start_column", "end_column", "page", "metadata").
+		Updates(chunk).Error
+	if err != nil {
+		return fmt.Errorf("failed to update chunk: %w", err)
+	}
+	return nil
+}
+
+// DeleteChunks deletes chunks and their embeddings.
+func DeleteChunks(ctx context.Context, db *gorm.DB, ids []string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	if err := db.WithContext(ctx).Exec(`
+		DELETE FROM chunk_embeddings
+		WHERE rowid IN (SELECT embedding_rowid FROM chunks WHERE id IN ?)`, ids).Error; err != nil {
+		return fmt.Errorf("failed to delete embeddings: %w", err)
+	}
+	if err := db.WithContext(ctx).Where("id IN ?", ids).Delete(&Chunk{}).Error; err != nil {
+		return fmt.Errorf("failed to delete chunks: %w", err)
+	}
+	return nil
+}
+
 type SearchResult struct {
-	ChunkID      string  `json:"chunk_id" gorm:"column:chunk_id"`
-	DocumentID   string  `json:"document_id" gorm:"column:document_id"`
-	DocumentName string  `json:"document_name" gorm:"column:document_name"`
-	ChunkIndex   int     `json:"chunk_index" gorm:"column:chunk_index"`
-	StartLine    int     `json:"start_line" gorm:"column:start_line"`
-	EndLine      int     `json:"end_line" gorm:"column:end_line"`
-	Content      string  `json:"data" gorm:"column:data"`
-	Distance     float64 `json:"distance" gorm:"column:distance"`
-	IsNameMatch  bool    `json:"is_name_match"`
+	ChunkID      string   `json:"chunk_id" gorm:"column:chunk_id"`
+	DocumentID   string   `json:"document_id" gorm:"column:document_id"`
+	DocumentName string   `json:"document_name" gorm:"column:document_name"`
+	ChunkIndex   int      `json:"chunk_index" gorm:"column:chunk_index"`
+	StartLine    int      `json:"start_line" gorm:"column:start_line"`
+	EndLine      int      `json:"end_line" gorm:"column:end_line"`
+	StartByte    int      `json:"start_byte" gorm:"column:start_byte"`
+	EndByte      int      `json:"end_byte" gorm:"column:end_byte"`
+	StartColumn  int      `json:"start_column" gorm:"column:start_column"`
+	EndColumn    int      `json:"end_column" gorm:"column:end_column"`
+	Page         int      `json:"page,omitempty" gorm:"column:page"`
+	Metadata     Metadata `json:"metadata,omitempty" gorm:"column:metadata"`
+	Content      string   `json:"data" gorm:"column:data"`
+	Distance     float64  `json:"distance" gorm:"column:distance"`
+	IsNameMatch  bool     `json:"is_name_match"`
+	// DocumentMetadata is the metadata of the document the chunk belongs to,
+	// such as the front matter of a Markdown note.
+	DocumentMetadata Metadata `json:"document_metadata,omitempty" gorm:"column:document_metadata"`
 }
 
+// childMatchesPerParent is how many extra nearest neighbours SearchChunks
+// fetches per requested result, so that several children of the same parent
+// collapsing into one result still leave enough distinct results.
+const childMatchesPerParent = 4
+
+// searchResultColumns selects a SearchResult from a chunk c of document d,
+// returned as its parent p when it has one.
+const searchResultColumns = `COALESCE(p.id, c.id) as chunk_id,
+		c.document_id as document_id,
+		d.name as document_name,
+		d.metadata as document_metadata,
+		COALESCE(p.chunk_index, c.chunk_index) as chunk_index,
+		COALESCE(p.start_line, c.start_line) as start_line,
+		COALESCE(p.end_line, c.end_line) as end_line,
+		COALESCE(p.start_byte, c.start_byte) as start_byte,
+		COALESCE(p.end_byte, c.end_byte) as end_byte,
+		COALESCE(p.start_column, c.start_column) as start_column,
+		COALESCE(p.end_column, c.end_column) as end_column,
+		c.page as page,
+		c.metadata as metadata,
+		COALESCE(p.data, c.data) as data`
+
+// SearchChunks returns the chunks closest to the query embedding. Matches on a
+// child chunk are returned as their parent chunk, scored by the best child.
 func SearchChunks(ctx context.Context, db *gorm.DB, queryEmbedding []float32, limit int) ([]SearchResult, error) {
 	queryJSON, err := json.Marshal(queryEmbedding)
 	if err != nil {
@@ -68,27 +147,114 @@ func SearchChunks(ctx context.Context, db *gorm.DB, queryEmbedding []float32, li
 
 	var results []SearchResult
 	err = db.WithContext(ctx).Raw(`SELECT
-		c.id as chunk_id,
-		c.document_id as document_id,
-		d.name as document_name,
-		c.chunk_index as chunk_index,
-		c.start_line as start_line,
-		c.end_line as end_line,
-		c.data as data,
+		`+searchResultColumns+`,
 		knn.distance as distance
 		FROM chunks c
 		JOIN documents d ON d.id = c.document_id
+		LEFT JOIN chunks p ON p.id = c.parent_id
 		JOIN (
 			SELECT rowid, distance
 			FROM chunk_embeddings
 			WHERE embedding MATCH ?
 			ORDER BY distance
 			LIMIT ?
-		) knn ON c.embedding_rowid = knn.rowid`, string(queryJSON), limit).Scan(&results).Error
+		) knn ON c.embedding_rowid = knn.rowid
+		ORDER BY knn.distance`, string(queryJSON), limit*childMatchesPerParent).Scan(&results).Error
 	if err != nil {
 		return nil, fmt.Errorf("failed to query: %w", err)
 	}
-	return results, nil
+	return dedupeResults(results, limit), nil
+}
+
+// SearchChunksWithFilters is SearchChunks restricted to the documents whose
+// metadata matches the filters. The distance is computed for every chunk of
+// those documents rather than looked up in the vector index, so documents
+// outside the filters can't crowd out the results.
+func SearchChunksWithFilters(ctx context.Context, db *gorm.DB, queryEmbedding []float32, limit int, filters Metadata) ([]SearchResult, error) {
+	if len(filters) == 0 {
+		return SearchChunks(ctx, db, queryEmbedding, limit)
+	}
+	queryJSON, err := json.Marshal(queryEmbedding)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal query embedding: %w", err)
+	}
+
+	filter, filterArgs := metadataFilter("d.metadata", filters)
+	args := append([]any{string(queryJSON)}, filterArgs...)
+	args = append(args, limit*childMatchesPerParent)
+
+	var results []SearchResult
+	err = db.WithContext(ctx).Raw(`SELECT
+		`+searchResultColumns+`,
+		vec_distance_l2(e.embedding, ?) as distance
+		FROM chunks c
+		JOIN documents d ON d.id = c.document_id
+		LEFT JOIN chunks p ON p.id = c.parent_id
+		JOIN chunk_embeddings e ON e.rowid = c.embedding_rowid
+		WHERE `+filter+`
+		ORDER BY distance
+		LIMIT ?`, args...).Scan(&results).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to query: %w", err)
+	}
+	return dedupeResults(results, limit), nil
+}
+
+// SearchDocumentChunks returns the chunk of each of the documents closest to
+// the query embedding, leaving out documents whose metadata doesn't match
+// the filters.
+func SearchDocumentChunks(ctx context.Context, db *gorm.DB, queryEmbedding []float32, documentIDs []string, filters Metadata) ([]SearchResult, error) {
+	if len(documentIDs) == 0 {
+		return nil, nil
+	}
+	queryJSON, err := json.Marshal(queryEmbedding)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal query embedding: %w", err)
+	}
+
+	filter, filterArgs := metadataFilter("d.metadata", filters)
+	args := append([]any{string(queryJSON), documentIDs}, filterArgs...)
+
+	var results []SearchResult
+	err = db.WithContext(ctx).Raw(`SELECT
+		`+searchResultColumns+`,
+		vec_distance_l2(e.embedding, ?) as distance
+		FROM chunks c
+		JOIN documents d ON d.id = c.document_id
+		LEFT JOIN chunks p ON p.id = c.parent_id
+		JOIN chunk_embeddings e ON e.rowid = c.embedding_rowid
+		WHERE c.document_id IN ? AND `+filter+`
+		ORDER BY distance`, args...).Scan(&results).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to query: %w", err)
+	}
+
+	seen := make(map[string]bool)
+	best := results[:0]
+	for _, result := range results {
+		if !seen[result.DocumentID] {
+			seen[result.DocumentID] = true
+			best = append(best, result)
+		}
+	}
+	return best, nil
+}
+
+// dedupeResults keeps only the closest match for every returned chunk, up to limit.
+func dedupeResults(results []SearchResult, limit int) []SearchResult {
+	seen := make(map[string]bool)
+	deduped := results[:0]
+	for _, result := range results {
+		if seen[result.ChunkID] {
+			continue
+		}
+		seen[result.ChunkID] = true
+		deduped = append(deduped, result)
+	}
+	if len(deduped) > limit {
+		deduped = deduped[:limit]
+	}
+	return deduped
 }
 
 type DocumentNameSearchResult struct {
@@ -130,3 +296,33 @@ func SearchDocumentNames(ctx context.Context, db *gorm.DB, queryEmbedding []floa
 	}
 	return results, nil
 }
+
+// SearchDocumentNamesWithFilters is SearchDocumentNames restricted to the
+// documents whose metadata matches the filters.
+func SearchDocumentNamesWithFilters(ctx context.Context, db *gorm.DB, queryEmbedding []float32, limit int, filters Metadata) ([]DocumentNameSearchResult, error) {
+	if len(filters) == 0 {
+		return SearchDocumentNames(ctx, db, queryEmbedding, limit)
+	}
+	queryJSON, err := json.Marshal(queryEmbedding)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal query embedding: %w", err)
+	}
+
+	filter, filterArgs := metadataFilter("d.metadata", filters)
+	args := append([]any{string(queryJSON)}, filterArgs...)
+	args = append(args, limit)
+
+	var results []DocumentNameSearchResult
+	err = db.WithContext(ctx).Raw(`SELECT
+		e.document_id as document_id,
+		vec_distance_l2(e.embedding, ?) as distance
+		FROM document_name_embeddings e
+		JOIN documents d ON d.id = e.document_id
+		WHERE `+filter+`
+		ORDER BY distance
+		LIMIT ?`, args...).Scan(&results).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to query document name embeddings: %w", err)
+	}
+	return results, nil
+}
@@ -26,7 +26,7 @@ func TestSaveChunk(t *testing.T) {
 	}
 
 	// Save chunk
-	err = SaveChunk(t.Context(), db, docID, chunkIndex, 1, 10, data, embedding)
+	err = SaveChunk(t.Context(), db, &Chunk{DocumentID: docID, ChunkIndex: chunkIndex, StartLine: 1, EndLine: 10, Data: data}, embedding)
 	require.NoError(t, err)
 
 	// Verify chunk was inserted
@@ -69,9 +69,9 @@ func TestSearchChunks(t *testing.T) {
 	embedding1[0] = 1.0
 	embedding2 := make([]float32, 768)
 	embedding2[1] = 1.0
-	err = SaveChunk(t.Context(), db, docID, 0, 1, 10, []byte("chunk 0"), embedding1)
+	err = SaveChunk(t.Context(), db, &Chunk{DocumentID: docID, ChunkIndex: 0, StartLine: 1, EndLine: 10, Data: []byte("chunk 0")}, embedding1)
 	require.NoError(t, err)
-	err = SaveChunk(t.Context(), db, docID, 1, 11, 20, []byte("chunk 1"), embedding2)
+	err = SaveChunk(t.Context(), db, &Chunk{DocumentID: docID, ChunkIndex: 1, StartLine: 11, EndLine: 20, Data: []byte("chunk 1")}, embedding2)
 	require.NoError(t, err)
 
 	// Search with the same embedding as embedding1
@@ -85,3 +85,171 @@ func TestSearchChunks(t *testing.T) {
 	assert.Equal(t, docID, results[0].DocumentID)
 	assert.Equal(t, "test document", results[0].DocumentName)
 }
+
+func TestSearchChunks_ReturnsParentOfMatchedChild(t *testing.T) {
+	db := SetupTestDB()
+	sqlDB, _ := db.DB()
+	defer sqlDB.Close()
+
+	docID := "test-doc-3"
+	err := db.Exec("INSERT INTO documents (id, name) VALUES (?, ?)", docID, "sectioned document").Error
+	require.NoError(t, err)
+
+	parent := &Chunk{DocumentID: docID, ChunkIndex: 0, StartLine: 1, EndLine: 20, Data: []byte("# Section\n\nchild one\n\nchild two\n")}
+	err = SaveParentChunk(t.Context(), db, parent)
+	require.NoError(t, err)
+	parentID := parent.ID
+
+	embedding1 := make([]float32, 768)
+	embedding1[0] = 1.0
+	embedding2 := make([]float32, 768)
+	embedding2[0] = 0.9
+	embedding2[1] = 0.1
+	err = SaveChunk(t.Context(), db, &Chunk{DocumentID: docID, ParentID: &parentID, ChunkIndex: 1, StartLine: 3, EndLine: 4, Data: []byte("child one\n\n")}, embedding1)
+	require.NoError(t, err)
+	err = SaveChunk(t.Context(), db, &Chunk{DocumentID: docID, ParentID: &parentID, ChunkIndex: 2, StartLine: 5, EndLine: 6, Data: []byte("child two\n")}, embedding2)
+	require.NoError(t, err)
+
+	results, err := SearchChunks(t.Context(), db, embedding1, 5)
+	require.NoError(t, err)
+
+	// Both children match, but they collapse into their single parent
+	require.Len(t, results, 1)
+	assert.Equal(t, parentID, results[0].ChunkID)
+	assert.Equal(t, "# Section\n\nchild one\n\nchild two\n", results[0].Content)
+	assert.Equal(t, 1, results[0].StartLine)
+	assert.Equal(t, 20, results[0].EndLine)
+	assert.InDelta(t, 0.0, results[0].Distance, 1e-6)
+}
+
+func TestSearchChunks_ReturnsPosition(t *testing.T) {
+	db := SetupTestDB()
+	sqlDB, _ := db.DB()
+	defer sqlDB.Close()
+
+	docID := "test-doc-4"
+	err := db.Exec("INSERT INTO documents (id, name) VALUES (?, ?)", docID, "offsets document").Error
+	require.NoError(t, err)
+
+	embedding := make([]float32, 768)
+	embedding[0] = 1.0
+	err = SaveChunk(t.Context(), db, &Chunk{
+		DocumentID:  docID,
+		StartLine:   2,
+		EndLine:     3,
+		StartByte:   14,
+		EndByte:     31,
+		StartColumn: 5,
+		EndColumn:   7,
+		Page:        3,
+		Data:        []byte("chunk with offsets"),
+	}, embedding)
+	require.NoError(t, err)
+
+	results, err := SearchChunks(t.Context(), db, embedding, 1)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, 14, results[0].StartByte)
+	assert.Equal(t, 31, results[0].EndByte)
+	assert.Equal(t, 5, results[0].StartColumn)
+	assert.Equal(t, 7, results[0].EndColumn)
+	assert.Equal(t, 3, results[0].Page)
+}
+
+func TestSearchChunksWithFilters(t *testing.T) {
+	db := SetupTestDB()
+	sqlDB, _ := db.DB()
+	defer sqlDB.Close()
+
+	docs := []*Document{
+		{ID: "note-go", Name: "go.md", Metadata: Metadata{"tags": []any{"go", "backend"}, "draft": false}},
+		{ID: "note-rust", Name: "rust.md", Metadata: Metadata{"tags": []any{"rust"}, "author": "Ana"}},
+		{ID: "note-plain", Name: "plain.txt"},
+	}
+	for i, doc := range docs {
+		require.NoError(t, SaveDocument(t.Context(), db, doc))
+
+		embedding := make([]float32, 768)
+		embedding[0] = 1.0
+		embedding[1] = float32(i) * 0.1
+		require.NoError(t, SaveChunk(t.Context(), db, &Chunk{DocumentID: doc.ID, Data: []byte(doc.Name)}, embedding))
+		require.NoError(t, SaveDocumentNameEmbedding(t.Context(), db, doc.ID, embedding))
+	}
+
+	query := make([]float32, 768)
+	query[0] = 1.0
+
+	tests := []struct {
+		name    string
+		filters Metadata
+		want    []string
+	}{
+		{name: "no filters", filters: nil, want: []string{"note-go", "note-rust", "note-plain"}},
+		{name: "array element", filters: Metadata{"tags": "rust"}, want: []string{"note-rust"}},
+		{name: "all listed values", filters: Metadata{"tags": []any{"go", "backend"}}, want: []string{"note-go"}},
+		{name: "missing value", filters: Metadata{"tags": []any{"go", "rust"}}, want: nil},
+		{name: "scalar", filters: Metadata{"author": "Ana"}, want: []string{"note-rust"}},
+		{name: "boolean", filters: Metadata{"draft": false}, want: []string{"note-go"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results, err := SearchChunksWithFilters(t.Context(), db, query, 5, tt.filters)
+			require.NoError(t, err)
+			var ids []string
+			for _, result := range results {
+				ids = append(ids, result.DocumentID)
+			}
+			require.Equal(t, tt.want, ids)
+
+			nameResults, err := SearchDocumentNamesWithFilters(t.Context(), db, query, 5, tt.filters)
+			require.NoError(t, err)
+			ids = nil
+			for _, result := range nameResults {
+				ids = append(ids, result.DocumentID)
+			}
+			require.Equal(t, tt.want, ids)
+		})
+	}
+
+	// Results carry the metadata of their document
+	results, err := SearchChunksWithFilters(t.Context(), db, query, 1, Metadata{"author": "Ana"})
+	require.NoError(t, err)
+	require.Equal(t, Metadata{"tags": []any{"rust"}, "author": "Ana"}, results[0].DocumentMetadata)
+}
+
+func TestSearchDocumentChunks(t *testing.T) {
+	db := SetupTestDB()
+	sqlDB, _ := db.DB()
+	defer sqlDB.Close()
+
+	require.NoError(t, SaveDocument(t.Context(), db, &Document{ID: "roadmap", Name: "roadmap.md"}))
+	require.NoError(t, SaveDocument(t.Context(), db, &Document{ID: "draft", Name: "draft.md", Metadata: Metadata{"draft": true}}))
+	require.NoError(t, SaveDocument(t.Context(), db, &Document{ID: "other", Name: "other.md"}))
+	for i, chunk := range []*Chunk{
+		{DocumentID: "roadmap", ChunkIndex: 0, Data: []byte("far")},
+		{DocumentID: "roadmap", ChunkIndex: 1, Data: []byte("near")},
+		{DocumentID: "draft", Data: []byte("draft")},
+		{DocumentID: "other", Data: []byte("other")},
+	} {
+		embedding := make([]float32, 768)
+		embedding[0] = 1.0
+		embedding[1] = 0.4 - float32(i)*0.1
+		require.NoError(t, SaveChunk(t.Context(), db, chunk, embedding))
+	}
+
+	query := make([]float32, 768)
+	query[0] = 1.0
+
+	// One result per document, its closest chunk
+	results, err := SearchDocumentChunks(t.Context(), db, query, []string{"roadmap", "draft"}, nil)
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	require.Equal(t, "draft", results[0].DocumentID)
+	require.Equal(t, "roadmap", results[1].DocumentID)
+	require.Equal(t, "near", results[1].Content)
+
+	results, err = SearchDocumentChunks(t.Context(), db, query, []string{"roadmap", "draft"}, Metadata{"draft": true})
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	require.Equal(t, "draft", results[0].DocumentID)
+}
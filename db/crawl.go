@@ -0,0 +1,33 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// GetCrawledPages retrieves the pages fetched by crawls of a site.
+func GetCrawledPages(ctx context.Context, db *gorm.DB, site string) ([]CrawledPage, error) {
+	var pages []CrawledPage
+	if err := db.WithContext(ctx).Where("site = ?", site).Order("url").Find(&pages).Error; err != nil {
+		return nil, err
+	}
+	return pages, nil
+}
+
+// SaveCrawledPage creates or replaces the crawl state of a page.
+func SaveCrawledPage(ctx context.Context, db *gorm.DB, page *CrawledPage) error {
+	if err := db.WithContext(ctx).Save(page).Error; err != nil {
+		return fmt.Errorf("failed to save crawled page: %w", err)
+	}
+	return nil
+}
+
+// DeleteCrawledPage deletes the crawl state of a page.
+func DeleteCrawledPage(ctx context.Context, db *gorm.DB, url string) error {
+	if err := db.WithContext(ctx).Delete(&CrawledPage{URL: url}).Error; err != nil {
+		return fmt.Errorf("failed to delete crawled page: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+package db
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCrawledPages(t *testing.T) {
+	db := SetupTestDB()
+	sqlDB, _ := db.DB()
+	defer sqlDB.Close()
+
+	page := &CrawledPage{
+		URL:       "http://docs.internal/guide",
+		Site:      "http://docs.internal/",
+		ETag:      `"abc"`,
+		Links:     []string{"http://docs.internal/guide/install"},
+		CrawledAt: time.Now(),
+	}
+	require.NoError(t, SaveCrawledPage(t.Context(), db, page))
+	require.NoError(t, SaveCrawledPage(t.Context(), db, &CrawledPage{URL: "http://other.internal/", Site: "http://other.internal/"}))
+
+	page.ETag = `"def"`
+	require.NoError(t, SaveCrawledPage(t.Context(), db, page))
+
+	pages, err := GetCrawledPages(t.Context(), db, "http://docs.internal/")
+	require.NoError(t, err)
+	require.Len(t, pages, 1)
+	require.Equal(t, `"def"`, pages[0].ETag)
+	require.Equal(t, []string{"http://docs.internal/guide/install"}, pages[0].Links)
+
+	require.NoError(t, DeleteCrawledPage(t.Context(), db, page.URL))
+	pages, err = GetCrawledPages(t.Context(), db, "http://docs.internal/")
+	require.NoError(t, err)
+	require.Empty(t, pages)
+}
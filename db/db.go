@@ -40,20 +40,63 @@ func Init(cfg *config.Config) *gorm.DB {
 }
 
 type Document struct {
-	ID        string    `gorm:"primaryKey"`
-	Name      string    `gorm:"not null"`
-	CreatedAt time.Time `gorm:"autoCreateTime"`
+	ID       string   `gorm:"primaryKey"`
+	Name     string   `gorm:"not null"`
+	Metadata Metadata `gorm:"column:metadata"`
+	// Source is the name of the file the document was split out of, such as
+	// the mailbox of a message. It is empty for documents uploaded on their own.
+	Source string `gorm:"column:source"`
+	// ContentHash is the hex SHA-256 of the uploaded file, and ModTime and
+	// Size describe that file. Documents split out of a file share its values.
+	ContentHash string     `gorm:"column:content_hash"`
+	ModTime     *time.Time `gorm:"column:mod_time"`
+	Size        int64      `gorm:"column:size"`
+	CreatedAt   time.Time  `gorm:"autoCreateTime"`
 }
 
 type Chunk struct {
 	ID             string `gorm:"primaryKey"`
 	DocumentID     string
-	ChunkIndex     int       `gorm:"not null"`
-	Data           []byte    `gorm:"not null"`
-	StartLine      int       `gorm:"column:start_line"`
-	EndLine        int       `gorm:"column:end_line"`
-	EmbeddingRowID int       `gorm:"column:embedding_rowid"`
-	CreatedAt      time.Time `gorm:"autoCreateTime"`
+	ParentID       *string  `gorm:"column:parent_id"`
+	ChunkIndex     int      `gorm:"not null"`
+	Data           []byte   `gorm:"not null"`
+	StartLine      int      `gorm:"column:start_line"`
+	EndLine        int      `gorm:"column:end_line"`
+	StartByte      int      `gorm:"column:start_byte"`
+	EndByte        int      `gorm:"column:end_byte"`
+	StartColumn    int      `gorm:"column:start_column"`
+	EndColumn      int      `gorm:"column:end_column"`
+	Page           int      `gorm:"column:page"`
+	Metadata       Metadata `gorm:"column:metadata"`
+	EmbeddingRowID int      `gorm:"column:embedding_rowid"`
+	// ContentHash is the hex SHA-256 of the text the chunk was embedded
+	// from, to keep the embedding when a reprocessed document still has it.
+	ContentHash string    `gorm:"column:content_hash"`
+	CreatedAt   time.Time `gorm:"autoCreateTime"`
+}
+
+// CrawledPage is a web page fetched by a crawl of a site, with what is needed
+// to request it conditionally next time.
+type CrawledPage struct {
+	URL string `gorm:"column:url;primaryKey"`
+	// Site is the URL the crawl started from.
+	Site         string `gorm:"not null"`
+	ETag         string `gorm:"column:etag"`
+	LastModified string `gorm:"column:last_modified"`
+	// Links are the pages of the site the page links to, followed when the
+	// page itself wasn't modified.
+	Links     []string  `gorm:"column:links;serializer:json"`
+	CrawledAt time.Time `gorm:"column:crawled_at"`
+}
+
+// SourceItem is the sync state of an item of a source, the version of it
+// that was last indexed.
+type SourceItem struct {
+	Source       string    `gorm:"primaryKey"`
+	ItemID       string    `gorm:"column:item_id;primaryKey"`
+	Version      string    `gorm:"column:version"`
+	DocumentName string    `gorm:"column:document_name;not null"`
+	SyncedAt     time.Time `gorm:"column:synced_at"`
 }
 
 func SetupTestDB() *gorm.DB {
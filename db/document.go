@@ -3,6 +3,7 @@ package db
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
@@ -59,20 +60,51 @@ func DeleteDocumentByName(ctx context.Context, db *gorm.DB, name string) error {
 	return DeleteDocument(ctx, db, doc.ID)
 }
 
-// SaveDocument creates a new document in the database and returns its ID.
-func SaveDocument(ctx context.Context, db *gorm.DB, name string) (string, error) {
-	docID := uuid.New().String()
+// SaveDocument creates a new document in the database. A new ID is generated if the document has none.
+func SaveDocument(ctx context.Context, db *gorm.DB, doc *Document) error {
+	if doc.ID == "" {
+		doc.ID = uuid.New().String()
+	}
+	if err := db.WithContext(ctx).Create(doc).Error; err != nil {
+		return fmt.Errorf("failed to insert document: %w", err)
+	}
+	return nil
+}
 
-	doc := Document{
-		ID:   docID,
-		Name: name,
+// UpdateDocument updates the metadata, source and file details of a stored
+// document, keeping its ID, chunks and name embedding.
+func UpdateDocument(ctx context.Context, db *gorm.DB, doc *Document) error {
+	err := db.WithContext(ctx).Model(&Document{ID: doc.ID}).
+		Select("metadata", "source", "content_hash", "mod_time", "size").
+		Updates(doc).Error
+	if err != nil {
+		return fmt.Errorf("failed to update document: %w", err)
 	}
+	return nil
+}
 
-	if err := db.WithContext(ctx).Create(&doc).Error; err != nil {
-		return "", fmt.Errorf("failed to insert document: %w", err)
+// GetDocumentsBySource retrieves the documents split out of the named file.
+func GetDocumentsBySource(ctx context.Context, db *gorm.DB, source string) ([]Document, error) {
+	var docs []Document
+	err := db.WithContext(ctx).Raw("SELECT * FROM documents WHERE source = ? ORDER BY name", source).Scan(&docs).Error
+	if err != nil {
+		return nil, err
 	}
+	return docs, nil
+}
 
-	return docID, nil
+// DeleteDocumentsBySource deletes the documents split out of the named file.
+func DeleteDocumentsBySource(ctx context.Context, db *gorm.DB, source string) error {
+	docs, err := GetDocumentsBySource(ctx, db, source)
+	if err != nil {
+		return err
+	}
+	for _, doc := range docs {
+		if err := DeleteDocument(ctx, db, doc.ID); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 // GetDocumentByID retrieves a document by ID.
@@ -87,3 +119,68 @@ func GetDocumentByID(ctx context.Context, db *gorm.DB, id string) (*Document, er
 	}
 	return &doc, nil
 }
+
+// GetDocumentsByMetadata retrieves the documents whose metadata matches the
+// filters, the way search filters match.
+func GetDocumentsByMetadata(ctx context.Context, db *gorm.DB, filters Metadata) ([]Document, error) {
+	filter, args := metadataFilter("metadata", filters)
+	var docs []Document
+	err := db.WithContext(ctx).Raw("SELECT * FROM documents WHERE "+filter+" ORDER BY name", args...).Scan(&docs).Error
+	if err != nil {
+		return nil, err
+	}
+	return docs, nil
+}
+
+// SetDocumentsMetadata sets a metadata key on every document whose metadata
+// matches the filters.
+func SetDocumentsMetadata(ctx context.Context, db *gorm.DB, filters Metadata, key string, value any) error {
+	filter, filterArgs := metadataFilter("metadata", filters)
+	args := append([]any{metadataPath(key), value}, filterArgs...)
+	err := db.WithContext(ctx).Exec("UPDATE documents SET metadata = json_set(COALESCE(metadata, '{}'), ?, ?) WHERE "+filter, args...).Error
+	if err != nil {
+		return fmt.Errorf("failed to update document metadata: %w", err)
+	}
+	return nil
+}
+
+// hashLookupBatch bounds the number of names in one query, below SQLite's
+// limit on query parameters.
+const hashLookupBatch = 500
+
+// GetDocumentHashes returns the content hash stored for each of the named
+// files that is indexed, whether as a document of that name or as the source
+// of documents split out of it. Files indexed without a hash map to "".
+func GetDocumentHashes(ctx context.Context, db *gorm.DB, names []string) (map[string]string, error) {
+	hashes := make(map[string]string, len(names))
+	for start := 0; start < len(names); start += hashLookupBatch {
+		batch := names[start:min(start+hashLookupBatch, len(names))]
+
+		var rows []Document
+		err := db.WithContext(ctx).Raw("SELECT name, source, content_hash FROM documents WHERE name IN ? OR source IN ?", batch, batch).Scan(&rows).Error
+		if err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			name := row.Name
+			if row.Source != "" {
+				name = row.Source
+			}
+			if _, ok := hashes[name]; !ok || row.ContentHash == "" {
+				// A document without a hash makes the whole file count as changed
+				hashes[name] = row.ContentHash
+			}
+		}
+	}
+	return hashes, nil
+}
+
+// SetDocumentModTime updates the modification time stored for the named
+// file and the documents split out of it.
+func SetDocumentModTime(ctx context.Context, db *gorm.DB, name string, modTime time.Time) error {
+	err := db.WithContext(ctx).Exec("UPDATE documents SET mod_time = ? WHERE (name = ? AND (source IS NULL OR source = '')) OR source = ?", modTime, name, name).Error
+	if err != nil {
+		return fmt.Errorf("failed to update document modification time: %w", err)
+	}
+	return nil
+}
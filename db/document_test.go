@@ -0,0 +1,56 @@
+package db
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetDocumentHashes(t *testing.T) {
+	db := SetupTestDB()
+	sqlDB, _ := db.DB()
+	defer sqlDB.Close()
+
+	require.NoError(t, SaveDocument(t.Context(), db, &Document{Name: "notes.md", ContentHash: "aaa"}))
+	require.NoError(t, SaveDocument(t.Context(), db, &Document{Name: "old.md"}))
+	// Documents split out of a file share its hash
+	require.NoError(t, SaveDocument(t.Context(), db, &Document{Name: "<1@example.com>", Source: "inbox.mbox", ContentHash: "bbb"}))
+	require.NoError(t, SaveDocument(t.Context(), db, &Document{Name: "<2@example.com>", Source: "inbox.mbox", ContentHash: "bbb"}))
+
+	hashes, err := GetDocumentHashes(t.Context(), db, []string{"notes.md", "old.md", "inbox.mbox", "missing.md"})
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"notes.md": "aaa", "old.md": "", "inbox.mbox": "bbb"}, hashes)
+
+	modTime := time.Date(2026, 4, 5, 12, 0, 0, 0, time.UTC)
+	require.NoError(t, SetDocumentModTime(t.Context(), db, "inbox.mbox", modTime))
+	docs, err := GetDocumentsBySource(t.Context(), db, "inbox.mbox")
+	require.NoError(t, err)
+	for _, doc := range docs {
+		require.NotNil(t, doc.ModTime)
+		require.True(t, modTime.Equal(*doc.ModTime))
+	}
+}
+
+func TestDocumentsMetadataQuotedKeys(t *testing.T) {
+	db := SetupTestDB()
+	sqlDB, _ := db.DB()
+	defer sqlDB.Close()
+
+	// Keys are matched literally, quotes, backslashes and dots included
+	metadata := Metadata{`say "hi"`: "x", `C:\notes`: "y", "a.b": "z"}
+	require.NoError(t, SaveDocument(t.Context(), db, &Document{Name: "quoted.md", Metadata: metadata}))
+	require.NoError(t, SaveDocument(t.Context(), db, &Document{Name: "plain.md", Metadata: Metadata{"a": map[string]any{"b": "z"}}}))
+
+	for key, value := range metadata {
+		require.NoError(t, SetDocumentsMetadata(t.Context(), db, Metadata{key: value}, key, "set"))
+		docs, err := GetDocumentsByMetadata(t.Context(), db, Metadata{key: "set"})
+		require.NoError(t, err)
+		require.Len(t, docs, 1, key)
+		require.Equal(t, "quoted.md", docs[0].Name)
+		require.Equal(t, "set", docs[0].Metadata[key])
+	}
+	doc, err := GetDocumentByName(t.Context(), db, "plain.md")
+	require.NoError(t, err)
+	require.Equal(t, map[string]any{"b": "z"}, doc.Metadata["a"])
+}
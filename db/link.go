@@ -0,0 +1,70 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// documentPath is a document matched by a path.
+type documentPath struct {
+	Path     string `gorm:"column:path"`
+	Document `gorm:"embedded"`
+}
+
+// GetDocumentsByPaths retrieves, for each path, the document named path or
+// whose name ends with "/" and path, ignoring case. Of several matches the
+// one with the shortest name wins. Paths nothing matches are left out.
+func GetDocumentsByPaths(ctx context.Context, db *gorm.DB, paths []string) (map[string]Document, error) {
+	if len(paths) == 0 {
+		return map[string]Document{}, nil
+	}
+	data, err := json.Marshal(paths)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal paths: %w", err)
+	}
+
+	// LIKE wildcards in the paths are escaped with \ to match literally
+	var matches []documentPath
+	err = db.WithContext(ctx).Raw(`WITH paths AS (SELECT DISTINCT value AS path FROM json_each(?))
+		SELECT paths.path AS path, d.* FROM paths
+		JOIN documents d ON lower(d.name) = lower(paths.path)
+			OR d.name LIKE '%/' || replace(replace(replace(paths.path, '\', '\\'), '%', '\%'), '_', '\_') ESCAPE '\'
+		ORDER BY length(d.name), d.name`, string(data)).Scan(&matches).Error
+	if err != nil {
+		return nil, err
+	}
+
+	docs := make(map[string]Document, len(matches))
+	for _, match := range matches {
+		if _, ok := docs[match.Path]; !ok {
+			docs[match.Path] = match.Document
+		}
+	}
+	return docs, nil
+}
+
+// GetLinkingDocuments retrieves the documents whose "links" or "embeds"
+// metadata holds any of the targets, ignoring case.
+func GetLinkingDocuments(ctx context.Context, db *gorm.DB, targets []string) ([]Document, error) {
+	if len(targets) == 0 {
+		return nil, nil
+	}
+	data, err := json.Marshal(targets)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal link targets: %w", err)
+	}
+
+	var docs []Document
+	err = db.WithContext(ctx).Raw(`WITH targets AS (SELECT lower(value) AS target FROM json_each(?))
+		SELECT * FROM documents
+		WHERE EXISTS (SELECT 1 FROM json_each(metadata, '$.links') WHERE lower(json_each.value) IN (SELECT target FROM targets))
+			OR EXISTS (SELECT 1 FROM json_each(metadata, '$.embeds') WHERE lower(json_each.value) IN (SELECT target FROM targets))
+		ORDER BY name`, string(data)).Scan(&docs).Error
+	if err != nil {
+		return nil, err
+	}
+	return docs, nil
+}
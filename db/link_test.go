@@ -0,0 +1,35 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDocumentLinks(t *testing.T) {
+	db := SetupTestDB()
+	sqlDB, _ := db.DB()
+	defer sqlDB.Close()
+
+	require.NoError(t, SaveDocument(t.Context(), db, &Document{Name: "vault/Projects/Alpha.md", Metadata: Metadata{"links": []any{"roadmap"}}}))
+	require.NoError(t, SaveDocument(t.Context(), db, &Document{Name: "vault/Roadmap.md"}))
+	require.NoError(t, SaveDocument(t.Context(), db, &Document{Name: "archive/old/Roadmap.md", Metadata: Metadata{"embeds": []any{"Roadmap"}}}))
+	require.NoError(t, SaveDocument(t.Context(), db, &Document{Name: "vault/My_Roadmap.md", Metadata: Metadata{"tags": []any{"roadmap"}}}))
+
+	paths, err := GetDocumentsByPaths(t.Context(), db, []string{"roadmap.md", "Projects/Alpha.md", "Beta.md"})
+	require.NoError(t, err)
+	require.Len(t, paths, 2)
+	require.Equal(t, "vault/Roadmap.md", paths["roadmap.md"].Name)
+	require.Equal(t, "vault/Projects/Alpha.md", paths["Projects/Alpha.md"].Name)
+
+	// LIKE wildcards in the paths are matched literally
+	paths, err = GetDocumentsByPaths(t.Context(), db, []string{"_oadmap.md"})
+	require.NoError(t, err)
+	require.Empty(t, paths)
+
+	docs, err := GetLinkingDocuments(t.Context(), db, []string{"Roadmap", "vault/Roadmap"})
+	require.NoError(t, err)
+	require.Len(t, docs, 2)
+	require.Equal(t, "archive/old/Roadmap.md", docs[0].Name)
+	require.Equal(t, "vault/Projects/Alpha.md", docs[1].Name)
+}
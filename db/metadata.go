@@ -0,0 +1,106 @@
+package db
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// Metadata is a set of key/value pairs stored as a JSON object.
+type Metadata map[string]any
+
+func (m Metadata) Value() (driver.Value, error) {
+	if len(m) == 0 {
+		return nil, nil
+	}
+	data, err := json.Marshal(m)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal metadata: %w", err)
+	}
+	return string(data), nil
+}
+
+func (m *Metadata) Scan(value any) error {
+	var data []byte
+	switch v := value.(type) {
+	case nil:
+		*m = nil
+		return nil
+	case string:
+		data = []byte(v)
+	case []byte:
+		data = v
+	default:
+		return fmt.Errorf("unsupported metadata type %T", value)
+	}
+	return json.Unmarshal(data, m)
+}
+
+func (Metadata) GormDataType() string {
+	return "text"
+}
+
+// metadataFilter returns an SQL condition matching rows whose metadata
+// column holds every filter. A filter matches a value equal to it or an array
+// containing it, a list of filter values matches when all of them do.
+func metadataFilter(column string, filters Metadata) (string, []any) {
+	keys := slices.Sorted(maps.Keys(filters))
+
+	var conditions []string
+	var args []any
+	for _, key := range keys {
+		for _, value := range filterValues(filters[key]) {
+			conditions = append(conditions, fmt.Sprintf("EXISTS (SELECT 1 FROM json_each(%s, ?) WHERE json_each.value = ?)", column))
+			args = append(args, metadataPath(key), value)
+		}
+	}
+	if len(conditions) == 0 {
+		return "1 = 1", nil
+	}
+	return strings.Join(conditions, " AND "), args
+}
+
+// pathEscaper escapes a key for a quoted label of a JSON path, which SQLite
+// reads like a JSON string.
+var pathEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+
+// metadataPath returns the JSON path of a top-level metadata key.
+func metadataPath(key string) string {
+	return `$."` + pathEscaper.Replace(key) + `"`
+}
+
+// filterValues returns the values a filter requires, as SQLite compares
+// them with values extracted from JSON.
+func filterValues(filter any) []any {
+	var values []any
+	switch v := filter.(type) {
+	case []any:
+		values = v
+	case []string:
+		for _, s := range v {
+			values = append(values, s)
+		}
+	default:
+		values = []any{v}
+	}
+
+	converted := make([]any, 0, len(values))
+	for _, value := range values {
+		switch v := value.(type) {
+		case nil:
+			continue
+		case bool:
+			// JSON booleans are extracted as 1 and 0
+			if v {
+				value = 1
+			} else {
+				value = 0
+			}
+		}
+		converted = append(converted, value)
+	}
+	return converted
+}
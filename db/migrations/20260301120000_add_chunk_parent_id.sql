@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE chunks ADD COLUMN parent_id TEXT;
+CREATE INDEX idx_chunks_parent_id ON chunks (parent_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX idx_chunks_parent_id;
+ALTER TABLE chunks DROP COLUMN parent_id;
+-- +goose StatementEnd
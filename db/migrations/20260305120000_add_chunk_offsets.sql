@@ -0,0 +1,51 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE chunks ADD COLUMN start_byte INTEGER;
+ALTER TABLE chunks ADD COLUMN end_byte INTEGER;
+ALTER TABLE chunks ADD COLUMN start_column INTEGER;
+ALTER TABLE chunks ADD COLUMN end_column INTEGER;
+
+-- Backfill byte offsets of top-level chunks by summing the lengths of the
+-- chunks before them. This is exact for chunks stored without overlap.
+UPDATE chunks SET start_byte = (
+    SELECT o.start_byte FROM (
+        SELECT id, SUM(length(data)) OVER (PARTITION BY document_id ORDER BY chunk_index) - length(data) AS start_byte
+        FROM chunks
+        WHERE parent_id IS NULL
+    ) o WHERE o.id = chunks.id
+) WHERE parent_id IS NULL;
+
+-- Child chunks are laid out the same way inside their parent
+UPDATE chunks SET start_byte = (
+    SELECT p.start_byte + o.offset FROM (
+        SELECT id, parent_id, SUM(length(data)) OVER (PARTITION BY parent_id ORDER BY chunk_index) - length(data) AS offset
+        FROM chunks
+        WHERE parent_id IS NOT NULL
+    ) o JOIN chunks p ON p.id = o.parent_id
+    WHERE o.id = chunks.id
+) WHERE parent_id IS NOT NULL;
+
+UPDATE chunks SET end_byte = start_byte + length(data);
+
+-- A chunk that starts the document or follows a line break starts in column 1,
+-- and a chunk that ends with a line break ends in column 1. Columns that can't
+-- be derived from the stored chunks are left NULL.
+UPDATE chunks SET start_column = 1
+WHERE start_byte = 0 OR EXISTS (
+    SELECT 1 FROM chunks prev
+    WHERE prev.document_id = chunks.document_id
+      AND prev.end_byte = chunks.start_byte
+      AND substr(prev.data, -1) = x'0A'
+);
+UPDATE chunks SET end_column = 1 WHERE substr(data, -1) = x'0A';
+UPDATE chunks SET end_column = start_column + length(CAST(data AS TEXT))
+WHERE end_column IS NULL AND start_column IS NOT NULL AND instr(data, x'0A') = 0;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE chunks DROP COLUMN end_column;
+ALTER TABLE chunks DROP COLUMN start_column;
+ALTER TABLE chunks DROP COLUMN end_byte;
+ALTER TABLE chunks DROP COLUMN start_byte;
+-- +goose StatementEnd
@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE documents ADD COLUMN source TEXT;
+CREATE INDEX idx_documents_source ON documents (source);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX idx_documents_source;
+ALTER TABLE documents DROP COLUMN source;
+-- +goose StatementEnd
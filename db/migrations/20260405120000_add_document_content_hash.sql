@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE documents ADD COLUMN content_hash TEXT;
+ALTER TABLE documents ADD COLUMN mod_time DATETIME;
+ALTER TABLE documents ADD COLUMN size INTEGER;
+CREATE INDEX idx_documents_name ON documents (name);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX idx_documents_name;
+ALTER TABLE documents DROP COLUMN size;
+ALTER TABLE documents DROP COLUMN mod_time;
+ALTER TABLE documents DROP COLUMN content_hash;
+-- +goose StatementEnd
@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE chunks ADD COLUMN content_hash TEXT;
+CREATE INDEX idx_chunks_document_id ON chunks (document_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX idx_chunks_document_id;
+ALTER TABLE chunks DROP COLUMN content_hash;
+-- +goose StatementEnd
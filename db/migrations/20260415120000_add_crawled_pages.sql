@@ -0,0 +1,18 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE crawled_pages (
+    url TEXT PRIMARY KEY,
+    site TEXT NOT NULL,
+    etag TEXT,
+    last_modified TEXT,
+    links TEXT,
+    crawled_at DATETIME
+);
+CREATE INDEX idx_crawled_pages_site ON crawled_pages (site);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX idx_crawled_pages_site;
+DROP TABLE crawled_pages;
+-- +goose StatementEnd
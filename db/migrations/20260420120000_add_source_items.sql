@@ -0,0 +1,16 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE source_items (
+    source TEXT NOT NULL,
+    item_id TEXT NOT NULL,
+    version TEXT,
+    document_name TEXT NOT NULL,
+    synced_at DATETIME,
+    PRIMARY KEY (source, item_id)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE source_items;
+-- +goose StatementEnd
@@ -0,0 +1,33 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// GetSourceItems retrieves the sync state of the items of a source.
+func GetSourceItems(ctx context.Context, db *gorm.DB, source string) ([]SourceItem, error) {
+	var items []SourceItem
+	if err := db.WithContext(ctx).Where("source = ?", source).Order("item_id").Find(&items).Error; err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// SaveSourceItem creates or replaces the sync state of an item.
+func SaveSourceItem(ctx context.Context, db *gorm.DB, item *SourceItem) error {
+	if err := db.WithContext(ctx).Save(item).Error; err != nil {
+		return fmt.Errorf("failed to save source item: %w", err)
+	}
+	return nil
+}
+
+// DeleteSourceItem deletes the sync state of an item.
+func DeleteSourceItem(ctx context.Context, db *gorm.DB, source, itemID string) error {
+	if err := db.WithContext(ctx).Delete(&SourceItem{Source: source, ItemID: itemID}).Error; err != nil {
+		return fmt.Errorf("failed to delete source item: %w", err)
+	}
+	return nil
+}
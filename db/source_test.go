@@ -0,0 +1,37 @@
+package db
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSourceItems(t *testing.T) {
+	db := SetupTestDB()
+	sqlDB, _ := db.DB()
+	defer sqlDB.Close()
+
+	item := &SourceItem{Source: "notes", ItemID: "todo.md", Version: "1", DocumentName: "/notes/todo.md", SyncedAt: time.Now()}
+	require.NoError(t, SaveSourceItem(t.Context(), db, item))
+	require.NoError(t, SaveSourceItem(t.Context(), db, &SourceItem{Source: "notes", ItemID: "ideas.md", Version: "1", DocumentName: "/notes/ideas.md"}))
+	// The same item ID in another source is another item
+	require.NoError(t, SaveSourceItem(t.Context(), db, &SourceItem{Source: "wiki", ItemID: "todo.md", Version: "7", DocumentName: "/wiki/todo.md"}))
+
+	item.Version = "2"
+	require.NoError(t, SaveSourceItem(t.Context(), db, item))
+
+	items, err := GetSourceItems(t.Context(), db, "notes")
+	require.NoError(t, err)
+	require.Len(t, items, 2)
+	require.Equal(t, "ideas.md", items[0].ItemID)
+	require.Equal(t, "2", items[1].Version)
+
+	require.NoError(t, DeleteSourceItem(t.Context(), db, "notes", "todo.md"))
+	items, err = GetSourceItems(t.Context(), db, "notes")
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+	items, err = GetSourceItems(t.Context(), db, "wiki")
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+}
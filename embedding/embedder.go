@@ -2,13 +2,28 @@ package embedding
 
 import (
 	"context"
+	"fmt"
 	"net/http"
+
+	"github.com/MaxIvanyshen/local-rag/config"
 )
 
 type Embedder interface {
 	GenerateEmbedding(ctx context.Context, input []byte) ([]float32, error)
 }
 
+// New returns the embedder the configuration selects.
+func New(cfg config.EmbedderConfig) (Embedder, error) {
+	switch cfg.Type {
+	case "ollama":
+		return NewOllamaEmbedder(cfg.Model, WithBaseURL(cfg.BaseURL)), nil
+	case "http":
+		return NewHTTPEmbedder(cfg.BaseURL), nil
+	default:
+		return nil, fmt.Errorf("unknown embedder type: %s", cfg.Type)
+	}
+}
+
 type TextEmbedder interface {
 	Embedder
 
@@ -0,0 +1,227 @@
+package extractor
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"log/slog"
+	"path"
+	"strings"
+)
+
+// ArchiveLimits bound what is read from an archive, so a small upload can't
+// expand into more data than the server can handle. Zero disables a limit.
+type ArchiveLimits struct {
+	// MaxEntries is the number of files read from the archive.
+	MaxEntries int
+	// MaxEntrySize is the uncompressed size of a single file in bytes.
+	MaxEntrySize int64
+	// MaxTotalSize is the uncompressed size of all files in bytes.
+	MaxTotalSize int64
+}
+
+// ArchiveExtractor extracts the files of zip and tar archives, optionally
+// gzip compressed. Every file is extracted by Files as if it was uploaded on
+// its own and is named "archive.zip!/path/inside". Nested archives are skipped.
+type ArchiveExtractor struct {
+	Files  Extractor
+	Limits ArchiveLimits
+}
+
+func NewArchiveExtractor(files Extractor, limits ArchiveLimits) *ArchiveExtractor {
+	return &ArchiveExtractor{
+		Files:  files,
+		Limits: limits,
+	}
+}
+
+func (a *ArchiveExtractor) Match(name string, data []byte) bool {
+	name = strings.ToLower(name)
+	for _, ext := range []string{".zip", ".tar", ".tar.gz", ".tgz"} {
+		if strings.HasSuffix(name, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// Extract returns the first document of the archive.
+func (a *ArchiveExtractor) Extract(name string, data []byte) (*Document, error) {
+	docs, err := a.ExtractAll(name, data)
+	if err != nil {
+		return nil, err
+	}
+	if len(docs) == 0 {
+		return &Document{}, nil
+	}
+	return docs[0], nil
+}
+
+// archiveEntry is a regular file read from an archive.
+type archiveEntry struct {
+	path string
+	data []byte
+}
+
+func (a *ArchiveExtractor) ExtractAll(name string, data []byte) ([]*Document, error) {
+	var entries []archiveEntry
+	var err error
+	if bytes.HasPrefix(data, zipMagic) {
+		entries, err = a.readZip(data)
+	} else {
+		entries, err = a.readTar(data)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var docs []*Document
+	for _, entry := range entries {
+		entryName := name + "!/" + entry.path
+		if a.Match(entryName, entry.data) {
+			slog.Warn("skipping nested archive", slog.String("document_name", entryName))
+			continue
+		}
+		entryDocs, err := a.extractFile(entryName, entry.data)
+		if err != nil {
+			// One broken file doesn't lose the rest of the archive
+			slog.Warn("skipping archive entry", slog.String("document_name", entryName), slog.String("error", err.Error()))
+			continue
+		}
+		for _, doc := range entryDocs {
+			if doc.Name == "" {
+				doc.Name = entryName
+			}
+			docs = append(docs, doc)
+		}
+	}
+	return docs, nil
+}
+
+func (a *ArchiveExtractor) extractFile(name string, data []byte) ([]*Document, error) {
+	switch e := a.Files.(type) {
+	case nil:
+		return []*Document{PlainText(name, data)}, nil
+	case MultiExtractor:
+		return e.ExtractAll(name, data)
+	default:
+		doc, err := e.Extract(name, data)
+		if err != nil {
+			return nil, err
+		}
+		return []*Document{doc}, nil
+	}
+}
+
+// entryReader reads archive entries while enforcing the limits.
+type entryReader struct {
+	limits ArchiveLimits
+	count  int
+	total  int64
+}
+
+// read reads the next entry. The sizes recorded in the archive aren't
+// trusted, the limits are checked against the bytes actually read.
+func (r *entryReader) read(name string, rc io.Reader) (archiveEntry, error) {
+	r.count++
+	if r.limits.MaxEntries > 0 && r.count > r.limits.MaxEntries {
+		return archiveEntry{}, fmt.Errorf("archive has more than %d files", r.limits.MaxEntries)
+	}
+
+	limit := int64(-1)
+	if r.limits.MaxEntrySize > 0 {
+		limit = r.limits.MaxEntrySize
+	}
+	if r.limits.MaxTotalSize > 0 && (limit < 0 || r.limits.MaxTotalSize-r.total < limit) {
+		limit = r.limits.MaxTotalSize - r.total
+	}
+	if limit >= 0 {
+		rc = io.LimitReader(rc, limit+1)
+	}
+	data, err := io.ReadAll(rc)
+	if err != nil {
+		return archiveEntry{}, fmt.Errorf("failed to read %s: %w", name, err)
+	}
+	if limit >= 0 && int64(len(data)) > limit {
+		if r.limits.MaxEntrySize > 0 && int64(len(data)) > r.limits.MaxEntrySize {
+			return archiveEntry{}, fmt.Errorf("%s is larger than %d bytes", name, r.limits.MaxEntrySize)
+		}
+		return archiveEntry{}, fmt.Errorf("archive is larger than %d bytes", r.limits.MaxTotalSize)
+	}
+	r.total += int64(len(data))
+	return archiveEntry{path: name, data: data}, nil
+}
+
+func (a *ArchiveExtractor) readZip(data []byte) ([]archiveEntry, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open zip archive: %w", err)
+	}
+	reader := &entryReader{limits: a.Limits}
+	var entries []archiveEntry
+	for _, f := range zr.File {
+		entryPath, ok := archivePath(f.Name)
+		if !ok || f.FileInfo().IsDir() || !f.Mode().IsRegular() {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, fmt.Errorf("failed to open %s: %w", f.Name, err)
+		}
+		entry, err := reader.read(entryPath, rc)
+		rc.Close()
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+func (a *ArchiveExtractor) readTar(data []byte) ([]archiveEntry, error) {
+	var r io.Reader = bytes.NewReader(data)
+	if bytes.HasPrefix(data, []byte{0x1f, 0x8b}) {
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open gzip stream: %w", err)
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	reader := &entryReader{limits: a.Limits}
+	var entries []archiveEntry
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read tar archive: %w", err)
+		}
+		entryPath, ok := archivePath(header.Name)
+		if !ok || header.Typeflag != tar.TypeReg {
+			continue
+		}
+		entry, err := reader.read(entryPath, tr)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// archivePath cleans the path of an archive entry and reports whether the
+// entry holds content, skipping the metadata macOS adds to archives.
+func archivePath(name string) (string, bool) {
+	p := strings.TrimPrefix(path.Clean("/"+strings.ReplaceAll(name, "\\", "/")), "/")
+	if p == "" || strings.HasPrefix(p, "__MACOSX/") || path.Base(p) == ".DS_Store" {
+		return "", false
+	}
+	return p, true
+}
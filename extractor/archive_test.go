@@ -0,0 +1,120 @@
+package extractor
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"testing"
+
+	"github.com/MaxIvanyshen/local-rag/chunker"
+	"github.com/stretchr/testify/require"
+)
+
+// archiveFile is a file written to a test archive, in order.
+type archiveFile struct {
+	name string
+	data string
+}
+
+func zipArchive(t *testing.T, files []archiveFile) []byte {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, f := range files {
+		w, err := zw.Create(f.name)
+		require.NoError(t, err)
+		_, err = w.Write([]byte(f.data))
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+	return buf.Bytes()
+}
+
+func tarGzArchive(t *testing.T, files []archiveFile) []byte {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for _, f := range files {
+		require.NoError(t, tw.WriteHeader(&tar.Header{Name: f.name, Mode: 0644, Size: int64(len(f.data)), Typeflag: tar.TypeReg}))
+		_, err := tw.Write([]byte(f.data))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	require.NoError(t, gz.Close())
+	return buf.Bytes()
+}
+
+func TestArchiveExtractor_ExtractAll(t *testing.T) {
+	files := []archiveFile{
+		{name: "docs/", data: ""},
+		{name: "docs/guide.md", data: "# Guide\n\nInstall the tool.\n"},
+		{name: "./docs/page.html", data: "<html><head><title>Page</title></head><body><p>Hello</p></body></html>"},
+		{name: "__MACOSX/docs/._guide.md", data: "junk"},
+		{name: "docs/nested.zip", data: "PK\x03\x04"},
+	}
+
+	archives := map[string][]byte{
+		"bundle.zip":    zipArchive(t, files),
+		"bundle.tar.gz": tarGzArchive(t, files[1:]),
+	}
+	for name, data := range archives {
+		t.Run(name, func(t *testing.T) {
+			extractor := NewArchiveExtractor(NewRegistry(&HTMLExtractor{}), ArchiveLimits{})
+			require.True(t, extractor.Match(name, data))
+
+			docs, err := extractor.ExtractAll(name, data)
+			require.NoError(t, err)
+			require.Len(t, docs, 2)
+
+			require.Equal(t, name+"!/docs/guide.md", docs[0].Name)
+			require.Equal(t, []Section{{Text: []byte("# Guide\n\nInstall the tool.\n"), Format: chunker.FormatMarkdown}}, docs[0].Sections)
+
+			// Entries go through the same extractors as loose files
+			require.Equal(t, name+"!/docs/page.html", docs[1].Name)
+			require.Equal(t, "Page", docs[1].Title)
+			require.Equal(t, "Hello\n", string(docs[1].Sections[0].Text))
+		})
+	}
+}
+
+func TestArchiveExtractor_Limits(t *testing.T) {
+	files := []archiveFile{
+		{name: "a.txt", data: "first"},
+		{name: "b.txt", data: "second"},
+		{name: "zeros.txt", data: string(make([]byte, 1<<20))},
+	}
+	data := zipArchive(t, files)
+	// The compressed archive is much smaller than its content
+	require.Less(t, len(data), 1<<16)
+
+	_, err := NewArchiveExtractor(nil, ArchiveLimits{MaxEntries: 2}).ExtractAll("bundle.zip", data)
+	require.ErrorContains(t, err, "more than 2 files")
+
+	_, err = NewArchiveExtractor(nil, ArchiveLimits{MaxEntrySize: 1 << 16}).ExtractAll("bundle.zip", data)
+	require.ErrorContains(t, err, "zeros.txt is larger than 65536 bytes")
+
+	_, err = NewArchiveExtractor(nil, ArchiveLimits{MaxTotalSize: 8}).ExtractAll("bundle.zip", data)
+	require.ErrorContains(t, err, "archive is larger than 8 bytes")
+
+	docs, err := NewArchiveExtractor(nil, ArchiveLimits{MaxEntries: 3, MaxEntrySize: 1 << 20, MaxTotalSize: 2 << 20}).ExtractAll("bundle.zip", data)
+	require.NoError(t, err)
+	require.Len(t, docs, 3)
+}
+
+func TestArchivePath(t *testing.T) {
+	for name, want := range map[string]string{
+		"docs/a.md":        "docs/a.md",
+		"./docs/a.md":      "docs/a.md",
+		"/docs/a.md":       "docs/a.md",
+		"../../etc/passwd": "etc/passwd",
+		"docs\\win.md":     "docs/win.md",
+	} {
+		got, ok := archivePath(name)
+		require.True(t, ok, name)
+		require.Equal(t, want, got)
+	}
+	for _, name := range []string{"", "__MACOSX/._a.md", "docs/.DS_Store"} {
+		_, ok := archivePath(name)
+		require.False(t, ok, name)
+	}
+}
@@ -0,0 +1,162 @@
+package extractor
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// DOCXExtractor extracts the paragraphs, headings, lists and tables of Word documents.
+type DOCXExtractor struct{}
+
+func (d *DOCXExtractor) Match(name string, data []byte) bool {
+	return matchPackage(name, data, "word/document.xml", ".docx")
+}
+
+func (d *DOCXExtractor) Extract(name string, data []byte) (*Document, error) {
+	zr, err := openPackage(data)
+	if err != nil {
+		return nil, err
+	}
+	body, err := readPart(zr, "word/document.xml")
+	if err != nil {
+		return nil, err
+	}
+	text, err := convertDOCX(body)
+	if err != nil {
+		return nil, err
+	}
+
+	title, metadata := readProperties(zr, "docProps/core.xml")
+	return &Document{
+		Title:    title,
+		Metadata: metadata,
+		Sections: sectionsOf(text),
+	}, nil
+}
+
+// docxHeadingLevel returns the heading level of a paragraph style, or 0 for
+// styles that are not headings.
+func docxHeadingLevel(style string) int {
+	style = strings.ToLower(strings.ReplaceAll(style, " ", ""))
+	if style == "title" {
+		return 1
+	}
+	if level, ok := strings.CutPrefix(style, "heading"); ok {
+		if n, err := strconv.Atoi(level); err == nil && n > 0 {
+			return n
+		}
+	}
+	return 0
+}
+
+func convertDOCX(data []byte) ([]byte, error) {
+	w := &officeWriter{}
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+
+	var (
+		para       strings.Builder
+		level      int
+		listItem   bool
+		inRun      bool
+		inText     bool
+		tableDepth int
+		cells      []string
+		cell       strings.Builder
+	)
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse document: %w", err)
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "Fallback", "txbxContent":
+				// Text boxes are stored twice for older readers and hold
+				// paragraphs nested in the surrounding one
+				if err := decoder.Skip(); err != nil {
+					return nil, fmt.Errorf("failed to parse document: %w", err)
+				}
+			case "p":
+				para.Reset()
+				level = 0
+				listItem = false
+			case "pStyle":
+				level = docxHeadingLevel(attrValue(t, "val"))
+			case "outlineLvl":
+				if n, err := strconv.Atoi(attrValue(t, "val")); err == nil && n < 9 {
+					level = n + 1
+				}
+			case "numPr":
+				listItem = true
+			case "r":
+				inRun = true
+			case "t":
+				inText = true
+			case "tab":
+				if inRun {
+					para.WriteByte('\t')
+				}
+			case "br", "cr":
+				if inRun {
+					para.WriteByte('\n')
+				}
+			case "tbl":
+				tableDepth++
+			case "tr":
+				if tableDepth == 1 {
+					cells = nil
+				}
+			case "tc":
+				if tableDepth == 1 {
+					cell.Reset()
+				}
+			}
+		case xml.CharData:
+			if inText {
+				para.Write(t)
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "t":
+				inText = false
+			case "r":
+				inRun = false
+			case "p":
+				switch {
+				case tableDepth > 0:
+					if cell.Len() > 0 {
+						cell.WriteByte(' ')
+					}
+					cell.WriteString(para.String())
+				case listItem && level == 0:
+					w.listItem(para.String())
+				default:
+					w.paragraph(para.String(), level)
+				}
+			case "tc":
+				if tableDepth == 1 {
+					cells = append(cells, cell.String())
+				}
+			case "tr":
+				if tableDepth == 1 {
+					w.tableRow(cells)
+				}
+			case "tbl":
+				tableDepth--
+				if tableDepth == 0 {
+					w.endBlock()
+				}
+			}
+		}
+	}
+	return w.bytes(), nil
+}
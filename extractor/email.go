@@ -0,0 +1,321 @@
+package extractor
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html"
+	"golang.org/x/text/encoding/htmlindex"
+)
+
+// EmailExtractor extracts RFC 5322 messages from .eml files and mbox
+// archives. Every message becomes its own document named by the file and its
+// Message-ID, such as inbox.mbox#<message-id>, so the same message in two
+// mailboxes is two documents. The sender, recipients, subject, date and
+// Message-ID are stored as metadata.
+type EmailExtractor struct {
+	// StripQuotes removes quoted replies and forwarded originals from message bodies.
+	StripQuotes bool
+}
+
+func NewEmailExtractor(stripQuotes bool) *EmailExtractor {
+	return &EmailExtractor{
+		StripQuotes: stripQuotes,
+	}
+}
+
+func (e *EmailExtractor) Match(name string, data []byte) bool {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".eml", ".mbox":
+		return true
+	}
+	return false
+}
+
+// Extract returns the first message of the file.
+func (e *EmailExtractor) Extract(name string, data []byte) (*Document, error) {
+	docs, err := e.ExtractAll(name, data)
+	if err != nil {
+		return nil, err
+	}
+	if len(docs) == 0 {
+		return &Document{}, nil
+	}
+	return docs[0], nil
+}
+
+func (e *EmailExtractor) ExtractAll(name string, data []byte) ([]*Document, error) {
+	if !isMbox(data) {
+		doc, err := e.extractMessage(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read message: %w", err)
+		}
+		doc.Name = messageName(name, doc, 1, nil)
+		return []*Document{doc}, nil
+	}
+
+	messages := splitMbox(data)
+	docs := make([]*Document, 0, len(messages))
+	taken := make(map[string]bool, len(messages))
+	for i, message := range messages {
+		doc, err := e.extractMessage(message)
+		if err != nil {
+			// One broken message doesn't lose the rest of the mailbox
+			slog.Warn("skipping mailbox message", slog.String("document_name", name), slog.Int("message", i+1), slog.String("error", err.Error()))
+			continue
+		}
+		doc.Name = messageName(name, doc, i+1, taken)
+		taken[doc.Name] = true
+		docs = append(docs, doc)
+	}
+	return docs, nil
+}
+
+// messageName names a message by the file and its Message-ID. Messages
+// without an ID are named by their 1-based position in the file, and so are
+// messages whose name is taken by an earlier copy, after their ID.
+func messageName(name string, doc *Document, position int, taken map[string]bool) string {
+	id, _ := doc.Metadata["message_id"].(string)
+	if id == "" {
+		return name + "#" + strconv.Itoa(position)
+	}
+	if taken[name+"#"+id] {
+		return name + "#" + id + "#" + strconv.Itoa(position)
+	}
+	return name + "#" + id
+}
+
+// isMbox reports whether data starts with an mbox "From " separator line.
+func isMbox(data []byte) bool {
+	return bytes.HasPrefix(data, []byte("From "))
+}
+
+// escapedFrom matches body lines mboxrd escapes so they aren't taken for separators.
+var escapedFrom = regexp.MustCompile(`^>+From `)
+
+// splitMbox splits an mbox archive on its "From " separator lines.
+func splitMbox(data []byte) [][]byte {
+	var messages [][]byte
+	var current *bytes.Buffer
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(nil, len(data)+1)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if bytes.HasPrefix(line, []byte("From ")) {
+			if current != nil {
+				messages = append(messages, current.Bytes())
+			}
+			current = &bytes.Buffer{}
+			continue
+		}
+		if current == nil {
+			continue
+		}
+		if escapedFrom.Match(line) {
+			line = line[1:]
+		}
+		current.Write(line)
+		current.WriteByte('\n')
+	}
+	if current != nil {
+		messages = append(messages, current.Bytes())
+	}
+	return messages
+}
+
+// wordDecoder decodes RFC 2047 encoded words in headers.
+var wordDecoder = &mime.WordDecoder{CharsetReader: charsetReader}
+
+func charsetReader(charset string, input io.Reader) (io.Reader, error) {
+	enc, err := htmlindex.Get(charset)
+	if err != nil {
+		return nil, fmt.Errorf("unsupported charset %s: %w", charset, err)
+	}
+	return enc.NewDecoder().Reader(input), nil
+}
+
+func decodeHeader(value string) string {
+	decoded, err := wordDecoder.DecodeHeader(value)
+	if err != nil {
+		return value
+	}
+	return decoded
+}
+
+func (e *EmailExtractor) extractMessage(data []byte) (*Document, error) {
+	msg, err := mail.ReadMessage(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	subject := strings.TrimSpace(decodeHeader(msg.Header.Get("Subject")))
+	metadata := make(map[string]any)
+	for _, key := range []string{"From", "To", "Cc"} {
+		if value := strings.TrimSpace(decodeHeader(msg.Header.Get(key))); value != "" {
+			metadata[strings.ToLower(key)] = value
+		}
+	}
+	if subject != "" {
+		metadata["subject"] = subject
+	}
+	if date, err := msg.Header.Date(); err == nil {
+		metadata["date"] = date.UTC().Format(time.RFC3339)
+	}
+	messageID := strings.Trim(strings.TrimSpace(msg.Header.Get("Message-ID")), "<>")
+	if messageID != "" {
+		metadata["message_id"] = messageID
+	}
+
+	body, err := messageBody(msg.Header, msg.Body)
+	if err != nil {
+		return nil, err
+	}
+	if e.StripQuotes {
+		body = stripQuotedReply(body)
+	}
+	body = strings.TrimSpace(body)
+
+	text := body
+	if subject != "" {
+		text = "# " + subject + "\n\n" + body
+	}
+	doc := &Document{
+		Title:    subject,
+		Metadata: metadata,
+	}
+	if text = strings.TrimSpace(text); text != "" {
+		doc.Sections = []Section{{Text: []byte(text + "\n")}}
+	}
+	return doc, nil
+}
+
+// partHeader is the header of a message or of one of its parts.
+type partHeader interface {
+	Get(key string) string
+}
+
+// messageBody returns the text of a message or part. Multipart alternatives
+// prefer plain text over HTML, attachments and non-text parts are skipped.
+func messageBody(header partHeader, body io.Reader) (string, error) {
+	mediaType, params, err := mime.ParseMediaType(header.Get("Content-Type"))
+	if err != nil {
+		mediaType = "text/plain"
+		params = nil
+	}
+	if disposition, _, err := mime.ParseMediaType(header.Get("Content-Disposition")); err == nil && disposition == "attachment" {
+		return "", nil
+	}
+
+	if strings.HasPrefix(mediaType, "multipart/") {
+		reader := multipart.NewReader(body, params["boundary"])
+		var texts []string
+		var alternatives []string
+		for {
+			part, err := reader.NextRawPart()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return "", fmt.Errorf("failed to read message part: %w", err)
+			}
+			text, err := messageBody(part.Header, part)
+			if err != nil {
+				return "", err
+			}
+			if text == "" {
+				continue
+			}
+			if mediaType == "multipart/alternative" {
+				// Parts are ordered by preference, the plain text comes first
+				alternatives = append(alternatives, text)
+				continue
+			}
+			texts = append(texts, text)
+		}
+		if len(alternatives) > 0 {
+			return alternatives[0], nil
+		}
+		return strings.Join(texts, "\n\n"), nil
+	}
+
+	if mediaType != "text/plain" && mediaType != "text/html" {
+		return "", nil
+	}
+
+	decoded, err := io.ReadAll(transferDecoder(header.Get("Content-Transfer-Encoding"), body))
+	if err != nil {
+		return "", fmt.Errorf("failed to decode message body: %w", err)
+	}
+	if charset := params["charset"]; charset != "" && !strings.EqualFold(charset, "utf-8") && !strings.EqualFold(charset, "us-ascii") {
+		if enc, err := htmlindex.Get(charset); err == nil {
+			if utf8, err := enc.NewDecoder().Bytes(decoded); err == nil {
+				decoded = utf8
+			}
+		}
+	}
+
+	if mediaType == "text/html" {
+		root, err := html.Parse(bytes.NewReader(decoded))
+		if err != nil {
+			return "", fmt.Errorf("failed to parse HTML body: %w", err)
+		}
+		return string(ConvertHTML(root)), nil
+	}
+	return strings.ReplaceAll(string(decoded), "\r\n", "\n"), nil
+}
+
+func transferDecoder(encoding string, body io.Reader) io.Reader {
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "quoted-printable":
+		return quotedprintable.NewReader(body)
+	case "base64":
+		return base64.NewDecoder(base64.StdEncoding, body)
+	}
+	return body
+}
+
+var (
+	// replyAttribution matches lines such as "On Mon, 3 Feb 2026, Ana wrote:".
+	replyAttribution = regexp.MustCompile(`(?i)^on .+ wrote:\s*$`)
+	// forwardedOriginal matches the separator above forwarded or replied-to messages.
+	forwardedOriginal = regexp.MustCompile(`(?i)^-{2,}\s*(original message|forwarded message)\s*-{2,}\s*$`)
+)
+
+// stripQuotedReply removes quoted lines with their attribution lines, and
+// everything from an original message separator on.
+func stripQuotedReply(body string) string {
+	lines := strings.Split(body, "\n")
+	kept := make([]string, 0, len(lines))
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if forwardedOriginal.MatchString(trimmed) {
+			break
+		}
+		if strings.HasPrefix(trimmed, ">") {
+			// Drop the attribution line and blank lines above the quote
+			for len(kept) > 0 {
+				last := strings.TrimSpace(kept[len(kept)-1])
+				if last != "" && !replyAttribution.MatchString(last) {
+					break
+				}
+				kept = kept[:len(kept)-1]
+			}
+			continue
+		}
+		kept = append(kept, line)
+	}
+	return strings.Join(kept, "\n")
+}
@@ -0,0 +1,82 @@
+package extractor
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEmailExtractor_ExtractAllMbox(t *testing.T) {
+	data, err := os.ReadFile("../test_data/dev-list.mbox")
+	require.NoError(t, err)
+
+	extractor := NewEmailExtractor(false)
+	require.True(t, extractor.Match("dev-list.mbox", data))
+	require.True(t, extractor.Match("message.EML", nil))
+
+	docs, err := extractor.ExtractAll("dev-list.mbox", data)
+	require.NoError(t, err)
+	require.Len(t, docs, 2)
+
+	require.Equal(t, "dev-list.mbox#build-1@example.com", docs[0].Name)
+	require.Equal(t, "Café build is broken", docs[0].Title)
+	require.Equal(t, map[string]any{
+		"from":       "Ana Silva <ana@example.com>",
+		"to":         "dev-list@example.com",
+		"subject":    "Café build is broken",
+		"date":       "2026-02-02T09:00:00Z",
+		"message_id": "build-1@example.com",
+	}, docs[0].Metadata)
+	// The quoted-printable body is decoded and the escaped "From " line restored
+	require.Equal(t, "# Café build is broken\n\n"+
+		"The nightly build fails on the linker step since yesterday. Can someone take a look?\n"+
+		"From the logs it looks like a missing symbol.\n", string(docs[0].Sections[0].Text))
+
+	// The plain text alternative is preferred and the attachment skipped
+	require.Equal(t, "dev-list.mbox#build-2@example.com", docs[1].Name)
+	require.Equal(t, "# Re: Cafe build is broken\n\n"+
+		"Fixed by pinning the linker version. Merci!\n\n"+
+		"On Mon, 2 Feb 2026, Ana Silva wrote:\n"+
+		"> The nightly build fails.\n", string(docs[1].Sections[0].Text))
+}
+
+func TestEmailExtractor_StripQuotes(t *testing.T) {
+	data, err := os.ReadFile("../test_data/dev-list.mbox")
+	require.NoError(t, err)
+
+	docs, err := NewEmailExtractor(true).ExtractAll("dev-list.mbox", data)
+	require.NoError(t, err)
+	require.Equal(t, "# Re: Cafe build is broken\n\nFixed by pinning the linker version. Merci!\n", string(docs[1].Sections[0].Text))
+}
+
+func TestEmailExtractor_ExtractMessageWithoutID(t *testing.T) {
+	data := []byte("From: ana@example.com\r\nSubject: Hello\r\n\r\n<p>Hi <b>team</b></p>\r\n")
+
+	docs, err := NewEmailExtractor(false).ExtractAll("hello.eml", data)
+	require.NoError(t, err)
+	require.Len(t, docs, 1)
+	require.Equal(t, "hello.eml#1", docs[0].Name)
+	require.Equal(t, "# Hello\n\n<p>Hi <b>team</b></p>\n", string(docs[0].Sections[0].Text))
+}
+
+func TestStripQuotedReply(t *testing.T) {
+	body := "Sounds good.\n\nOn Tue, Bo wrote:\n> Shall we ship?\n>\n> Bo\n\nThanks\n\n-----Original Message-----\nFrom: Bo\nOld text"
+	require.Equal(t, "Sounds good.\n\nThanks\n", stripQuotedReply(body))
+}
+
+func TestEmailExtractor_ExtractAllSkipsBrokenMessages(t *testing.T) {
+	data := []byte("From ana@example.com Mon Feb  2 09:00:00 2026\n" +
+		"Message-ID: <resent@example.com>\nSubject: Release\n\nShipping today.\n" +
+		"From ana@example.com Mon Feb  2 09:05:00 2026\n" +
+		"Not a header\n\nGarbled.\n" +
+		"From ana@example.com Mon Feb  2 09:10:00 2026\n" +
+		"Message-ID: <resent@example.com>\nSubject: Release\n\nShipping today, resent.\n")
+
+	docs, err := NewEmailExtractor(false).ExtractAll("inbox.mbox", data)
+	require.NoError(t, err)
+	require.Len(t, docs, 2)
+	// The copy with the same Message-ID gets its position too
+	require.Equal(t, "inbox.mbox#resent@example.com", docs[0].Name)
+	require.Equal(t, "inbox.mbox#resent@example.com#3", docs[1].Name)
+}
@@ -0,0 +1,203 @@
+package extractor
+
+import (
+	"bytes"
+	"log/slog"
+	"sort"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/unicode"
+	"golang.org/x/text/unicode/norm"
+)
+
+// Names of the encodings DecodeText detects, as recorded in document metadata.
+const (
+	EncodingUTF8        = "utf-8"
+	EncodingUTF16LE     = "utf-16le"
+	EncodingUTF16BE     = "utf-16be"
+	EncodingWindows1252 = "windows-1252"
+	EncodingLatin1      = "iso-8859-1"
+)
+
+var (
+	utf8BOM    = []byte{0xef, 0xbb, 0xbf}
+	utf16LEBOM = []byte{0xff, 0xfe}
+	utf16BEBOM = []byte{0xfe, 0xff}
+
+	replacementChar = []byte("\ufffd")
+)
+
+// DecodeText converts text to UTF-8 and returns the encoding it was in.
+// UTF-16 is recognized by its byte order mark. Text that isn't valid UTF-8 is
+// read as Windows-1252, or Latin-1 when it has no bytes that differ between
+// the two. When the encoding can't be determined the invalid bytes are
+// replaced with U+FFFD and the returned encoding is empty.
+func DecodeText(data []byte) ([]byte, string) {
+	switch {
+	case bytes.HasPrefix(data, utf8BOM):
+		return data[len(utf8BOM):], EncodingUTF8
+	case bytes.HasPrefix(data, utf16LEBOM):
+		return decodeWith(unicode.UTF16(unicode.LittleEndian, unicode.ExpectBOM), data, EncodingUTF16LE)
+	case bytes.HasPrefix(data, utf16BEBOM):
+		return decodeWith(unicode.UTF16(unicode.BigEndian, unicode.ExpectBOM), data, EncodingUTF16BE)
+	case utf8.Valid(data):
+		return data, EncodingUTF8
+	case looksBinary(data):
+		return bytes.ToValidUTF8(data, replacementChar), ""
+	}
+
+	usesWindows1252 := false
+	for _, b := range data {
+		if b < 0x80 || b > 0x9f {
+			continue
+		}
+		// Windows-1252 leaves these bytes undefined, so the text is in some
+		// other single-byte encoding
+		if b == 0x81 || b == 0x8d || b == 0x8f || b == 0x90 || b == 0x9d {
+			return bytes.ToValidUTF8(data, replacementChar), ""
+		}
+		usesWindows1252 = true
+	}
+	if usesWindows1252 {
+		return decodeWith(charmap.Windows1252, data, EncodingWindows1252)
+	}
+	return decodeWith(charmap.ISO8859_1, data, EncodingLatin1)
+}
+
+func decodeWith(enc encoding.Encoding, data []byte, name string) ([]byte, string) {
+	decoded, err := enc.NewDecoder().Bytes(data)
+	if err != nil {
+		return bytes.ToValidUTF8(data, replacementChar), ""
+	}
+	return decoded, name
+}
+
+// looksBinary reports whether data has NUL bytes, which no text in a
+// single-byte encoding does. It is usually UTF-16 without a byte order mark.
+func looksBinary(data []byte) bool {
+	return bytes.IndexByte(data, 0) >= 0
+}
+
+// binarySniffLength is how much of a file IsBinary looks at, as much as git does.
+const binarySniffLength = 8000
+
+// IsBinary reports whether data looks like the content of a binary file
+// rather than text: it has a NUL byte near the start. UTF-16 text with a byte
+// order mark isn't binary.
+func IsBinary(data []byte) bool {
+	if bytes.HasPrefix(data, utf16LEBOM) || bytes.HasPrefix(data, utf16BEBOM) {
+		return false
+	}
+	return looksBinary(data[:min(len(data), binarySniffLength)])
+}
+
+// unknownEncodingWarning is the warning returned with a document whose
+// encoding couldn't be determined.
+const unknownEncodingWarning = "could not determine the encoding, invalid characters were replaced"
+
+// decodeDocumentText decodes the text of an uploaded file, warning when its
+// encoding can't be determined.
+func decodeDocumentText(name string, data []byte) ([]byte, string) {
+	text, enc := DecodeText(data)
+	if enc == "" {
+		slog.Warn("could not determine document encoding, invalid characters were replaced", slog.String("document_name", name))
+	}
+	return text, enc
+}
+
+// NormalizeText puts extracted text in Unicode normalization form C, turns
+// CRLF and CR line endings into LF and removes control characters other
+// than tabs and line feeds, along with byte order marks.
+func NormalizeText(text []byte) []byte {
+	normalized, _ := NormalizeTextOffsets(text)
+	return normalized
+}
+
+// NormalizeTextOffsets normalizes text like NormalizeText and returns the
+// map from byte offsets of the normalized text back to offsets of text.
+func NormalizeTextOffsets(text []byte) ([]byte, *OffsetMap) {
+	offsets := &OffsetMap{}
+
+	// Line endings and control characters
+	var shifts []offsetShift
+	out := make([]byte, 0, len(text))
+	for i := 0; i < len(text); {
+		r, size := utf8.DecodeRune(text[i:])
+		switch {
+		case r == '\r':
+			out = append(out, '\n')
+			if i+1 < len(text) && text[i+1] == '\n' {
+				size++
+			}
+		case r == '\t' || r == '\n':
+			out = append(out, byte(r))
+		case r == '\ufeff' || r < 0x20 || (r >= 0x7f && r <= 0x9f):
+		default:
+			out = utf8.AppendRune(out, r)
+		}
+		i += size
+		shifts = appendShift(shifts, len(out), i)
+	}
+	offsets.stages = append(offsets.stages, shifts)
+
+	if norm.NFC.IsNormal(out) {
+		return out, offsets
+	}
+	shifts = nil
+	composed := make([]byte, 0, len(out))
+	var iter norm.Iter
+	iter.Init(norm.NFC, out)
+	for !iter.Done() {
+		composed = append(composed, iter.Next()...)
+		shifts = appendShift(shifts, len(composed), iter.Pos())
+	}
+	offsets.stages = append(offsets.stages, shifts)
+	return composed, offsets
+}
+
+// OffsetMap maps byte offsets of normalized text back to the text it was
+// normalized from.
+type OffsetMap struct {
+	// stages hold the shifts of each normalization step in the order they
+	// were applied.
+	stages [][]offsetShift
+}
+
+// offsetShift records that from the normalized offset on, offsets differ
+// from the original ones by the same amount as they do here.
+type offsetShift struct {
+	normalized int
+	original   int
+}
+
+// appendShift records the normalized and original offsets when they differ
+// by another amount than at the last shift.
+func appendShift(shifts []offsetShift, normalized, original int) []offsetShift {
+	delta := 0
+	if len(shifts) > 0 {
+		last := shifts[len(shifts)-1]
+		delta = last.original - last.normalized
+	}
+	if original-normalized == delta {
+		return shifts
+	}
+	return append(shifts, offsetShift{normalized: normalized, original: original})
+}
+
+// Original returns the offset in the original text of an offset in the
+// normalized text. A nil map leaves offsets as they are.
+func (m *OffsetMap) Original(offset int) int {
+	if m == nil {
+		return offset
+	}
+	for i := len(m.stages) - 1; i >= 0; i-- {
+		shifts := m.stages[i]
+		j := sort.Search(len(shifts), func(j int) bool { return shifts[j].normalized > offset })
+		if j > 0 {
+			offset += shifts[j-1].original - shifts[j-1].normalized
+		}
+	}
+	return offset
+}
@@ -0,0 +1,111 @@
+package extractor
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeText(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     []byte
+		text     string
+		encoding string
+	}{
+		{
+			name:     "utf-8",
+			data:     []byte("Café au lait"),
+			text:     "Café au lait",
+			encoding: EncodingUTF8,
+		},
+		{
+			name:     "utf-8 with BOM",
+			data:     []byte("\xef\xbb\xbfCafé"),
+			text:     "Café",
+			encoding: EncodingUTF8,
+		},
+		{
+			name:     "utf-16le",
+			data:     []byte("\xff\xfeC\x00a\x00f\x00\xe9\x00"),
+			text:     "Café",
+			encoding: EncodingUTF16LE,
+		},
+		{
+			name:     "utf-16be",
+			data:     []byte("\xfe\xff\x00C\x00a\x00f\x00\xe9"),
+			text:     "Café",
+			encoding: EncodingUTF16BE,
+		},
+		{
+			name:     "windows-1252",
+			data:     []byte("\x93Caf\xe9\x94 \x96 5 \x80"),
+			text:     "“Café” – 5 €",
+			encoding: EncodingWindows1252,
+		},
+		{
+			name:     "latin-1",
+			data:     []byte("Caf\xe9 cr\xe8me"),
+			text:     "Café crème",
+			encoding: EncodingLatin1,
+		},
+		{
+			name:     "undefined windows-1252 byte",
+			data:     []byte("Caf\xe9 \x81"),
+			text:     "Caf� �",
+			encoding: "",
+		},
+		{
+			name:     "utf-16 without BOM",
+			data:     []byte("C\x00a\x00f\x00\xe9\x00"),
+			text:     "C\x00a\x00f\x00�\x00",
+			encoding: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			text, encoding := DecodeText(tt.data)
+			require.Equal(t, tt.text, string(text))
+			require.Equal(t, tt.encoding, encoding)
+		})
+	}
+}
+
+func TestNormalizeText(t *testing.T) {
+	// "e" followed by a combining acute accent composes to "é"
+	text := NormalizeText([]byte("\ufeffCafe\u0301\r\nline\rtwo\x00\x07\tend\u0085\n"))
+	require.Equal(t, "Café\nline\ntwo\tend\n", string(text))
+}
+
+func TestNormalizeTextOffsets(t *testing.T) {
+	original := []byte("\ufeffCafe\u0301\r\n\r\nsecond\x00 para\r\n")
+	text, offsets := NormalizeTextOffsets(original)
+	require.Equal(t, "Café\n\nsecond para\n", string(text))
+
+	start := strings.Index(string(text), "second")
+	require.Equal(t, "second\x00 para", string(original[offsets.Original(start):offsets.Original(len(text)-1)]))
+	require.Equal(t, len("\ufeff"), offsets.Original(0))
+	require.Equal(t, len(original), offsets.Original(len(text)))
+}
+
+func TestPlainText_RecordsEncoding(t *testing.T) {
+	doc := PlainText("notes.txt", []byte("\xff\xfeh\x00i\x00"))
+	require.Equal(t, map[string]any{"encoding": EncodingUTF16LE}, doc.Metadata)
+	require.Equal(t, "hi", string(doc.Sections[0].Text))
+
+	doc = PlainText("notes.txt", []byte("\x00\x81"))
+	require.Nil(t, doc.Metadata)
+	require.Len(t, doc.Warnings, 1)
+}
+
+func TestIsBinary(t *testing.T) {
+	require.False(t, IsBinary([]byte("plain text")))
+	require.False(t, IsBinary([]byte("\xff\xfeh\x00i\x00")))
+	require.True(t, IsBinary([]byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")))
+
+	// Only the start of the file is looked at
+	late := append(bytes.Repeat([]byte("a"), binarySniffLength), 0)
+	require.False(t, IsBinary(late))
+}
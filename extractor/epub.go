@@ -0,0 +1,235 @@
+package extractor
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"net/url"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/MaxIvanyshen/local-rag/chunker"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// EPUBExtractor extracts the chapters of EPUB e-books in reading order, one
+// section per chapter, titled after the table of contents.
+type EPUBExtractor struct{}
+
+func (e *EPUBExtractor) Match(name string, data []byte) bool {
+	return strings.EqualFold(filepath.Ext(name), ".epub") || hasMediaType(data, "application/epub+zip")
+}
+
+// opfPackage is the package document listing the files of the book and
+// their reading order.
+type opfPackage struct {
+	Title    []string `xml:"metadata>title"`
+	Creator  []string `xml:"metadata>creator"`
+	Language []string `xml:"metadata>language"`
+	Manifest []struct {
+		ID         string `xml:"id,attr"`
+		Href       string `xml:"href,attr"`
+		MediaType  string `xml:"media-type,attr"`
+		Properties string `xml:"properties,attr"`
+	} `xml:"manifest>item"`
+	Spine struct {
+		TOC      string `xml:"toc,attr"`
+		ItemRefs []struct {
+			IDRef string `xml:"idref,attr"`
+		} `xml:"itemref"`
+	} `xml:"spine"`
+}
+
+func (e *EPUBExtractor) Extract(name string, data []byte) (*Document, error) {
+	zr, err := openPackage(data)
+	if err != nil {
+		return nil, err
+	}
+	opfPath, err := epubRootFile(zr)
+	if err != nil {
+		return nil, err
+	}
+	opfData, err := readPart(zr, opfPath)
+	if err != nil {
+		return nil, err
+	}
+	var opf opfPackage
+	if err := xml.Unmarshal(opfData, &opf); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", opfPath, err)
+	}
+
+	// Manifest paths are relative to the package document
+	opfDir := path.Dir(opfPath)
+	items := make(map[string]string, len(opf.Manifest))
+	mediaTypes := make(map[string]string, len(opf.Manifest))
+	var navPath string
+	for _, item := range opf.Manifest {
+		itemPath := resolveHref(opfDir, item.Href)
+		items[item.ID] = itemPath
+		mediaTypes[item.ID] = item.MediaType
+		if strings.Contains(" "+item.Properties+" ", " nav ") {
+			navPath = itemPath
+		}
+	}
+
+	titles := make(map[string]string)
+	if navPath != "" {
+		titles = navTitles(zr, navPath)
+	} else if ncxPath, ok := items[opf.Spine.TOC]; ok {
+		titles = ncxTitles(zr, ncxPath)
+	}
+
+	doc := &Document{}
+	if len(opf.Title) > 0 {
+		doc.Title = strings.TrimSpace(opf.Title[0])
+	}
+	doc.Metadata = make(map[string]any)
+	if len(opf.Creator) > 0 {
+		doc.Metadata["author"] = strings.TrimSpace(opf.Creator[0])
+	}
+	if len(opf.Language) > 0 {
+		doc.Metadata["language"] = strings.TrimSpace(opf.Language[0])
+	}
+
+	for _, ref := range opf.Spine.ItemRefs {
+		chapterPath, ok := items[ref.IDRef]
+		if !ok || !isXHTML(mediaTypes[ref.IDRef]) {
+			continue
+		}
+		chapterData, err := readPart(zr, chapterPath)
+		if err != nil {
+			return nil, err
+		}
+		root, err := html.Parse(bytes.NewReader(chapterData))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", chapterPath, err)
+		}
+		text := ConvertHTML(root)
+		if len(text) == 0 {
+			continue
+		}
+
+		section := Section{Text: text, Format: chunker.FormatMarkdown}
+		section.Title = titles[chapterPath]
+		if section.Title == "" {
+			section.Title = chapterTitle(root)
+		}
+		if section.Title != "" {
+			section.Metadata = map[string]any{"chapter": section.Title}
+		}
+		doc.Sections = append(doc.Sections, section)
+	}
+	return doc, nil
+}
+
+func isXHTML(mediaType string) bool {
+	return mediaType == "application/xhtml+xml" || mediaType == "text/html"
+}
+
+// resolveHref returns the package path of a link relative to dir, without
+// its fragment.
+func resolveHref(dir, href string) string {
+	href, _, _ = strings.Cut(href, "#")
+	if unescaped, err := url.PathUnescape(href); err == nil {
+		href = unescaped
+	}
+	return path.Join(dir, href)
+}
+
+// epubRootFile returns the path of the package document.
+func epubRootFile(zr *zip.Reader) (string, error) {
+	data, err := readPart(zr, "META-INF/container.xml")
+	if err != nil {
+		return "", err
+	}
+	var container struct {
+		RootFiles []struct {
+			FullPath string `xml:"full-path,attr"`
+		} `xml:"rootfiles>rootfile"`
+	}
+	if err := xml.Unmarshal(data, &container); err != nil {
+		return "", fmt.Errorf("failed to parse container: %w", err)
+	}
+	if len(container.RootFiles) == 0 {
+		return "", fmt.Errorf("container has no package document")
+	}
+	return container.RootFiles[0].FullPath, nil
+}
+
+// navTitles maps chapter paths to their titles in an EPUB 3 navigation
+// document. A broken table of contents only loses the titles.
+func navTitles(zr *zip.Reader, navPath string) map[string]string {
+	titles := make(map[string]string)
+	data, err := readPart(zr, navPath)
+	if err != nil {
+		return titles
+	}
+	root, err := html.Parse(bytes.NewReader(data))
+	if err != nil {
+		return titles
+	}
+	for _, link := range findElements(root, atom.A) {
+		href := attr(link, "href")
+		title := strings.Join(strings.Fields(nodeText(link)), " ")
+		chapterPath := resolveHref(path.Dir(navPath), href)
+		if href == "" || title == "" || titles[chapterPath] != "" {
+			continue
+		}
+		titles[chapterPath] = title
+	}
+	return titles
+}
+
+// ncxTitles maps chapter paths to their titles in an EPUB 2 NCX table of
+// contents. A broken table of contents only loses the titles.
+func ncxTitles(zr *zip.Reader, ncxPath string) map[string]string {
+	titles := make(map[string]string)
+	data, err := readPart(zr, ncxPath)
+	if err != nil {
+		return titles
+	}
+
+	type navPoint struct {
+		Label   string `xml:"navLabel>text"`
+		Content struct {
+			Src string `xml:"src,attr"`
+		} `xml:"content"`
+		Children []navPoint `xml:"navPoint"`
+	}
+	var ncx struct {
+		NavPoints []navPoint `xml:"navMap>navPoint"`
+	}
+	if err := xml.Unmarshal(data, &ncx); err != nil {
+		return titles
+	}
+
+	var walk func(points []navPoint)
+	walk = func(points []navPoint) {
+		for _, point := range points {
+			chapterPath := resolveHref(path.Dir(ncxPath), point.Content.Src)
+			title := strings.Join(strings.Fields(point.Label), " ")
+			if point.Content.Src != "" && title != "" && titles[chapterPath] == "" {
+				titles[chapterPath] = title
+			}
+			walk(point.Children)
+		}
+	}
+	walk(ncx.NavPoints)
+	return titles
+}
+
+// chapterTitle returns the first heading of a chapter missing from the table
+// of contents, or its <title>.
+func chapterTitle(root *html.Node) string {
+	for _, a := range []atom.Atom{atom.H1, atom.H2, atom.H3, atom.Title} {
+		if n := findElement(root, a); n != nil {
+			if title := strings.Join(strings.Fields(nodeText(n)), " "); title != "" {
+				return title
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,68 @@
+package extractor
+
+import (
+	"archive/zip"
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/MaxIvanyshen/local-rag/chunker"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEPUBExtractor_Extract(t *testing.T) {
+	data, err := os.ReadFile("../test_data/sample.epub")
+	require.NoError(t, err)
+
+	extractor := &EPUBExtractor{}
+	require.True(t, extractor.Match("upload.bin", data))
+	require.False(t, (&ODTExtractor{}).Match("upload.bin", data))
+
+	doc, err := extractor.Extract("sample.epub", data)
+	require.NoError(t, err)
+	require.Equal(t, "The Lighthouse Keeper", doc.Title)
+	require.Equal(t, map[string]any{"author": "A. Writer", "language": "en"}, doc.Metadata)
+
+	// Chapters follow the spine, not the manifest, and are titled after the
+	// table of contents even when the text has no heading
+	require.Equal(t, []Section{
+		{
+			Title:    "Chapter 1: The Storm",
+			Text:     []byte("# Chapter 1: The Storm\n\nThe wind rose over the rocks.\n\n## Landfall\n\nThe boat reached the shore.\n"),
+			Metadata: map[string]any{"chapter": "Chapter 1: The Storm"},
+			Format:   chunker.FormatMarkdown,
+		},
+		{
+			Title:    "Chapter 2: The Lamp",
+			Text:     []byte("The keeper trimmed the lamp wick every evening.\n"),
+			Metadata: map[string]any{"chapter": "Chapter 2: The Lamp"},
+			Format:   chunker.FormatMarkdown,
+		},
+	}, doc.Sections)
+}
+
+func TestEPUBExtractor_NavDocument(t *testing.T) {
+	nav := []byte(`<html xmlns:epub="http://www.idpf.org/2007/ops"><body>
+<nav epub:type="toc"><ol><li><a href="ch1.xhtml">Opening</a></li></ol></nav>
+</body></html>`)
+	zr := zipOf(t, map[string][]byte{"OPS/nav.xhtml": nav})
+
+	require.Equal(t, map[string]string{"OPS/ch1.xhtml": "Opening"}, navTitles(zr, "OPS/nav.xhtml"))
+}
+
+// zipOf builds a zip archive holding the files.
+func zipOf(t *testing.T, files map[string][]byte) *zip.Reader {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, data := range files {
+		w, err := zw.Create(name)
+		require.NoError(t, err)
+		_, err = w.Write(data)
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+	return zr
+}
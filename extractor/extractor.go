@@ -0,0 +1,187 @@
+package extractor
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"strings"
+
+	"github.com/MaxIvanyshen/local-rag/chunker"
+)
+
+// Document is the text extracted from an uploaded file, ready for chunking.
+type Document struct {
+	// Name is set on documents split out of a file holding several, such as
+	// the messages of a mailbox. It replaces the name of the file.
+	Name string
+	// Title is the document title when the format records one.
+	Title string
+	// Metadata holds document-level properties such as the author.
+	Metadata map[string]any
+	// Warnings tell about problems reading the file that didn't stop its
+	// text from being extracted, such as an unknown encoding.
+	Warnings []string
+	// Sections are the parts of the document in reading order. Each section
+	// is chunked on its own and chunk positions are relative to it.
+	Sections []Section
+}
+
+// Section is a part of a document that maps to a location in the source
+// file, such as a PDF page or a spreadsheet sheet.
+type Section struct {
+	// Title is the heading the section falls under, such as an e-book
+	// chapter title that isn't part of the text.
+	Title string
+	Text  []byte
+	// Page is the 1-based page number, or 0 when the format has no pages.
+	Page int
+	// Metadata identifies the section within the file, such as a slide
+	// number or sheet name, and is stored on every chunk cut from it.
+	Metadata map[string]any
+	// Format names the format of Text when it is chunked differently from
+	// prose, one of the chunker formats such as chunker.FormatCSV.
+	Format string
+	// LineOffset and ByteOffset are where Text starts in the file, when the
+	// file has lines or bytes before it that aren't part of the text, such as
+	// a byte order mark or the front matter of a note.
+	LineOffset int
+	ByteOffset int
+}
+
+// Extractor turns the raw bytes of a document of a particular format into text.
+type Extractor interface {
+	// Match reports whether the extractor handles the document.
+	Match(name string, data []byte) bool
+	Extract(name string, data []byte) (*Document, error)
+}
+
+// MultiExtractor is implemented by extractors of files that hold several
+// documents. Documents split out of the file have a Name, a file holding a
+// single document may return it without one.
+type MultiExtractor interface {
+	Extractor
+	ExtractAll(name string, data []byte) ([]*Document, error)
+}
+
+// WindowExtractor is implemented by extractors of text formats that can be
+// read a window at a time, for files too large to hold in memory. The first
+// window of a UTF-8 file is extracted with Extract, and every later window
+// with ExtractWindow, which adds what it finds in the window to the document
+// of the first.
+type WindowExtractor interface {
+	Extractor
+	ExtractWindow(doc *Document, window []byte) Section
+}
+
+// PlainText returns data as a document with a single section, converted
+// to UTF-8. The detected encoding is recorded in the document metadata.
+func PlainText(name string, data []byte) *Document {
+	text, enc := decodeDocumentText(name, data)
+	doc := &Document{
+		Sections: []Section{{Text: text, Format: textFormat(name)}},
+	}
+	// Chunk offsets count from the start of a UTF-8 file, not its text
+	if bytes.HasPrefix(data, utf8BOM) {
+		doc.Sections[0].ByteOffset = len(utf8BOM)
+	}
+	if enc != "" {
+		doc.Metadata = map[string]any{"encoding": enc}
+	} else {
+		doc.Warnings = []string{unknownEncodingWarning}
+	}
+	return doc
+}
+
+// textFormat returns the chunker format of a text file by its extension.
+func textFormat(name string) string {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".csv":
+		return chunker.FormatCSV
+	case ".tsv", ".tab":
+		return chunker.FormatTSV
+	case ".md", ".markdown":
+		return chunker.FormatMarkdown
+	}
+	return ""
+}
+
+// Registry dispatches a document to the first extractor that matches it.
+// Documents no extractor matches are treated as plain text.
+type Registry struct {
+	extractors []Extractor
+}
+
+func NewRegistry(extractors ...Extractor) *Registry {
+	return &Registry{
+		extractors: extractors,
+	}
+}
+
+func (r *Registry) Match(name string, data []byte) bool {
+	return true
+}
+
+// Supports reports whether a registered extractor handles the document,
+// rather than it falling back to plain text.
+func (r *Registry) Supports(name string, data []byte) bool {
+	return r.Lookup(name, data) != nil
+}
+
+// Lookup returns the registered extractor that handles the document, or nil
+// when it falls back to plain text.
+func (r *Registry) Lookup(name string, data []byte) Extractor {
+	for _, e := range r.extractors {
+		if registry, ok := e.(*Registry); ok {
+			if found := registry.Lookup(name, data); found != nil {
+				return found
+			}
+			continue
+		}
+		if e.Match(name, data) {
+			return e
+		}
+	}
+	return nil
+}
+
+func (r *Registry) Extract(name string, data []byte) (*Document, error) {
+	for _, e := range r.extractors {
+		if !e.Match(name, data) {
+			continue
+		}
+		doc, err := e.Extract(name, data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to extract %s: %w", name, err)
+		}
+		slog.Debug("extracted document", slog.String("document_name", name), slog.Int("sections", len(doc.Sections)))
+		return doc, nil
+	}
+	return PlainText(name, data), nil
+}
+
+// ExtractAll extracts every document held in the file. Files handled by an
+// extractor that is not a MultiExtractor hold a single document.
+func (r *Registry) ExtractAll(name string, data []byte) ([]*Document, error) {
+	for _, e := range r.extractors {
+		if !e.Match(name, data) {
+			continue
+		}
+		multi, ok := e.(MultiExtractor)
+		if !ok {
+			break
+		}
+		docs, err := multi.ExtractAll(name, data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to extract %s: %w", name, err)
+		}
+		slog.Debug("extracted documents", slog.String("document_name", name), slog.Int("documents", len(docs)))
+		return docs, nil
+	}
+
+	doc, err := r.Extract(name, data)
+	if err != nil {
+		return nil, err
+	}
+	return []*Document{doc}, nil
+}
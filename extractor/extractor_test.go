@@ -0,0 +1,62 @@
+package extractor
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistry_FallsBackToPlainText(t *testing.T) {
+	registry := NewRegistry(&PDFExtractor{})
+	doc, err := registry.Extract("notes.txt", []byte("plain text"))
+	require.NoError(t, err)
+	require.Equal(t, PlainText("notes.txt", []byte("plain text")), doc)
+}
+
+func TestRegistry_Supports(t *testing.T) {
+	files := NewRegistry(&PDFExtractor{})
+	registry := NewRegistry(NewArchiveExtractor(files, ArchiveLimits{}), files)
+	require.True(t, registry.Supports("guide.pdf", nil))
+	require.True(t, registry.Supports("docs.zip", nil))
+	require.False(t, registry.Supports("notes.txt", []byte("plain text")))
+}
+
+func TestPDFExtractor_Extract(t *testing.T) {
+	data, err := os.ReadFile("../test_data/sample.pdf")
+	require.NoError(t, err)
+
+	extractor := &PDFExtractor{}
+	require.True(t, extractor.Match("upload.bin", data))
+	require.True(t, extractor.Match("guide.PDF", nil))
+	require.False(t, extractor.Match("guide.md", []byte("# Guide")))
+
+	doc, err := extractor.Extract("sample.pdf", data)
+	require.NoError(t, err)
+	require.Equal(t, "Sample Vendor Guide", doc.Title)
+	require.Len(t, doc.Sections, 2)
+	require.Equal(t, 1, doc.Sections[0].Page)
+	require.Contains(t, string(doc.Sections[0].Text), "Page one covers installation.")
+	require.Equal(t, 2, doc.Sections[1].Page)
+	require.Contains(t, string(doc.Sections[1].Text), "Page two covers the zqx settings.")
+}
+
+func TestPDFExtractor_InvalidPDF(t *testing.T) {
+	_, err := NewRegistry(&PDFExtractor{}).Extract("broken.pdf", []byte("%PDF-1.4 garbage"))
+	require.Error(t, err)
+}
+
+func TestPDFExtractor_CorruptedPDF(t *testing.T) {
+	data, err := os.ReadFile("../test_data/sample.pdf")
+	require.NoError(t, err)
+
+	// The pdf package panics on an object that isn't closed
+	for name, data := range map[string][]byte{
+		"truncated.pdf": data[:len(data)/2],
+		"corrupted.pdf": bytes.Replace(data, []byte("endobj"), []byte("endobx"), 1),
+	} {
+		_, err := (&PDFExtractor{}).Extract(name, data)
+		require.Error(t, err, name)
+	}
+}
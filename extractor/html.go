@@ -0,0 +1,355 @@
+package extractor
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/MaxIvanyshen/local-rag/chunker"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// HTMLExtractor converts HTML pages into Markdown-like text. Headings, lists,
+// code blocks, tables and link text are kept, while scripts, styles,
+// navigation and footers are dropped.
+type HTMLExtractor struct{}
+
+func (h *HTMLExtractor) Match(name string, data []byte) bool {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".html", ".htm", ".xhtml":
+		return true
+	}
+	head := bytes.ToLower(bytes.TrimLeft(data[:min(len(data), 512)], "\ufeff \t\r\n"))
+	return bytes.HasPrefix(head, []byte("<!doctype html")) || bytes.HasPrefix(head, []byte("<html"))
+}
+
+func (h *HTMLExtractor) Extract(name string, data []byte) (*Document, error) {
+	root, err := html.Parse(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse HTML: %w", err)
+	}
+
+	doc := &Document{
+		Title:    strings.TrimSpace(nodeText(findElement(root, atom.Title))),
+		Metadata: htmlMetadata(root),
+	}
+	if text := ConvertHTML(root); len(text) > 0 {
+		doc.Sections = []Section{{Text: text, Format: chunker.FormatMarkdown}}
+	}
+	return doc, nil
+}
+
+// ConvertHTML renders the main content of a parsed HTML document as text.
+func ConvertHTML(root *html.Node) []byte {
+	c := &htmlConverter{}
+	c.walk(contentRoot(root))
+	return c.finish()
+}
+
+// skippedElements never contain content worth indexing.
+var skippedElements = map[atom.Atom]bool{
+	atom.Head:     true,
+	atom.Script:   true,
+	atom.Style:    true,
+	atom.Noscript: true,
+	atom.Template: true,
+	atom.Nav:      true,
+	atom.Footer:   true,
+	atom.Aside:    true,
+	atom.Form:     true,
+	atom.Button:   true,
+	atom.Svg:      true,
+	atom.Iframe:   true,
+}
+
+// skippedRoles are ARIA roles of boilerplate page regions.
+var skippedRoles = map[string]bool{
+	"navigation":    true,
+	"contentinfo":   true,
+	"banner":        true,
+	"search":        true,
+	"complementary": true,
+}
+
+// contentRoot returns the element holding the main content of the page: the
+// <main> element, a lone <article>, or the <body>.
+func contentRoot(root *html.Node) *html.Node {
+	if main := findElement(root, atom.Main); main != nil {
+		return main
+	}
+	if articles := findElements(root, atom.Article); len(articles) == 1 {
+		return articles[0]
+	}
+	if body := findElement(root, atom.Body); body != nil {
+		return body
+	}
+	return root
+}
+
+func findElement(n *html.Node, a atom.Atom) *html.Node {
+	if n.Type == html.ElementNode && n.DataAtom == a {
+		return n
+	}
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if found := findElement(child, a); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+func findElements(n *html.Node, a atom.Atom) []*html.Node {
+	var found []*html.Node
+	if n.Type == html.ElementNode && n.DataAtom == a {
+		found = append(found, n)
+	}
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		found = append(found, findElements(child, a)...)
+	}
+	return found
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+// nodeText returns the concatenated text of a node and its descendants.
+func nodeText(n *html.Node) string {
+	if n == nil {
+		return ""
+	}
+	if n.Type == html.TextNode {
+		return n.Data
+	}
+	var sb strings.Builder
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		sb.WriteString(nodeText(child))
+	}
+	return sb.String()
+}
+
+// htmlMetadata collects the description, author and keywords meta tags.
+func htmlMetadata(root *html.Node) map[string]any {
+	metadata := make(map[string]any)
+	for _, meta := range findElements(root, atom.Meta) {
+		name := strings.ToLower(attr(meta, "name"))
+		switch name {
+		case "description", "author", "keywords":
+			if content := strings.TrimSpace(attr(meta, "content")); content != "" {
+				metadata[name] = content
+			}
+		}
+	}
+	if len(metadata) == 0 {
+		return nil
+	}
+	return metadata
+}
+
+type htmlList struct {
+	ordered bool
+	count   int
+}
+
+// htmlConverter writes HTML nodes out as Markdown-like text.
+type htmlConverter struct {
+	buf   bytes.Buffer
+	lists []htmlList
+	inPre int
+}
+
+// trimTrailingSpace removes spaces and tabs from the end of the output.
+func (c *htmlConverter) trimTrailingSpace() {
+	c.buf.Truncate(len(bytes.TrimRight(c.buf.Bytes(), " \t")))
+}
+
+// lineBreak makes the output end with a newline.
+func (c *htmlConverter) lineBreak() {
+	c.trimTrailingSpace()
+	if c.buf.Len() > 0 && !bytes.HasSuffix(c.buf.Bytes(), []byte("\n")) {
+		c.buf.WriteByte('\n')
+	}
+}
+
+// blockBreak makes the output end with a blank line.
+func (c *htmlConverter) blockBreak() {
+	c.lineBreak()
+	if c.buf.Len() > 0 && !bytes.HasSuffix(c.buf.Bytes(), []byte("\n\n")) {
+		c.buf.WriteByte('\n')
+	}
+}
+
+func (c *htmlConverter) writeText(text string) {
+	if c.inPre > 0 {
+		c.buf.WriteString(text)
+		return
+	}
+	// Collapse runs of whitespace but keep a single space at the edges, so
+	// text around inline elements doesn't run together
+	collapsed := strings.Join(strings.Fields(text), " ")
+	if collapsed == "" {
+		if text != "" {
+			collapsed = " "
+		}
+	} else {
+		if isSpace(text[0]) {
+			collapsed = " " + collapsed
+		}
+		if isSpace(text[len(text)-1]) {
+			collapsed += " "
+		}
+	}
+	if c.buf.Len() == 0 || isSpace(c.buf.Bytes()[c.buf.Len()-1]) {
+		collapsed = strings.TrimLeft(collapsed, " ")
+	}
+	c.buf.WriteString(collapsed)
+}
+
+func isSpace(b byte) bool {
+	return b == ' ' || b == '\t' || b == '\n' || b == '\r' || b == '\f'
+}
+
+func (c *htmlConverter) children(n *html.Node) {
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		c.walk(child)
+	}
+}
+
+func (c *htmlConverter) walk(n *html.Node) {
+	switch n.Type {
+	case html.TextNode:
+		c.writeText(n.Data)
+		return
+	case html.ElementNode:
+	default:
+		c.children(n)
+		return
+	}
+
+	if skippedElements[n.DataAtom] || skippedRoles[attr(n, "role")] {
+		return
+	}
+
+	switch n.DataAtom {
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+		level, _ := strconv.Atoi(n.Data[1:])
+		c.blockBreak()
+		c.buf.WriteString(strings.Repeat("#", level) + " ")
+		c.children(n)
+		c.blockBreak()
+	case atom.P, atom.Div, atom.Section, atom.Article, atom.Main, atom.Header,
+		atom.Blockquote, atom.Figure, atom.Figcaption, atom.Dl, atom.Details, atom.Summary:
+		c.blockBreak()
+		c.children(n)
+		c.blockBreak()
+	case atom.Ul, atom.Ol:
+		if len(c.lists) == 0 {
+			c.blockBreak()
+		} else {
+			c.lineBreak()
+		}
+		c.lists = append(c.lists, htmlList{ordered: n.DataAtom == atom.Ol})
+		c.children(n)
+		c.lists = c.lists[:len(c.lists)-1]
+		if len(c.lists) == 0 {
+			c.blockBreak()
+		} else {
+			c.lineBreak()
+		}
+	case atom.Li:
+		c.lineBreak()
+		prefix := "- "
+		if len(c.lists) > 0 {
+			list := &c.lists[len(c.lists)-1]
+			list.count++
+			if list.ordered {
+				prefix = strconv.Itoa(list.count) + ". "
+			}
+			c.buf.WriteString(strings.Repeat("  ", len(c.lists)-1))
+		}
+		c.buf.WriteString(prefix)
+		c.children(n)
+		c.lineBreak()
+	case atom.Dt, atom.Dd, atom.Tr:
+		c.lineBreak()
+		c.children(n)
+		c.lineBreak()
+	case atom.Pre:
+		c.blockBreak()
+		c.buf.WriteString("```\n")
+		c.inPre++
+		c.children(n)
+		c.inPre--
+		if !bytes.HasSuffix(c.buf.Bytes(), []byte("\n")) {
+			c.buf.WriteByte('\n')
+		}
+		c.buf.WriteString("```")
+		c.blockBreak()
+	case atom.Code:
+		if c.inPre > 0 {
+			c.children(n)
+			return
+		}
+		c.buf.WriteByte('`')
+		c.children(n)
+		c.buf.WriteByte('`')
+	case atom.Br:
+		if c.inPre > 0 {
+			c.buf.WriteByte('\n')
+			return
+		}
+		c.lineBreak()
+	case atom.Hr:
+		c.blockBreak()
+	case atom.Table:
+		c.blockBreak()
+		c.table(n)
+		c.blockBreak()
+	case atom.Img:
+		if alt := strings.TrimSpace(attr(n, "alt")); alt != "" {
+			c.writeText(alt + " ")
+		}
+	default:
+		c.children(n)
+	}
+}
+
+// table writes a table as a Markdown table, using the first row as header.
+func (c *htmlConverter) table(n *html.Node) {
+	rows := findElements(n, atom.Tr)
+	for i, row := range rows {
+		var cells []string
+		for cell := row.FirstChild; cell != nil; cell = cell.NextSibling {
+			if cell.Type != html.ElementNode || (cell.DataAtom != atom.Td && cell.DataAtom != atom.Th) {
+				continue
+			}
+			inner := &htmlConverter{}
+			inner.children(cell)
+			text := strings.Join(strings.Fields(string(inner.buf.Bytes())), " ")
+			cells = append(cells, strings.ReplaceAll(text, "|", `\|`))
+		}
+		if len(cells) == 0 {
+			continue
+		}
+		c.buf.WriteString("| " + strings.Join(cells, " | ") + " |\n")
+		if i == 0 {
+			c.buf.WriteString(strings.Repeat("| --- ", len(cells)) + "|\n")
+		}
+	}
+}
+
+func (c *htmlConverter) finish() []byte {
+	text := bytes.TrimSpace(c.buf.Bytes())
+	if len(text) == 0 {
+		return nil
+	}
+	return append(text, '\n')
+}
@@ -0,0 +1,51 @@
+package extractor
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTMLExtractor_Extract(t *testing.T) {
+	data, err := os.ReadFile("../test_data/sample.html")
+	require.NoError(t, err)
+
+	extractor := &HTMLExtractor{}
+	require.True(t, extractor.Match("upload.bin", data))
+	require.True(t, extractor.Match("page.HTM", nil))
+	require.False(t, extractor.Match("notes.md", []byte("# Notes")))
+
+	doc, err := extractor.Extract("sample.html", data)
+	require.NoError(t, err)
+	require.Equal(t, "Widget Setup Guide", doc.Title)
+	require.Equal(t, map[string]any{"description": "How to set up the widget"}, doc.Metadata)
+	require.Len(t, doc.Sections, 1)
+
+	expected := "# Widget Setup\n\n" +
+		"Install the widget package before you start.\n\n" +
+		"## Steps\n\n" +
+		"1. Download the archive\n" +
+		"2. Run the installer\n" +
+		"  - Accept the license\n\n" +
+		"```\nwidget --configure\nwidget --start\n```\n\n" +
+		"| Option | Default |\n" +
+		"| --- | --- |\n" +
+		"| port | 8080 |\n"
+	require.Equal(t, expected, string(doc.Sections[0].Text))
+}
+
+func TestHTMLExtractor_DropsBoilerplate(t *testing.T) {
+	data := []byte(`<html><body>
+<header role="banner">Site name</header>
+<div role="navigation">Menu</div>
+<p>Body text</p>
+<aside>Related posts</aside>
+<noscript>Enable JavaScript</noscript>
+</body></html>`)
+
+	doc, err := (&HTMLExtractor{}).Extract("page.html", data)
+	require.NoError(t, err)
+	require.Empty(t, doc.Title)
+	require.Equal(t, "Body text\n", string(doc.Sections[0].Text))
+}
@@ -0,0 +1,277 @@
+package extractor
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// JSONExtractor splits JSON Lines files, and JSON files holding an array,
+// into records. Every record becomes its own section, so it is chunked on its
+// own and search results carry its ID.
+//
+// Paths select fields with dot-separated keys and array indices, where "*"
+// matches every element of an array, e.g. "fields.summary" or "comments.*.body".
+type JSONExtractor struct {
+	// TextPaths select the fields that are indexed. When none of them is
+	// present the whole record is indexed as "key: value" lines.
+	TextPaths []string
+	// TitlePath selects the record title, used as its heading.
+	TitlePath string
+	// IDPath selects the record ID. Records without one are identified by
+	// their 1-based position in the file.
+	IDPath string
+	// MetadataPaths select fields stored as metadata on the record's chunks.
+	MetadataPaths []string
+}
+
+func (j *JSONExtractor) Match(name string, data []byte) bool {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".json", ".jsonl", ".ndjson":
+		return true
+	}
+	return false
+}
+
+// jsonRecord is a decoded record and the line of the file it starts on.
+type jsonRecord struct {
+	value any
+	line  int
+}
+
+func (j *JSONExtractor) Extract(name string, data []byte) (*Document, error) {
+	// Exports from Windows tools are often UTF-16
+	text, enc := decodeDocumentText(name, data)
+
+	var records []jsonRecord
+	var err error
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".jsonl", ".ndjson":
+		records = decodeJSONLines(name, text)
+	default:
+		records, err = decodeJSON(text)
+		if err != nil {
+			// Config files such as tsconfig.json often have comments or
+			// trailing commas, they are still worth indexing as text
+			slog.Warn("indexing invalid JSON as plain text", slog.String("document_name", name), slog.String("error", err.Error()))
+			doc := PlainText(name, data)
+			doc.Warnings = append(doc.Warnings, fmt.Sprintf("indexed as plain text: %v", err))
+			return doc, nil
+		}
+	}
+
+	doc := &Document{}
+	if enc != "" {
+		doc.Metadata = map[string]any{"encoding": enc}
+	} else {
+		doc.Warnings = []string{unknownEncodingWarning}
+	}
+	for i, record := range records {
+		if section, ok := j.section(i+1, record); ok {
+			doc.Sections = append(doc.Sections, section)
+		}
+	}
+	return doc, nil
+}
+
+func newJSONDecoder(data []byte) *json.Decoder {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	// Keep large numeric IDs exact
+	decoder.UseNumber()
+	return decoder
+}
+
+// decodeJSONLines decodes a record from every non-empty line. Lines that
+// aren't valid JSON are skipped so one bad record doesn't lose the export.
+func decodeJSONLines(name string, data []byte) []jsonRecord {
+	var records []jsonRecord
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(nil, len(data)+1)
+	for line := 1; scanner.Scan(); line++ {
+		text := bytes.TrimSpace(scanner.Bytes())
+		if len(text) == 0 {
+			continue
+		}
+		var value any
+		if err := newJSONDecoder(text).Decode(&value); err != nil {
+			slog.Warn("skipping invalid JSON record", slog.String("document_name", name), slog.Int("line", line), slog.String("error", err.Error()))
+			continue
+		}
+		records = append(records, jsonRecord{value: value, line: line})
+	}
+	return records
+}
+
+// decodeJSON returns the elements of a top-level array as records, or the
+// whole value as a single record.
+func decodeJSON(data []byte) ([]jsonRecord, error) {
+	var value any
+	if err := newJSONDecoder(data).Decode(&value); err != nil {
+		return nil, fmt.Errorf("failed to parse JSON: %w", err)
+	}
+	values, ok := value.([]any)
+	if !ok {
+		return []jsonRecord{{value: value}}, nil
+	}
+	records := make([]jsonRecord, len(values))
+	for i, v := range values {
+		records[i] = jsonRecord{value: v}
+	}
+	return records, nil
+}
+
+func (j *JSONExtractor) section(position int, record jsonRecord) (Section, bool) {
+	var parts []string
+	for _, path := range j.TextPaths {
+		for _, value := range lookupPath(record.value, path) {
+			if text := strings.TrimSpace(flattenJSON(value)); text != "" {
+				parts = append(parts, text)
+			}
+		}
+	}
+	if len(parts) == 0 {
+		parts = append(parts, flattenJSON(record.value))
+	}
+	text := strings.TrimSpace(strings.Join(parts, "\n\n"))
+	if text == "" {
+		return Section{}, false
+	}
+
+	var id any = position
+	if values := lookupPath(record.value, j.IDPath); j.IDPath != "" && len(values) > 0 {
+		id = values[0]
+	}
+	metadata := map[string]any{"record_id": id}
+	if record.line > 0 {
+		metadata["line"] = record.line
+	}
+	for _, path := range j.MetadataPaths {
+		if values := lookupPath(record.value, path); len(values) == 1 {
+			metadata[path] = values[0]
+		} else if len(values) > 1 {
+			metadata[path] = values
+		}
+	}
+
+	var title string
+	if values := lookupPath(record.value, j.TitlePath); j.TitlePath != "" && len(values) > 0 {
+		title = strings.Join(strings.Fields(flattenJSON(values[0])), " ")
+	}
+	if title != "" {
+		text = "# " + title + "\n\n" + text
+	}
+
+	return Section{
+		Title:    title,
+		Text:     []byte(text + "\n"),
+		Metadata: metadata,
+	}, true
+}
+
+// lookupPath returns the values at a path in a decoded JSON value.
+func lookupPath(value any, path string) []any {
+	path = strings.TrimPrefix(strings.TrimPrefix(path, "$"), ".")
+	if path == "" {
+		return []any{value}
+	}
+	key, rest, _ := strings.Cut(path, ".")
+
+	switch v := value.(type) {
+	case map[string]any:
+		child, ok := v[key]
+		if !ok || child == nil {
+			return nil
+		}
+		return lookupPath(child, rest)
+	case []any:
+		if key == "*" {
+			var values []any
+			for _, element := range v {
+				values = append(values, lookupPath(element, rest)...)
+			}
+			return values
+		}
+		i, err := strconv.Atoi(key)
+		if err != nil || i < 0 || i >= len(v) {
+			return nil
+		}
+		return lookupPath(v[i], rest)
+	}
+	return nil
+}
+
+// flattenJSON renders a JSON value as text. Scalars are written as is and
+// objects as "key: value" lines, with nested keys joined by dots.
+func flattenJSON(value any) string {
+	var lines []string
+	var walk func(prefix string, value any)
+	walk = func(prefix string, value any) {
+		switch v := value.(type) {
+		case map[string]any:
+			keys := make([]string, 0, len(v))
+			for key := range v {
+				keys = append(keys, key)
+			}
+			slices.Sort(keys)
+			for _, key := range keys {
+				walk(joinKey(prefix, key), v[key])
+			}
+		case []any:
+			if scalars, ok := joinScalars(v); ok {
+				walk(prefix, scalars)
+				return
+			}
+			for i, element := range v {
+				walk(joinKey(prefix, strconv.Itoa(i)), element)
+			}
+		case nil:
+		default:
+			text := scalarText(v)
+			if prefix != "" {
+				text = prefix + ": " + text
+			}
+			lines = append(lines, text)
+		}
+	}
+	walk("", value)
+	return strings.Join(lines, "\n")
+}
+
+func joinKey(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
+
+// joinScalars joins an array of scalars into a comma-separated list.
+func joinScalars(values []any) (string, bool) {
+	texts := make([]string, 0, len(values))
+	for _, value := range values {
+		switch value.(type) {
+		case map[string]any, []any:
+			return "", false
+		case nil:
+			continue
+		}
+		texts = append(texts, scalarText(value))
+	}
+	return strings.Join(texts, ", "), true
+}
+
+func scalarText(value any) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,85 @@
+package extractor
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestJSONExtractor_ExtractJSONLines(t *testing.T) {
+	data, err := os.ReadFile("../test_data/tickets.jsonl")
+	require.NoError(t, err)
+
+	extractor := &JSONExtractor{
+		TextPaths:     []string{"fields.description", "comments.*.body"},
+		TitlePath:     "title",
+		IDPath:        "id",
+		MetadataPaths: []string{"fields.status"},
+	}
+	require.True(t, extractor.Match("tickets.jsonl", data))
+	require.False(t, extractor.Match("tickets.csv", data))
+
+	doc, err := extractor.Extract("tickets.jsonl", data)
+	require.NoError(t, err)
+
+	// The invalid line is skipped
+	require.Equal(t, []Section{
+		{
+			Title: "Login fails after password reset",
+			Text: []byte("# Login fails after password reset\n\n" +
+				"Users see an error page after resetting their password.\n\n" +
+				"Reproduced on staging.\n\n" +
+				"Caused by a stale session cookie.\n"),
+			Metadata: map[string]any{"record_id": json.Number("1042"), "line": 1, "fields.status": "open"},
+		},
+		{
+			Title:    "Export times out",
+			Text:     []byte("# Export times out\n\nCSV export of large projects never finishes.\n"),
+			Metadata: map[string]any{"record_id": json.Number("1043"), "line": 4, "fields.status": "closed"},
+		},
+	}, doc.Sections)
+}
+
+func TestJSONExtractor_ExtractArrayWithoutMapping(t *testing.T) {
+	data := []byte(`[{"name": "alpha", "tags": ["a", "b"], "owner": {"team": "core"}}, {"name": "beta", "count": 3}]`)
+
+	doc, err := (&JSONExtractor{}).Extract("items.json", data)
+	require.NoError(t, err)
+
+	// Records without mapped fields are indexed as key/value lines and
+	// identified by their position
+	require.Equal(t, []Section{
+		{
+			Text:     []byte("name: alpha\nowner.team: core\ntags: a, b\n"),
+			Metadata: map[string]any{"record_id": 1},
+		},
+		{
+			Text:     []byte("count: 3\nname: beta\n"),
+			Metadata: map[string]any{"record_id": 2},
+		},
+	}, doc.Sections)
+}
+
+func TestJSONExtractor_ExtractInvalidJSONAsText(t *testing.T) {
+	// Comments and trailing commas, as in tsconfig.json
+	data := []byte("{\n  // Output directory\n  \"outDir\": \"dist\",\n}\n")
+
+	doc, err := (&JSONExtractor{}).Extract("tsconfig.json", data)
+	require.NoError(t, err)
+	require.Len(t, doc.Sections, 1)
+	require.Equal(t, data, doc.Sections[0].Text)
+	require.Len(t, doc.Warnings, 1)
+	require.Contains(t, doc.Warnings[0], "indexed as plain text")
+}
+
+func TestLookupPath(t *testing.T) {
+	var value any
+	require.NoError(t, json.Unmarshal([]byte(`{"a": {"b": [{"c": 1}, {"c": 2}]}}`), &value))
+
+	require.Equal(t, []any{float64(1), float64(2)}, lookupPath(value, "a.b.*.c"))
+	require.Equal(t, []any{float64(2)}, lookupPath(value, "$.a.b.1.c"))
+	require.Empty(t, lookupPath(value, "a.x"))
+	require.Empty(t, lookupPath(value, "a.b.5"))
+}
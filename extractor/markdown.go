@@ -0,0 +1,254 @@
+package extractor
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"maps"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/MaxIvanyshen/local-rag/chunker"
+	"gopkg.in/yaml.v3"
+)
+
+// MarkdownExtractor extracts Markdown documents. A YAML (---) or TOML (+++)
+// front matter block at the start of the document becomes the document
+// metadata and is left out of the text, with its title as the document title.
+// Wiki-style [[links]], ![[embeds]] and #tags, as written in Obsidian vaults,
+// are recorded in the "links", "embeds" and "tags" metadata.
+type MarkdownExtractor struct{}
+
+func (m *MarkdownExtractor) Match(name string, data []byte) bool {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".md", ".markdown":
+		return true
+	}
+	return false
+}
+
+func (m *MarkdownExtractor) Extract(name string, data []byte) (*Document, error) {
+	doc := PlainText(name, data)
+	text := doc.Sections[0].Text
+
+	if frontMatter, body, ok := splitFrontMatter(text); ok {
+		metadata, err := parseFrontMatter(frontMatter)
+		if err != nil {
+			// A typo in the front matter shouldn't keep the note out of the index
+			slog.Warn("failed to parse front matter", slog.String("document_name", name), slog.String("error", err.Error()))
+		} else {
+			if title, ok := metadata["title"].(string); ok {
+				doc.Title = strings.TrimSpace(title)
+			}
+			if doc.Metadata == nil {
+				doc.Metadata = make(map[string]any, len(metadata))
+			}
+			maps.Copy(doc.Metadata, metadata)
+			// Chunk positions still count the lines of the front matter
+			start := len(text) - len(body)
+			doc.Sections[0].Text = body
+			doc.Sections[0].LineOffset = bytes.Count(text[:start], []byte("\n"))
+			doc.Sections[0].ByteOffset += start
+		}
+	}
+
+	addWikiMetadata(doc, doc.Sections[0].Text)
+	return doc, nil
+}
+
+// ExtractWindow adds the links, embeds and tags of a later window of a large
+// note to the document extracted from its first window. Front matter is only
+// looked for in the first window.
+func (m *MarkdownExtractor) ExtractWindow(doc *Document, window []byte) Section {
+	addWikiMetadata(doc, window)
+	return Section{Text: window, Format: chunker.FormatMarkdown}
+}
+
+// addWikiMetadata adds the links, embeds and tags of a Markdown text to the
+// metadata of its document.
+func addWikiMetadata(doc *Document, text []byte) {
+	links, embeds, tags := parseWikiText(text)
+	for key, values := range map[string][]string{"links": links, "embeds": embeds, "tags": tags} {
+		if len(values) == 0 {
+			continue
+		}
+		if doc.Metadata == nil {
+			doc.Metadata = make(map[string]any)
+		}
+		doc.Metadata[key] = appendMetadataValues(doc.Metadata[key], values)
+	}
+}
+
+var (
+	wikiLinkPattern = regexp.MustCompile(`(!?)\[\[([^\[\]\n]+)\]\]`)
+	// Tags follow whitespace or the start of a line, so headings, URL
+	// fragments and colors in CSS aren't taken for tags
+	tagPattern      = regexp.MustCompile(`(?:^|\s)#([\p{L}\p{N}_/-]+)`)
+	codeSpanPattern = regexp.MustCompile("`[^`\n]*`")
+)
+
+// parseWikiText returns the targets of the [[links]] and ![[embeds]] in a
+// Markdown text, and its #tags, in the order they first appear. Targets leave
+// out the alias and the heading or block they point to, so [[Note#Intro|see
+// intro]] links to "Note". Code blocks and code spans are ignored.
+func parseWikiText(text []byte) (links, embeds, tags []string) {
+	inFence := false
+	var fence string
+	for line := range strings.Lines(string(text)) {
+		trimmed := strings.TrimSpace(line)
+		if inFence {
+			if strings.HasPrefix(trimmed, fence) {
+				inFence = false
+			}
+			continue
+		}
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			inFence, fence = true, trimmed[:3]
+			continue
+		}
+
+		line = codeSpanPattern.ReplaceAllString(line, "")
+		for _, match := range wikiLinkPattern.FindAllStringSubmatch(line, -1) {
+			target, _, _ := strings.Cut(match[2], "|")
+			target, _, _ = strings.Cut(target, "#")
+			target = strings.TrimSpace(target)
+			if target == "" {
+				// A link to a heading of the same note
+				continue
+			}
+			if match[1] == "!" {
+				embeds = appendNew(embeds, target)
+			} else {
+				links = appendNew(links, target)
+			}
+		}
+		for _, match := range tagPattern.FindAllStringSubmatch(wikiLinkPattern.ReplaceAllString(line, ""), -1) {
+			// Tags need a character other than a digit, so "#123" isn't one
+			if strings.Trim(match[1], "0123456789") != "" {
+				tags = appendNew(tags, match[1])
+			}
+		}
+	}
+	return links, embeds, tags
+}
+
+func appendNew(values []string, value string) []string {
+	if slices.Contains(values, value) {
+		return values
+	}
+	return append(values, value)
+}
+
+// appendMetadataValues adds values to a metadata value from the front
+// matter, which may be missing, a single value or a list.
+func appendMetadataValues(existing any, values []string) []any {
+	var merged []any
+	switch v := existing.(type) {
+	case nil:
+	case []any:
+		merged = v
+	default:
+		merged = []any{v}
+	}
+	for _, value := range values {
+		if !slices.Contains(merged, any(value)) {
+			merged = append(merged, value)
+		}
+	}
+	return merged
+}
+
+// frontMatter is a block of metadata at the start of a Markdown document.
+type frontMatter struct {
+	format string
+	data   []byte
+}
+
+// splitFrontMatter splits the front matter off a Markdown document. YAML
+// front matter is delimited by "---" lines, and may also end with "...",
+// TOML front matter by "+++" lines. The body is the rest of text after the
+// blank lines following the front matter.
+func splitFrontMatter(text []byte) (frontMatter, []byte, bool) {
+	firstLine, rest, ok := bytes.Cut(text, []byte("\n"))
+	if !ok {
+		return frontMatter{}, nil, false
+	}
+
+	var format string
+	var closing []string
+	switch string(bytes.TrimRight(firstLine, " \t\r")) {
+	case "---":
+		format, closing = "yaml", []string{"---", "..."}
+	case "+++":
+		format, closing = "toml", []string{"+++"}
+	default:
+		return frontMatter{}, nil, false
+	}
+
+	offset := 0
+	for offset < len(rest) {
+		line, _, _ := bytes.Cut(rest[offset:], []byte("\n"))
+		end := offset + len(line) + 1
+		for _, delimiter := range closing {
+			if string(bytes.TrimRight(line, " \t\r")) == delimiter {
+				body := rest[min(end, len(rest)):]
+				return frontMatter{format: format, data: rest[:offset]}, bytes.TrimLeft(body, "\r\n"), true
+			}
+		}
+		offset = end
+	}
+	// Without a closing line the document starts with a horizontal rule
+	return frontMatter{}, nil, false
+}
+
+func parseFrontMatter(fm frontMatter) (map[string]any, error) {
+	metadata := make(map[string]any)
+	var err error
+	switch fm.format {
+	case "yaml":
+		err = yaml.Unmarshal(fm.data, &metadata)
+	case "toml":
+		err = toml.Unmarshal(fm.data, &metadata)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s front matter: %w", fm.format, err)
+	}
+	for key, value := range metadata {
+		metadata[key] = frontMatterValue(value)
+	}
+	return metadata, nil
+}
+
+// frontMatterValue converts dates and times to strings, so they are stored
+// and filtered on the way they are written. Dates without a time of day are
+// written as "2006-01-02".
+func frontMatterValue(value any) any {
+	switch v := value.(type) {
+	case time.Time:
+		if v.Hour() == 0 && v.Minute() == 0 && v.Second() == 0 && v.Nanosecond() == 0 {
+			return v.Format(time.DateOnly)
+		}
+		return v.Format(time.RFC3339)
+	case map[string]any:
+		for key, element := range v {
+			v[key] = frontMatterValue(element)
+		}
+		return v
+	case []map[string]any:
+		values := make([]any, len(v))
+		for i, element := range v {
+			values[i] = frontMatterValue(element)
+		}
+		return values
+	case []any:
+		for i, element := range v {
+			v[i] = frontMatterValue(element)
+		}
+		return v
+	}
+	return value
+}
@@ -0,0 +1,83 @@
+package extractor
+
+import (
+	"testing"
+
+	"github.com/MaxIvanyshen/local-rag/chunker"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMarkdownExtractor_YAMLFrontMatter(t *testing.T) {
+	data := []byte("---\ntitle: Weekly Review\ntags: [review, planning]\ndate: 2026-03-02\nauthor: Ana\nrating: 4\n---\n\n# Wins\n\nShipped the importer.\n")
+
+	extractor := &MarkdownExtractor{}
+	require.True(t, extractor.Match("notes/review.md", data))
+	require.False(t, extractor.Match("notes/review.txt", data))
+
+	doc, err := extractor.Extract("review.md", data)
+	require.NoError(t, err)
+	require.Equal(t, "Weekly Review", doc.Title)
+	require.Equal(t, map[string]any{
+		"encoding": EncodingUTF8,
+		"title":    "Weekly Review",
+		"tags":     []any{"review", "planning"},
+		"date":     "2026-03-02",
+		"author":   "Ana",
+		"rating":   4,
+	}, doc.Metadata)
+	// The body starts on line 9, after the front matter and a blank line
+	require.Equal(t, []Section{{Text: []byte("# Wins\n\nShipped the importer.\n"), Format: chunker.FormatMarkdown, LineOffset: 8, ByteOffset: 94}}, doc.Sections)
+}
+
+func TestMarkdownExtractor_TOMLFrontMatter(t *testing.T) {
+	data := []byte("+++\ntitle = \"Release Notes\"\ntags = [\"release\"]\ndate = 2026-03-02T10:30:00Z\n\n[params]\nversion = \"1.2\"\n+++\nAll fixes.\n")
+
+	doc, err := (&MarkdownExtractor{}).Extract("release.md", data)
+	require.NoError(t, err)
+	require.Equal(t, "Release Notes", doc.Title)
+	require.Equal(t, map[string]any{
+		"encoding": EncodingUTF8,
+		"title":    "Release Notes",
+		"tags":     []any{"release"},
+		"date":     "2026-03-02T10:30:00Z",
+		"params":   map[string]any{"version": "1.2"},
+	}, doc.Metadata)
+	require.Equal(t, "All fixes.\n", string(doc.Sections[0].Text))
+	require.Equal(t, 8, doc.Sections[0].LineOffset)
+	require.Equal(t, 105, doc.Sections[0].ByteOffset)
+}
+
+func TestMarkdownExtractor_KeepsTextWithoutFrontMatter(t *testing.T) {
+	for name, text := range map[string]string{
+		"no front matter":   "# Title\n\nBody\n",
+		"unclosed rule":     "---\n\nBody after a rule\n",
+		"invalid YAML":      "---\ntags: [unclosed\n---\nBody\n",
+		"rule not at start": "Intro\n---\ntitle: x\n---\n",
+	} {
+		t.Run(name, func(t *testing.T) {
+			doc, err := (&MarkdownExtractor{}).Extract("note.md", []byte(text))
+			require.NoError(t, err)
+			require.Equal(t, text, string(doc.Sections[0].Text))
+			require.Equal(t, map[string]any{"encoding": EncodingUTF8}, doc.Metadata)
+		})
+	}
+}
+
+func TestMarkdownExtractor_WikiLinksAndTags(t *testing.T) {
+	data := []byte("---\ntags: [project]\n---\n" +
+		"# Alpha #draft\n\n" +
+		"Owned by [[People/Ana|Ana]], see [[Roadmap#Q3]] and [[Roadmap]]. #project #infra/db #42\n\n" +
+		"![[diagram.png]] and a [[#Local heading]] link.\n\n" +
+		"Inline `[[not a link]] #notatag` code.\n\n" +
+		"```\n[[Fenced]] #fenced\n```\n" +
+		"See https://example.com/#fragment.\n")
+
+	doc, err := (&MarkdownExtractor{}).Extract("vault/Alpha.md", data)
+	require.NoError(t, err)
+	require.Equal(t, []any{"People/Ana", "Roadmap"}, doc.Metadata["links"])
+	require.Equal(t, []any{"diagram.png"}, doc.Metadata["embeds"])
+	// Inline tags are added to the front matter tags
+	require.Equal(t, []any{"project", "draft", "infra/db"}, doc.Metadata["tags"])
+	// The text keeps the links as written
+	require.Contains(t, string(doc.Sections[0].Text), "[[People/Ana|Ana]]")
+}
@@ -0,0 +1,157 @@
+package extractor
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/MaxIvanyshen/local-rag/chunker"
+)
+
+// NotebookExtractor extracts the cells of Jupyter notebooks, one section per
+// cell. Code cells are fenced with the kernel language and, when
+// IncludeOutputs is set, followed by their text outputs. Images and other
+// binary outputs are always dropped.
+type NotebookExtractor struct {
+	IncludeOutputs bool
+}
+
+func NewNotebookExtractor(includeOutputs bool) *NotebookExtractor {
+	return &NotebookExtractor{
+		IncludeOutputs: includeOutputs,
+	}
+}
+
+func (n *NotebookExtractor) Match(name string, data []byte) bool {
+	return strings.EqualFold(filepath.Ext(name), ".ipynb")
+}
+
+// notebookText is a multiline string that notebooks store either as a single
+// string or as a list of lines.
+type notebookText string
+
+func (t *notebookText) UnmarshalJSON(data []byte) error {
+	var lines []string
+	if err := json.Unmarshal(data, &lines); err == nil {
+		*t = notebookText(strings.Join(lines, ""))
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	*t = notebookText(s)
+	return nil
+}
+
+type notebook struct {
+	Metadata struct {
+		KernelSpec struct {
+			Language string `json:"language"`
+		} `json:"kernelspec"`
+		LanguageInfo struct {
+			Name string `json:"name"`
+		} `json:"language_info"`
+	} `json:"metadata"`
+	Cells []struct {
+		CellType string       `json:"cell_type"`
+		Source   notebookText `json:"source"`
+		Outputs  []cellOutput `json:"outputs"`
+	} `json:"cells"`
+}
+
+type cellOutput struct {
+	OutputType string `json:"output_type"`
+	// Text is the output of stream outputs
+	Text notebookText `json:"text"`
+	// Data maps MIME types to the output of display and execute results
+	Data struct {
+		Plain notebookText `json:"text/plain"`
+	} `json:"data"`
+	EName  string `json:"ename"`
+	EValue string `json:"evalue"`
+}
+
+func (o cellOutput) text() string {
+	switch o.OutputType {
+	case "stream":
+		return string(o.Text)
+	case "execute_result", "display_data":
+		return string(o.Data.Plain)
+	case "error":
+		return o.EName + ": " + o.EValue
+	}
+	return ""
+}
+
+// dataURIImage matches Markdown images embedded as base64 data URIs.
+var dataURIImage = regexp.MustCompile(`!\[([^\]]*)\]\(data:[^)]*\)`)
+
+func (n *NotebookExtractor) Extract(name string, data []byte) (*Document, error) {
+	var nb notebook
+	if err := json.Unmarshal(data, &nb); err != nil {
+		return nil, fmt.Errorf("failed to parse notebook: %w", err)
+	}
+
+	language := nb.Metadata.KernelSpec.Language
+	if language == "" {
+		language = nb.Metadata.LanguageInfo.Name
+	}
+
+	doc := &Document{}
+	if language != "" {
+		doc.Metadata = map[string]any{"language": language}
+	}
+	for i, cell := range nb.Cells {
+		source := strings.TrimSpace(string(cell.Source))
+
+		var text string
+		switch cell.CellType {
+		case "markdown":
+			text = strings.TrimSpace(dataURIImage.ReplaceAllString(source, "$1"))
+		case "code":
+			if source == "" {
+				continue
+			}
+			text = "```" + language + "\n" + source + "\n```"
+			if n.IncludeOutputs {
+				text += outputsText(cell.Outputs)
+			}
+		default:
+			// Raw cells hold content for other formats
+			continue
+		}
+		if text == "" {
+			continue
+		}
+
+		section := Section{
+			Text: []byte(text + "\n"),
+			Metadata: map[string]any{
+				"cell":      i + 1,
+				"cell_type": cell.CellType,
+			},
+		}
+		if cell.CellType == "markdown" {
+			section.Format = chunker.FormatMarkdown
+		}
+		doc.Sections = append(doc.Sections, section)
+	}
+	return doc, nil
+}
+
+// outputsText returns the text outputs of a code cell as a fenced block.
+func outputsText(outputs []cellOutput) string {
+	var parts []string
+	for _, output := range outputs {
+		if text := strings.TrimRight(output.text(), "\n"); strings.TrimSpace(text) != "" {
+			parts = append(parts, text)
+		}
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return "\n\nOutput:\n\n```\n" + strings.Join(parts, "\n") + "\n```"
+}
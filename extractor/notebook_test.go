@@ -0,0 +1,54 @@
+package extractor
+
+import (
+	"os"
+	"testing"
+
+	"github.com/MaxIvanyshen/local-rag/chunker"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotebookExtractor_Extract(t *testing.T) {
+	data, err := os.ReadFile("../test_data/sample.ipynb")
+	require.NoError(t, err)
+
+	extractor := NewNotebookExtractor(false)
+	require.True(t, extractor.Match("analysis.ipynb", data))
+	require.False(t, extractor.Match("analysis.json", data))
+
+	doc, err := extractor.Extract("sample.ipynb", data)
+	require.NoError(t, err)
+	require.Equal(t, map[string]any{"language": "python"}, doc.Metadata)
+
+	// The raw cell is skipped, cells keep their position in the notebook
+	require.Equal(t, []Section{
+		{
+			Text:     []byte("# Churn Analysis\n\nWe look at monthly churn by plan.\nchart\n"),
+			Metadata: map[string]any{"cell": 1, "cell_type": "markdown"},
+			Format:   chunker.FormatMarkdown,
+		},
+		{
+			Text:     []byte("```python\ndf = load_churn()\nprint(f\"rows: {len(df)}\")\n```\n"),
+			Metadata: map[string]any{"cell": 2, "cell_type": "code"},
+		},
+		{
+			Text:     []byte("```python\ndf.groupby(\"plan\").churn.mean()\n```\n"),
+			Metadata: map[string]any{"cell": 4, "cell_type": "code"},
+		},
+	}, doc.Sections)
+}
+
+func TestNotebookExtractor_IncludeOutputs(t *testing.T) {
+	data, err := os.ReadFile("../test_data/sample.ipynb")
+	require.NoError(t, err)
+
+	doc, err := NewNotebookExtractor(true).Extract("sample.ipynb", data)
+	require.NoError(t, err)
+	require.Len(t, doc.Sections, 3)
+
+	// Text outputs are kept, the base64 image is not
+	require.Equal(t, "```python\ndf = load_churn()\nprint(f\"rows: {len(df)}\")\n```\n\n"+
+		"Output:\n\n```\nrows: 1200\n<Figure size 640x480 with 1 Axes>\n```\n", string(doc.Sections[1].Text))
+	require.Equal(t, "```python\ndf.groupby(\"plan\").churn.mean()\n```\n\n"+
+		"Output:\n\n```\nKeyError: 'plan'\n```\n", string(doc.Sections[2].Text))
+}
@@ -0,0 +1,152 @@
+package extractor
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// ODTExtractor extracts the paragraphs, headings, lists and tables of
+// OpenDocument text documents.
+type ODTExtractor struct{}
+
+func (o *ODTExtractor) Match(name string, data []byte) bool {
+	return strings.EqualFold(filepath.Ext(name), ".odt") || hasMediaType(data, "application/vnd.oasis.opendocument.text")
+}
+
+func (o *ODTExtractor) Extract(name string, data []byte) (*Document, error) {
+	zr, err := openPackage(data)
+	if err != nil {
+		return nil, err
+	}
+	content, err := readPart(zr, "content.xml")
+	if err != nil {
+		return nil, err
+	}
+	text, err := convertODT(content)
+	if err != nil {
+		return nil, err
+	}
+
+	title, metadata := readProperties(zr, "meta.xml")
+	return &Document{
+		Title:    title,
+		Metadata: metadata,
+		Sections: sectionsOf(text),
+	}, nil
+}
+
+func convertODT(data []byte) ([]byte, error) {
+	w := &officeWriter{}
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+
+	var (
+		para       strings.Builder
+		level      int
+		inPara     bool
+		listDepth  int
+		tableDepth int
+		cells      []string
+		cell       strings.Builder
+	)
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse document: %w", err)
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "note", "annotation", "tracked-changes":
+				// Footnotes and comments hold paragraphs nested in the
+				// surrounding one, and tracked changes hold deleted text
+				if err := decoder.Skip(); err != nil {
+					return nil, fmt.Errorf("failed to parse document: %w", err)
+				}
+			case "h":
+				para.Reset()
+				inPara = true
+				level = 1
+				if n, err := strconv.Atoi(attrValue(t, "outline-level")); err == nil && n > 0 {
+					level = n
+				}
+			case "p":
+				para.Reset()
+				inPara = true
+				level = 0
+			case "s":
+				if inPara {
+					count := 1
+					if n, err := strconv.Atoi(attrValue(t, "c")); err == nil && n > 0 {
+						count = n
+					}
+					para.WriteString(strings.Repeat(" ", count))
+				}
+			case "tab":
+				if inPara {
+					para.WriteByte('\t')
+				}
+			case "line-break":
+				if inPara {
+					para.WriteByte('\n')
+				}
+			case "list-item":
+				listDepth++
+			case "table":
+				tableDepth++
+			case "table-row":
+				if tableDepth == 1 {
+					cells = nil
+				}
+			case "table-cell":
+				if tableDepth == 1 {
+					cell.Reset()
+				}
+			}
+		case xml.CharData:
+			if inPara {
+				para.Write(t)
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "h", "p":
+				inPara = false
+				switch {
+				case tableDepth > 0:
+					if cell.Len() > 0 {
+						cell.WriteByte(' ')
+					}
+					cell.WriteString(para.String())
+				case listDepth > 0 && level == 0:
+					w.listItem(para.String())
+				default:
+					w.paragraph(para.String(), level)
+				}
+			case "list-item":
+				listDepth--
+			case "table-cell":
+				if tableDepth == 1 {
+					cells = append(cells, cell.String())
+				}
+			case "table-row":
+				if tableDepth == 1 {
+					w.tableRow(cells)
+				}
+			case "table":
+				tableDepth--
+				if tableDepth == 0 {
+					w.endBlock()
+				}
+			}
+		}
+	}
+	return w.bytes(), nil
+}
@@ -0,0 +1,247 @@
+package extractor
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/MaxIvanyshen/local-rag/chunker"
+)
+
+// Office documents (OOXML and ODF) are zip packages of XML parts. The helpers
+// in this file are shared by the DOCX, ODT, PPTX, XLSX and EPUB extractors.
+
+const dublinCoreNamespace = "http://purl.org/dc/elements/1.1/"
+
+var zipMagic = []byte("PK\x03\x04")
+
+// matchPackage reports whether a document is an office package: it has one
+// of the extensions, or it is a zip archive containing the marker part.
+func matchPackage(name string, data []byte, marker string, extensions ...string) bool {
+	ext := strings.ToLower(filepath.Ext(name))
+	for _, e := range extensions {
+		if ext == e {
+			return true
+		}
+	}
+	if !bytes.HasPrefix(data, zipMagic) {
+		return false
+	}
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return false
+	}
+	for _, f := range zr.File {
+		if f.Name == marker {
+			return true
+		}
+	}
+	return false
+}
+
+// hasMediaType reports whether data is an ODF or EPUB package of the media
+// type. Those formats store it uncompressed in a "mimetype" file at the start
+// of the archive, so it can be sniffed without opening the archive.
+func hasMediaType(data []byte, mediaType string) bool {
+	return bytes.HasPrefix(data, zipMagic) && bytes.Contains(data[:min(len(data), 128)], []byte("mimetype"+mediaType))
+}
+
+func openPackage(data []byte) (*zip.Reader, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open package: %w", err)
+	}
+	return zr, nil
+}
+
+// readPart returns the contents of a file in the package.
+func readPart(zr *zip.Reader, name string) ([]byte, error) {
+	f, err := zr.Open(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", name, err)
+	}
+	defer f.Close()
+
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", name, err)
+	}
+	return data, nil
+}
+
+// relationship links an OOXML part to another part of the package.
+type relationship struct {
+	Type string
+	// Target is the path of the target part within the package.
+	Target string
+}
+
+// readRelationships returns the relationships of an OOXML part by ID.
+func readRelationships(zr *zip.Reader, part string) (map[string]relationship, error) {
+	dir, file := path.Split(part)
+	data, err := readPart(zr, path.Join(dir, "_rels", file+".rels"))
+	if err != nil {
+		return nil, err
+	}
+
+	var rels struct {
+		Relationships []struct {
+			ID     string `xml:"Id,attr"`
+			Type   string `xml:"Type,attr"`
+			Target string `xml:"Target,attr"`
+		} `xml:"Relationship"`
+	}
+	if err := xml.Unmarshal(data, &rels); err != nil {
+		return nil, fmt.Errorf("failed to parse relationships of %s: %w", part, err)
+	}
+
+	relationships := make(map[string]relationship, len(rels.Relationships))
+	for _, rel := range rels.Relationships {
+		target := path.Join(dir, rel.Target)
+		if strings.HasPrefix(rel.Target, "/") {
+			target = strings.TrimPrefix(rel.Target, "/")
+		}
+		relationships[rel.ID] = relationship{Type: rel.Type, Target: target}
+	}
+	return relationships, nil
+}
+
+// readProperties reads the Dublin Core title and creator from a metadata
+// part, docProps/core.xml in OOXML or meta.xml in ODF. A missing part is not
+// an error, most properties are optional.
+func readProperties(zr *zip.Reader, part string) (string, map[string]any) {
+	data, err := readPart(zr, part)
+	if err != nil {
+		return "", nil
+	}
+
+	var title string
+	var metadata map[string]any
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			break
+		}
+		start, ok := token.(xml.StartElement)
+		if !ok || start.Name.Space != dublinCoreNamespace {
+			continue
+		}
+		var value string
+		if err := decoder.DecodeElement(&value, &start); err != nil {
+			break
+		}
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+		switch start.Name.Local {
+		case "title":
+			title = value
+		case "creator":
+			metadata = map[string]any{"author": value}
+		}
+	}
+	return title, metadata
+}
+
+func attrValue(start xml.StartElement, local string) string {
+	for _, a := range start.Attr {
+		if a.Name.Local == local {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+type blockKind int
+
+const (
+	paragraphBlock blockKind = iota
+	listBlock
+	tableBlock
+)
+
+// officeWriter lays out the paragraphs, list items and table rows of an
+// office document as Markdown-like text.
+type officeWriter struct {
+	buf       bytes.Buffer
+	last      blockKind
+	tableRows int
+}
+
+func (w *officeWriter) write(kind blockKind, text string) {
+	if w.buf.Len() > 0 {
+		// List items and table rows are kept on consecutive lines
+		if kind == w.last && kind != paragraphBlock {
+			w.buf.WriteByte('\n')
+		} else {
+			w.buf.WriteString("\n\n")
+		}
+	}
+	w.buf.WriteString(text)
+	w.last = kind
+}
+
+// paragraph writes a paragraph, as a heading when level is above 0.
+func (w *officeWriter) paragraph(text string, level int) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return
+	}
+	if level > 0 {
+		text = strings.Repeat("#", min(level, 6)) + " " + strings.Join(strings.Fields(text), " ")
+	}
+	w.write(paragraphBlock, text)
+}
+
+func (w *officeWriter) listItem(text string) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return
+	}
+	w.write(listBlock, "- "+text)
+}
+
+// tableRow writes a row as a Markdown table row, the first row of a table
+// being its header.
+func (w *officeWriter) tableRow(cells []string) {
+	if len(cells) == 0 {
+		return
+	}
+	for i, cell := range cells {
+		cells[i] = strings.ReplaceAll(strings.Join(strings.Fields(cell), " "), "|", `\|`)
+	}
+	row := "| " + strings.Join(cells, " | ") + " |"
+	if w.tableRows == 0 {
+		row += "\n" + strings.Repeat("| --- ", len(cells)) + "|"
+	}
+	w.write(tableBlock, row)
+	w.tableRows++
+}
+
+// endBlock ends the current table or list, so that what follows starts a new block.
+func (w *officeWriter) endBlock() {
+	w.tableRows = 0
+	w.last = paragraphBlock
+}
+
+func (w *officeWriter) bytes() []byte {
+	if w.buf.Len() == 0 {
+		return nil
+	}
+	return append(w.buf.Bytes(), '\n')
+}
+
+// sectionsOf returns a single section for text, or none when it is empty.
+func sectionsOf(text []byte) []Section {
+	if len(text) == 0 {
+		return nil
+	}
+	return []Section{{Text: text, Format: chunker.FormatMarkdown}}
+}
@@ -0,0 +1,117 @@
+package extractor
+
+import (
+	"os"
+	"testing"
+
+	"github.com/MaxIvanyshen/local-rag/chunker"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDOCXExtractor_Extract(t *testing.T) {
+	data, err := os.ReadFile("../test_data/sample.docx")
+	require.NoError(t, err)
+
+	extractor := &DOCXExtractor{}
+	require.True(t, extractor.Match("upload.bin", data))
+	require.True(t, extractor.Match("spec.DOCX", nil))
+	require.False(t, extractor.Match("spec.odt", nil))
+
+	doc, err := extractor.Extract("sample.docx", data)
+	require.NoError(t, err)
+	require.Equal(t, "Widget Product Spec", doc.Title)
+	require.Equal(t, map[string]any{"author": "Jane Doe"}, doc.Metadata)
+	require.Len(t, doc.Sections, 1)
+
+	expected := "# Widget Spec\n\n" +
+		"# Overview\n\n" +
+		"The widget must start in under a second.\n\n" +
+		"- Fast startup\n" +
+		"- Low memory\n\n" +
+		"| Limit | Value |\n" +
+		"| --- | --- |\n" +
+		"| Startup | 1s |\n\n" +
+		"Deleted\n"
+	require.Equal(t, expected, string(doc.Sections[0].Text))
+}
+
+func TestODTExtractor_Extract(t *testing.T) {
+	data, err := os.ReadFile("../test_data/sample.odt")
+	require.NoError(t, err)
+
+	extractor := &ODTExtractor{}
+	require.True(t, extractor.Match("upload.bin", data))
+	require.False(t, extractor.Match("upload.bin", []byte("PK\x03\x04")))
+
+	doc, err := extractor.Extract("sample.odt", data)
+	require.NoError(t, err)
+	require.Equal(t, "Release Notes 2", doc.Title)
+	require.Len(t, doc.Sections, 1)
+
+	expected := "# Release Notes\n\n" +
+		"Version  two adds sync.\n\n" +
+		"- Faster sync\n" +
+		"- New icons\n\n" +
+		"| Module | Owner |\n" +
+		"| --- | --- |\n" +
+		"| sync | Ana |\n"
+	require.Equal(t, expected, string(doc.Sections[0].Text))
+}
+
+func TestPPTXExtractor_Extract(t *testing.T) {
+	data, err := os.ReadFile("../test_data/sample.pptx")
+	require.NoError(t, err)
+
+	extractor := &PPTXExtractor{}
+	require.True(t, extractor.Match("upload.bin", data))
+	require.False(t, (&DOCXExtractor{}).Match("upload.bin", data))
+
+	doc, err := extractor.Extract("sample.pptx", data)
+	require.NoError(t, err)
+	require.Equal(t, "Quarterly Review", doc.Title)
+	require.Equal(t, []Section{
+		{
+			Text:     []byte("# Quarterly Review\n\n- Sales team\n"),
+			Metadata: map[string]any{"slide": 1},
+		},
+		{
+			Text:     []byte("# Pricing\n\n- Plans start at $10\n- Annual discount\n\nFree text box\n\nNotes:\n\nMention the pricing change.\n"),
+			Metadata: map[string]any{"slide": 2},
+		},
+	}, doc.Sections)
+}
+
+func TestXLSXExtractor_Extract(t *testing.T) {
+	data, err := os.ReadFile("../test_data/sample.xlsx")
+	require.NoError(t, err)
+
+	extractor := &XLSXExtractor{}
+	require.True(t, extractor.Match("upload.bin", data))
+
+	doc, err := extractor.Extract("sample.xlsx", data)
+	require.NoError(t, err)
+	require.Equal(t, "Budget 2026", doc.Title)
+	// Empty sheets are skipped
+	require.Equal(t, []Section{
+		{
+			Text:     []byte("Item,Cost,\n\"Server, rack\",,1200\n"),
+			Metadata: map[string]any{"sheet": "Budget"},
+			Format:   chunker.FormatCSV,
+		},
+		{
+			Text:     []byte("Name,Active\nAna,TRUE\n"),
+			Metadata: map[string]any{"sheet": "Team"},
+			Format:   chunker.FormatCSV,
+		},
+	}, doc.Sections)
+}
+
+func TestColumnIndex(t *testing.T) {
+	for ref, expected := range map[string]int{"A1": 0, "C7": 2, "Z3": 25, "AA10": 26, "AB2": 27} {
+		col, ok := columnIndex(ref)
+		require.True(t, ok)
+		require.Equal(t, expected, col, ref)
+	}
+	_, ok := columnIndex("12")
+	require.False(t, ok)
+}
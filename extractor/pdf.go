@@ -0,0 +1,61 @@
+package extractor
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/ledongthuc/pdf"
+)
+
+// PDFExtractor extracts the text of every page of a PDF file.
+type PDFExtractor struct{}
+
+func (p *PDFExtractor) Match(name string, data []byte) bool {
+	return bytes.HasPrefix(data, []byte("%PDF-")) || strings.EqualFold(filepath.Ext(name), ".pdf")
+}
+
+func (p *PDFExtractor) Extract(name string, data []byte) (doc *Document, err error) {
+	// The pdf package panics on some malformed files instead of returning an error
+	defer func() {
+		if v := recover(); v != nil {
+			doc, err = nil, fmt.Errorf("failed to read PDF: %v", v)
+		}
+	}()
+
+	r, err := pdf.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open PDF: %w", err)
+	}
+
+	doc = &Document{
+		Title: strings.TrimSpace(r.Trailer().Key("Info").Key("Title").Text()),
+	}
+	fonts := make(map[string]*pdf.Font)
+	for i := 1; i <= r.NumPage(); i++ {
+		page := r.Page(i)
+		if page.V.IsNull() {
+			continue
+		}
+		for _, name := range page.Fonts() {
+			if _, ok := fonts[name]; !ok {
+				font := page.Font(name)
+				fonts[name] = &font
+			}
+		}
+		text, err := page.GetPlainText(fonts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to extract text of page %d: %w", i, err)
+		}
+		text = strings.TrimSpace(text)
+		if text == "" {
+			continue
+		}
+		doc.Sections = append(doc.Sections, Section{
+			Text: []byte(text + "\n"),
+			Page: i,
+		})
+	}
+	return doc, nil
+}
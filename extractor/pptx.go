@@ -0,0 +1,210 @@
+package extractor
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// PPTXExtractor extracts the text and speaker notes of PowerPoint
+// presentations, one section per slide.
+type PPTXExtractor struct{}
+
+func (p *PPTXExtractor) Match(name string, data []byte) bool {
+	return matchPackage(name, data, "ppt/presentation.xml", ".pptx")
+}
+
+func (p *PPTXExtractor) Extract(name string, data []byte) (*Document, error) {
+	zr, err := openPackage(data)
+	if err != nil {
+		return nil, err
+	}
+	slides, err := slideParts(zr)
+	if err != nil {
+		return nil, err
+	}
+
+	title, metadata := readProperties(zr, "docProps/core.xml")
+	doc := &Document{
+		Title:    title,
+		Metadata: metadata,
+	}
+	for i, slide := range slides {
+		text, err := convertSlide(zr, slide)
+		if err != nil {
+			return nil, err
+		}
+		if len(text) == 0 {
+			continue
+		}
+		doc.Sections = append(doc.Sections, Section{
+			Text:     text,
+			Metadata: map[string]any{"slide": i + 1},
+		})
+	}
+	return doc, nil
+}
+
+// slideParts returns the slide parts of a presentation in slide order.
+func slideParts(zr *zip.Reader) ([]string, error) {
+	data, err := readPart(zr, "ppt/presentation.xml")
+	if err != nil {
+		return nil, err
+	}
+	var presentation struct {
+		Slides []struct {
+			RelationshipID string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+		} `xml:"sldIdLst>sldId"`
+	}
+	if err := xml.Unmarshal(data, &presentation); err != nil {
+		return nil, fmt.Errorf("failed to parse presentation: %w", err)
+	}
+
+	rels, err := readRelationships(zr, "ppt/presentation.xml")
+	if err != nil {
+		return nil, err
+	}
+	var slides []string
+	for _, slide := range presentation.Slides {
+		if rel, ok := rels[slide.RelationshipID]; ok {
+			slides = append(slides, rel.Target)
+		}
+	}
+	return slides, nil
+}
+
+// convertSlide returns the text of a slide followed by its speaker notes.
+func convertSlide(zr *zip.Reader, slide string) ([]byte, error) {
+	data, err := readPart(zr, slide)
+	if err != nil {
+		return nil, err
+	}
+	text, err := convertSlideXML(data, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", slide, err)
+	}
+
+	// Slides without notes have no relationships part or no notes relationship
+	rels, _ := readRelationships(zr, slide)
+	for _, rel := range rels {
+		if !strings.HasSuffix(rel.Type, "/notesSlide") {
+			continue
+		}
+		data, err := readPart(zr, rel.Target)
+		if err != nil {
+			return nil, err
+		}
+		notes, err := convertSlideXML(data, true)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", rel.Target, err)
+		}
+		if len(notes) > 0 {
+			text = append(text, "\nNotes:\n\n"...)
+			text = append(text, notes...)
+		}
+	}
+	return text, nil
+}
+
+// convertSlideXML converts the shapes of a slide. Slide titles become
+// headings and other placeholder paragraphs list items. Notes slides only
+// keep the notes body, not the slide image, number or footers.
+func convertSlideXML(data []byte, notes bool) ([]byte, error) {
+	w := &officeWriter{}
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+
+	var (
+		para        strings.Builder
+		placeholder string
+		inText      bool
+		tableDepth  int
+		cells       []string
+		cell        strings.Builder
+	)
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "fld":
+				// Fields hold generated text such as slide numbers and dates
+				if err := decoder.Skip(); err != nil {
+					return nil, err
+				}
+			case "sp":
+				placeholder = ""
+			case "ph":
+				placeholder = attrValue(t, "type")
+				if placeholder == "" {
+					placeholder = "obj"
+				}
+			case "p":
+				para.Reset()
+			case "t":
+				inText = true
+			case "br":
+				para.WriteByte('\n')
+			case "tbl":
+				tableDepth++
+			case "tr":
+				if tableDepth == 1 {
+					cells = nil
+				}
+			case "tc":
+				if tableDepth == 1 {
+					cell.Reset()
+				}
+			}
+		case xml.CharData:
+			if inText {
+				para.Write(t)
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "t":
+				inText = false
+			case "sp":
+				w.endBlock()
+			case "p":
+				switch {
+				case notes && placeholder != "body":
+				case tableDepth > 0:
+					if cell.Len() > 0 {
+						cell.WriteByte(' ')
+					}
+					cell.WriteString(para.String())
+				case placeholder == "title" || placeholder == "ctrTitle":
+					w.paragraph(para.String(), 1)
+				case placeholder != "" && !notes:
+					w.listItem(para.String())
+				default:
+					w.paragraph(para.String(), 0)
+				}
+			case "tc":
+				if tableDepth == 1 {
+					cells = append(cells, cell.String())
+				}
+			case "tr":
+				if tableDepth == 1 {
+					w.tableRow(cells)
+				}
+			case "tbl":
+				tableDepth--
+				if tableDepth == 0 {
+					w.endBlock()
+				}
+			}
+		}
+	}
+	return w.bytes(), nil
+}
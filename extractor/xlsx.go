@@ -0,0 +1,211 @@
+package extractor
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/MaxIvanyshen/local-rag/chunker"
+)
+
+// XLSXExtractor extracts the cell values of Excel workbooks as CSV, one
+// section per sheet.
+type XLSXExtractor struct{}
+
+func (x *XLSXExtractor) Match(name string, data []byte) bool {
+	return matchPackage(name, data, "xl/workbook.xml", ".xlsx")
+}
+
+func (x *XLSXExtractor) Extract(name string, data []byte) (*Document, error) {
+	zr, err := openPackage(data)
+	if err != nil {
+		return nil, err
+	}
+	sheets, err := workbookSheets(zr)
+	if err != nil {
+		return nil, err
+	}
+	shared, err := sharedStrings(zr)
+	if err != nil {
+		return nil, err
+	}
+
+	title, metadata := readProperties(zr, "docProps/core.xml")
+	doc := &Document{
+		Title:    title,
+		Metadata: metadata,
+	}
+	for _, sheet := range sheets {
+		data, err := readPart(zr, sheet.part)
+		if err != nil {
+			return nil, err
+		}
+		text, err := convertSheet(data, shared)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse sheet %s: %w", sheet.name, err)
+		}
+		if len(text) == 0 {
+			continue
+		}
+		doc.Sections = append(doc.Sections, Section{
+			Text:     text,
+			Metadata: map[string]any{"sheet": sheet.name},
+			Format:   chunker.FormatCSV,
+		})
+	}
+	return doc, nil
+}
+
+type workbookSheet struct {
+	name string
+	part string
+}
+
+// workbookSheets returns the sheets of a workbook in tab order.
+func workbookSheets(zr *zip.Reader) ([]workbookSheet, error) {
+	data, err := readPart(zr, "xl/workbook.xml")
+	if err != nil {
+		return nil, err
+	}
+	var workbook struct {
+		Sheets []struct {
+			Name           string `xml:"name,attr"`
+			RelationshipID string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+		} `xml:"sheets>sheet"`
+	}
+	if err := xml.Unmarshal(data, &workbook); err != nil {
+		return nil, fmt.Errorf("failed to parse workbook: %w", err)
+	}
+
+	rels, err := readRelationships(zr, "xl/workbook.xml")
+	if err != nil {
+		return nil, err
+	}
+	var sheets []workbookSheet
+	for _, sheet := range workbook.Sheets {
+		if rel, ok := rels[sheet.RelationshipID]; ok {
+			sheets = append(sheets, workbookSheet{name: sheet.Name, part: rel.Target})
+		}
+	}
+	return sheets, nil
+}
+
+// richText is a string made of plain text and formatted runs.
+type richText struct {
+	Text string `xml:"t"`
+	Runs []struct {
+		Text string `xml:"t"`
+	} `xml:"r"`
+}
+
+func (r richText) String() string {
+	var sb strings.Builder
+	sb.WriteString(r.Text)
+	for _, run := range r.Runs {
+		sb.WriteString(run.Text)
+	}
+	return sb.String()
+}
+
+// sharedStrings returns the shared string table cells refer to by index.
+// Workbooks without string cells have none.
+func sharedStrings(zr *zip.Reader) ([]string, error) {
+	data, err := readPart(zr, "xl/sharedStrings.xml")
+	if err != nil {
+		return nil, nil
+	}
+	var table struct {
+		Items []richText `xml:"si"`
+	}
+	if err := xml.Unmarshal(data, &table); err != nil {
+		return nil, fmt.Errorf("failed to parse shared strings: %w", err)
+	}
+	shared := make([]string, len(table.Items))
+	for i, item := range table.Items {
+		shared[i] = item.String()
+	}
+	return shared, nil
+}
+
+// columnIndex returns the 0-based column of a cell reference such as "C7".
+func columnIndex(ref string) (int, bool) {
+	col := 0
+	for i, r := range ref {
+		if r < 'A' || r > 'Z' {
+			return col - 1, i > 0
+		}
+		col = col*26 + int(r-'A'+1)
+	}
+	return col - 1, col > 0
+}
+
+// convertSheet writes the non-empty rows of a sheet as CSV, padded to the
+// same number of columns.
+func convertSheet(data []byte, shared []string) ([]byte, error) {
+	var worksheet struct {
+		Rows []struct {
+			Cells []struct {
+				Ref    string   `xml:"r,attr"`
+				Type   string   `xml:"t,attr"`
+				Value  string   `xml:"v"`
+				Inline richText `xml:"is"`
+			} `xml:"c"`
+		} `xml:"sheetData>row"`
+	}
+	if err := xml.Unmarshal(data, &worksheet); err != nil {
+		return nil, err
+	}
+
+	var rows [][]string
+	width := 0
+	for _, row := range worksheet.Rows {
+		var values []string
+		for _, c := range row.Cells {
+			value := c.Value
+			switch c.Type {
+			case "s":
+				if i, err := strconv.Atoi(value); err == nil && i >= 0 && i < len(shared) {
+					value = shared[i]
+				}
+			case "inlineStr":
+				value = c.Inline.String()
+			case "b":
+				value = strings.ToUpper(strconv.FormatBool(value == "1"))
+			}
+
+			col, ok := columnIndex(c.Ref)
+			if !ok {
+				col = len(values)
+			}
+			for len(values) <= col {
+				values = append(values, "")
+			}
+			values[col] = value
+		}
+		if strings.TrimSpace(strings.Join(values, "")) == "" {
+			continue
+		}
+		rows = append(rows, values)
+		width = max(width, len(values))
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+
+	var buf bytes.Buffer
+	cw := csv.NewWriter(&buf)
+	for _, row := range rows {
+		for len(row) < width {
+			row = append(row, "")
+		}
+		if err := cw.Write(row); err != nil {
+			return nil, err
+		}
+	}
+	cw.Flush()
+	return buf.Bytes(), cw.Error()
+}
@@ -0,0 +1,110 @@
+// Package gitrepo reads the files of a local git repository at a commit by
+// running the git command.
+package gitrepo
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// Repository is a local git repository.
+type Repository struct {
+	// Path is the absolute path of the working tree root.
+	Path string
+}
+
+// Open returns the repository that path is in.
+func Open(ctx context.Context, path string) (*Repository, error) {
+	out, err := run(ctx, path, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil, fmt.Errorf("not a git repository: %w", err)
+	}
+	return &Repository{Path: strings.TrimSpace(string(out))}, nil
+}
+
+// ResolveRef returns the SHA of the commit a ref such as HEAD, a branch or a
+// tag points to.
+func (r *Repository) ResolveRef(ctx context.Context, ref string) (string, error) {
+	out, err := run(ctx, r.Path, "rev-parse", "--verify", "--quiet", ref+"^{commit}")
+	if err != nil {
+		return "", fmt.Errorf("unknown ref %s: %w", ref, err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// File is a regular file committed to the repository.
+type File struct {
+	// Path is relative to the repository root, with forward slashes.
+	Path string
+	// Blob is the SHA of the file content.
+	Blob string
+	Size int64
+}
+
+// Files lists the regular files of a commit. Only committed files are listed,
+// so files matched by .gitignore never are. Symlinks and submodules are left out.
+func (r *Repository) Files(ctx context.Context, commit string) ([]File, error) {
+	out, err := run(ctx, r.Path, "ls-tree", "-r", "-z", "--long", "--full-tree", commit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list files of %s: %w", commit, err)
+	}
+
+	var files []File
+	for _, entry := range bytes.Split(out, []byte{0}) {
+		if len(entry) == 0 {
+			continue
+		}
+		// <mode> <type> <object> <size>\t<path>
+		info, path, ok := bytes.Cut(entry, []byte("\t"))
+		if !ok {
+			return nil, fmt.Errorf("unexpected ls-tree output %q", entry)
+		}
+		fields := strings.Fields(string(info))
+		if len(fields) != 4 {
+			return nil, fmt.Errorf("unexpected ls-tree output %q", entry)
+		}
+		mode, objectType := fields[0], fields[1]
+		if objectType != "blob" || !regularFile(mode) {
+			continue
+		}
+		size, err := strconv.ParseInt(fields[3], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("unexpected ls-tree size %q: %w", fields[3], err)
+		}
+		files = append(files, File{Path: string(path), Blob: fields[2], Size: size})
+	}
+	return files, nil
+}
+
+// regularFile reports whether a tree entry mode is that of a regular file,
+// rather than a symlink, submodule or a missing entry.
+func regularFile(mode string) bool {
+	return mode == "100644" || mode == "100755"
+}
+
+// ReadFile returns the content of a file at a commit.
+func (r *Repository) ReadFile(ctx context.Context, commit, path string) ([]byte, error) {
+	out, err := run(ctx, r.Path, "cat-file", "blob", commit+":"+path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s at %s: %w", path, commit, err)
+	}
+	return out, nil
+}
+
+func run(ctx context.Context, dir string, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, "git", append([]string{"-C", dir}, args...)...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("git %s: %w: %s", args[0], err, msg)
+		}
+		return nil, fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return out, nil
+}
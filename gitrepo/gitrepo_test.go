@@ -0,0 +1,85 @@
+package gitrepo
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// testRepo creates a repository in a temporary directory.
+func testRepo(t *testing.T) string {
+	dir := t.TempDir()
+	git(t, dir, "init", "-q", "-b", "main")
+	return dir
+}
+
+func git(t *testing.T, dir string, args ...string) string {
+	cmd := exec.Command("git", append([]string{"-C", dir, "-c", "user.name=Test", "-c", "user.email=test@example.com"}, args...)...)
+	out, err := cmd.CombinedOutput()
+	require.NoError(t, err, string(out))
+	return string(out)
+}
+
+func writeFile(t *testing.T, dir, name, content string) {
+	path := filepath.Join(dir, name)
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+}
+
+func commit(t *testing.T, dir, message string) string {
+	git(t, dir, "add", "-A")
+	git(t, dir, "commit", "-q", "-m", message)
+	return git(t, dir, "rev-parse", "HEAD")[:40]
+}
+
+func TestRepository(t *testing.T) {
+	dir := testRepo(t)
+	writeFile(t, dir, ".gitignore", "build/\n")
+	writeFile(t, dir, "README.md", "# Readme\n")
+	writeFile(t, dir, "docs/guide with space.md", "Guide\n")
+	writeFile(t, dir, "build/out.txt", "ignored\n")
+	require.NoError(t, os.Symlink("README.md", filepath.Join(dir, "link.md")))
+	first := commit(t, dir, "first")
+
+	repo, err := Open(t.Context(), filepath.Join(dir, "docs"))
+	require.NoError(t, err)
+	resolved, err := filepath.EvalSymlinks(dir)
+	require.NoError(t, err)
+	require.Equal(t, resolved, repo.Path)
+
+	head, err := repo.ResolveRef(t.Context(), "HEAD")
+	require.NoError(t, err)
+	require.Equal(t, first, head)
+	_, err = repo.ResolveRef(t.Context(), "no-such-branch")
+	require.Error(t, err)
+
+	// Ignored files aren't committed and symlinks are left out
+	files, err := repo.Files(t.Context(), first)
+	require.NoError(t, err)
+	var paths []string
+	for _, f := range files {
+		paths = append(paths, f.Path)
+	}
+	require.Equal(t, []string{".gitignore", "README.md", "docs/guide with space.md"}, paths)
+	require.Equal(t, int64(9), files[1].Size)
+
+	data, err := repo.ReadFile(t.Context(), first, "docs/guide with space.md")
+	require.NoError(t, err)
+	require.Equal(t, "Guide\n", string(data))
+
+	writeFile(t, dir, "README.md", "# Readme\n\nMore.\n")
+	commit(t, dir, "second")
+
+	// The file is read at the requested commit, not from the working tree
+	data, err = repo.ReadFile(t.Context(), first, "README.md")
+	require.NoError(t, err)
+	require.Equal(t, "# Readme\n", string(data))
+}
+
+func TestOpen_NotARepository(t *testing.T) {
+	_, err := Open(t.Context(), t.TempDir())
+	require.Error(t, err)
+}
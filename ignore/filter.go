@@ -0,0 +1,117 @@
+package ignore
+
+import (
+	"io/fs"
+	"path/filepath"
+	"strings"
+)
+
+// Filter selects the files of a directory tree that are indexed. Hidden
+// files and directories are left out, and so are paths matched by .ragignore
+// files or the exclude globs, and files the include globs don't match when
+// there are any. rag ingest, the watcher and filesystem sources all walk
+// trees with it, so they agree on the files of a tree.
+type Filter struct {
+	root    string
+	include *Matcher
+	exclude *Matcher
+	ignored *Matcher
+}
+
+// WalkFunc is called by Filter.Walk with the path of a file or directory,
+// its slash-separated path relative to the root, "" for the root itself,
+// and its entry. err is set as for fs.WalkDirFunc.
+type WalkFunc func(path, rel string, d fs.DirEntry, err error) error
+
+// NewFilter returns a filter for the tree at root. Include and exclude are
+// globs in .gitignore syntax relative to root.
+func NewFilter(root string, include, exclude []string) *Filter {
+	return &Filter{
+		root:    filepath.Clean(root),
+		include: New(include...),
+		exclude: New(exclude...),
+		ignored: New(),
+	}
+}
+
+// Walk walks dir, which is the root or a directory under it that passes the
+// filter, reading the .ragignore file of every directory it enters. fn is
+// called for the directories and regular files that pass the filter, and
+// for the errors reading them, which stop the walk when fn returns them.
+func (f *Filter) Walk(dir string, fn WalkFunc) error {
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		rel, relErr := f.Rel(path)
+		if relErr != nil {
+			return relErr
+		}
+		if err != nil {
+			return fn(path, rel, d, err)
+		}
+
+		if d.IsDir() {
+			// The parents were checked on the way down
+			if path != dir && !f.dir(rel) {
+				return filepath.SkipDir
+			}
+			if err := f.ignored.AddDir(path, rel); err != nil {
+				return fn(path, rel, d, err)
+			}
+			return fn(path, rel, d, nil)
+		}
+		if !d.Type().IsRegular() || !f.file(rel) {
+			return nil
+		}
+		return fn(path, rel, d, nil)
+	})
+}
+
+// Wanted reports whether a path relative to the root passes the filter,
+// checking its parent directories too, for paths that come one at a time
+// such as from file system events. The .ragignore files taken into account
+// are the ones of the directories walked so far.
+func (f *Filter) Wanted(rel string, isDir bool) bool {
+	if rel == "" {
+		return true
+	}
+	parts := strings.Split(rel, "/")
+	for i := 1; i < len(parts); i++ {
+		if !f.dir(strings.Join(parts[:i], "/")) {
+			return false
+		}
+	}
+	if isDir {
+		return f.dir(rel)
+	}
+	return f.file(rel)
+}
+
+// Rel returns the slash-separated path of a path under the root relative to
+// it, "" for the root itself.
+func (f *Filter) Rel(path string) (string, error) {
+	rel, err := filepath.Rel(f.root, path)
+	if err != nil {
+		return "", err
+	}
+	if rel == "." {
+		return "", nil
+	}
+	return filepath.ToSlash(rel), nil
+}
+
+// dir reports whether a directory is walked into, given its parents are.
+func (f *Filter) dir(rel string) bool {
+	return !hidden(rel) && !f.ignored.Match(rel, true) && !f.exclude.Match(rel, true)
+}
+
+// file reports whether a file is wanted, given its parents are.
+func (f *Filter) file(rel string) bool {
+	if hidden(rel) || f.ignored.Match(rel, false) || f.exclude.Match(rel, false) {
+		return false
+	}
+	return f.include.Empty() || f.include.Match(rel, false)
+}
+
+// hidden reports whether the last element of a path starts with a dot.
+func hidden(rel string) bool {
+	return strings.HasPrefix(rel[strings.LastIndex(rel, "/")+1:], ".")
+}
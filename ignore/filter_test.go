@@ -0,0 +1,55 @@
+package ignore
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFilter_Walk(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"notes/todo.md":        "Buy milk\n",
+		"notes/draft.md":       "Unfinished\n",
+		"notes/.ragignore":     "draft.md\n",
+		".hidden/secret.md":    "Hidden\n",
+		"build/out.md":         "Generated\n",
+		"readme.txt":           "Readme\n",
+		"notes/archive/old.md": "Old\n",
+	} {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+	require.NoError(t, os.Symlink("readme.txt", filepath.Join(dir, "link.txt")))
+
+	f := NewFilter(dir, []string{"*.md"}, []string{"build/", "archive"})
+	var dirs, files []string
+	err := f.Walk(dir, func(path, rel string, d fs.DirEntry, err error) error {
+		require.NoError(t, err)
+		require.Equal(t, filepath.Join(dir, filepath.FromSlash(rel)), path)
+		if d.IsDir() {
+			dirs = append(dirs, rel)
+		} else {
+			files = append(files, rel)
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{"", "notes"}, dirs)
+	require.Equal(t, []string{"notes/todo.md"}, files)
+
+	// Paths checked one at a time see the .ragignore files walked so far
+	require.True(t, f.Wanted("notes/todo.md", false))
+	require.True(t, f.Wanted("notes/new.md", false))
+	require.False(t, f.Wanted("notes/draft.md", false))
+	require.False(t, f.Wanted("notes/archive/new.md", false))
+	require.False(t, f.Wanted(".hidden/secret.md", false))
+	require.False(t, f.Wanted("notes/.ragignore", false))
+	require.False(t, f.Wanted("readme.txt", false))
+	require.True(t, f.Wanted("notes", true))
+	require.False(t, f.Wanted("build", true))
+}
@@ -0,0 +1,188 @@
+// Package ignore matches file paths against patterns in gitignore syntax, as
+// used by .ragignore files and include/exclude globs.
+package ignore
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// FileName is the name of the files holding ignore patterns.
+const FileName = ".ragignore"
+
+// rule is a single compiled pattern.
+type rule struct {
+	// base is the directory the pattern is relative to, "" for the root.
+	base    string
+	re      *regexp.Regexp
+	negate  bool
+	dirOnly bool
+}
+
+// Matcher matches slash-separated paths relative to a root directory. When
+// several patterns match a path, the last one wins, so a "!" pattern can
+// re-include a path excluded by an earlier one.
+type Matcher struct {
+	rules []rule
+}
+
+// New returns a matcher for patterns relative to the root.
+func New(patterns ...string) *Matcher {
+	m := &Matcher{}
+	for _, pattern := range patterns {
+		m.add("", pattern)
+	}
+	return m
+}
+
+// AddFile adds the patterns of an ignore file. Patterns are relative to dir,
+// the slash-separated path of the file's directory relative to the root.
+func (m *Matcher) AddFile(dir string, data []byte) {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		m.add(dir, scanner.Text())
+	}
+}
+
+// AddDir adds the patterns of the ignore file in a directory, if it has one.
+// dir is the path of the directory on disk and rel its slash-separated path
+// relative to the root.
+func (m *Matcher) AddDir(dir, rel string) error {
+	data, err := os.ReadFile(filepath.Join(dir, FileName))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	m.AddFile(rel, data)
+	return nil
+}
+
+// Empty reports whether the matcher has no patterns.
+func (m *Matcher) Empty() bool {
+	return len(m.rules) == 0
+}
+
+// Match reports whether the path itself matches the patterns. isDir tells
+// whether the path is a directory, for patterns ending in a slash.
+func (m *Matcher) Match(p string, isDir bool) bool {
+	matched := false
+	for _, r := range m.rules {
+		rel := p
+		if r.base != "" {
+			if !strings.HasPrefix(p, r.base+"/") {
+				continue
+			}
+			rel = strings.TrimPrefix(p, r.base+"/")
+		}
+		if r.dirOnly && !isDir {
+			continue
+		}
+		if r.re.MatchString(rel) {
+			matched = !r.negate
+		}
+	}
+	return matched
+}
+
+// Ignored reports whether a file path, or any of its parent directories,
+// matches the patterns. Like git, a file in an ignored directory can't be
+// re-included.
+func (m *Matcher) Ignored(p string) bool {
+	dirs := strings.Split(p, "/")
+	for i := 1; i < len(dirs); i++ {
+		if m.Match(strings.Join(dirs[:i], "/"), true) {
+			return true
+		}
+	}
+	return m.Match(p, false)
+}
+
+func (m *Matcher) add(base, line string) {
+	line = strings.TrimSuffix(line, "\r")
+	// Trailing spaces are ignored unless escaped
+	for strings.HasSuffix(line, " ") && !strings.HasSuffix(line, "\\ ") {
+		line = line[:len(line)-1]
+	}
+	if line == "" || strings.HasPrefix(line, "#") {
+		return
+	}
+
+	r := rule{base: base}
+	if strings.HasPrefix(line, "!") {
+		r.negate = true
+		line = line[1:]
+	} else if strings.HasPrefix(line, "\\!") || strings.HasPrefix(line, "\\#") {
+		line = line[1:]
+	}
+	if strings.HasSuffix(line, "/") {
+		r.dirOnly = true
+		line = strings.TrimSuffix(line, "/")
+	}
+	if line == "" {
+		return
+	}
+
+	// A pattern with a slash other than a trailing one is relative to its
+	// base, otherwise it matches a name at any depth
+	anchored := strings.Contains(line, "/")
+	line = strings.TrimPrefix(line, "/")
+
+	re, err := regexp.Compile(compile(line, anchored))
+	if err != nil {
+		return
+	}
+	r.re = re
+	m.rules = append(m.rules, r)
+}
+
+// compile converts a gitignore pattern into a regular expression.
+func compile(pattern string, anchored bool) string {
+	var sb strings.Builder
+	sb.WriteString("^")
+	if !anchored {
+		sb.WriteString("(?:.*/)?")
+	}
+
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		switch {
+		case strings.HasPrefix(pattern[i:], "**/"):
+			// Zero or more directories
+			sb.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(pattern[i:], "**") && i+2 == len(pattern):
+			sb.WriteString(".*")
+			i++
+		case c == '*':
+			sb.WriteString("[^/]*")
+		case c == '?':
+			sb.WriteString("[^/]")
+		case c == '[':
+			end := strings.IndexByte(pattern[i+1:], ']')
+			if end < 0 {
+				sb.WriteString(`\[`)
+				continue
+			}
+			class := pattern[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			sb.WriteString("[" + strings.ReplaceAll(class, `\`, `\\`) + "]")
+			i += end + 1
+		case c == '\\' && i+1 < len(pattern):
+			i++
+			sb.WriteString(regexp.QuoteMeta(string(pattern[i])))
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	// A pattern matching a directory also matches everything in it
+	sb.WriteString("(?:/.*)?$")
+	return sb.String()
+}
@@ -0,0 +1,82 @@
+package ignore
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatcher_Ignored(t *testing.T) {
+	m := New()
+	m.AddFile("", []byte(`# build output
+*.log
+!keep.log
+build/
+/secrets.txt
+docs/**/draft-*.md
+**/tmp
+file\ with\ space.txt
+\#hash.md
+[abc].txt
+`))
+
+	tests := map[string]bool{
+		"app.log":                      true,
+		"nested/deep/app.log":          true,
+		"keep.log":                     false,
+		"build/out.txt":                true,
+		"src/build/out.txt":            true,
+		"secrets.txt":                  true,
+		"nested/secrets.txt":           false,
+		"docs/draft-1.md":              true,
+		"docs/2026/03/draft-2.md":      true,
+		"notes/draft-1.md":             false,
+		"a/tmp/x.md":                   true,
+		"file with space.txt":          true,
+		"#hash.md":                     true,
+		"b.txt":                        true,
+		"d.txt":                        false,
+		"README.md":                    false,
+		"buildings.md":                 false,
+		"docs/guide.md":                false,
+		"nested/deep/app.log.md":       false,
+		"nested/deep/tmp-not-a-dir.md": false,
+	}
+	for p, want := range tests {
+		require.Equal(t, want, m.Ignored(p), p)
+	}
+}
+
+func TestMatcher_DirOnly(t *testing.T) {
+	m := New("cache/")
+	require.True(t, m.Match("cache", true))
+	require.False(t, m.Match("cache", false))
+	require.True(t, m.Ignored("cache/entry.md"))
+}
+
+func TestMatcher_NestedFile(t *testing.T) {
+	m := New()
+	m.AddFile("", []byte("*.tmp\n"))
+	m.AddFile("docs", []byte("/index.md\n!keep.tmp\n"))
+
+	require.True(t, m.Ignored("docs/index.md"))
+	require.False(t, m.Ignored("index.md"))
+	require.False(t, m.Ignored("docs/sub/index.md"))
+	require.True(t, m.Ignored("notes.tmp"))
+	require.False(t, m.Ignored("docs/keep.tmp"))
+	require.True(t, m.Ignored("keep.tmp"))
+}
+
+func TestMatcher_AddDir(t *testing.T) {
+	dir := t.TempDir()
+	m := New()
+	require.NoError(t, m.AddDir(dir, ""))
+	require.True(t, m.Empty())
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, FileName), []byte("*.bak\n"), 0644))
+	require.NoError(t, m.AddDir(dir, ""))
+	require.False(t, m.Empty())
+	require.True(t, m.Ignored("notes.bak"))
+}
@@ -0,0 +1,147 @@
+// Package junk recognizes documents whose text would only add noise to
+// search results, such as binary data, minified code, lockfiles and
+// generated files.
+package junk
+
+import (
+	"bytes"
+	"fmt"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/MaxIvanyshen/local-rag/extractor"
+)
+
+// SampleSize is how much of the start of a document Check looks at.
+const SampleSize = 64 << 10
+
+// markerLines is how many lines at the start of a document are searched for
+// generated file markers.
+const markerLines = 5
+
+// Kinds of junk, which lead the reasons Check returns.
+const (
+	KindBinary    = "binary"
+	KindMinified  = "minified"
+	KindLockfile  = "lockfile"
+	KindGenerated = "generated"
+)
+
+// Rules select the documents that are junk. Rules that are empty or zero
+// are off.
+type Rules struct {
+	// MaxNonPrintableRatio is the share of control and undecodable
+	// characters above which text is taken as binary.
+	MaxNonPrintableRatio float64
+	// MinifiedExtensions are the extensions of code checked for
+	// minification, such as ".js".
+	MinifiedExtensions []string
+	// MinifiedLineLength is the average line length from which code is
+	// taken as minified.
+	MinifiedLineLength int
+	// Lockfiles are the file names of dependency lockfiles, such as
+	// "package-lock.json".
+	Lockfiles []string
+	// GeneratedPatterns are globs matched against the file names of
+	// generated files, such as "*.pb.go".
+	GeneratedPatterns []string
+	// GeneratedMarkers are phrases that mark a file as generated in its
+	// first lines, such as "Code generated".
+	GeneratedMarkers []string
+}
+
+// CheckName returns why a document is junk judging by its name alone, or an
+// empty string.
+func (r *Rules) CheckName(name string) string {
+	base := filepath.Base(name)
+	if slices.Contains(r.Lockfiles, base) {
+		return fmt.Sprintf("%s: %s", KindLockfile, base)
+	}
+	for _, pattern := range r.GeneratedPatterns {
+		if ok, _ := path.Match(pattern, base); ok {
+			return fmt.Sprintf("%s: name matches %s", KindGenerated, pattern)
+		}
+	}
+	return ""
+}
+
+// Check returns why a document is junk judging by its name and the start of
+// its text, or an empty string. Reasons start with the kind of junk, as in
+// "lockfile: yarn.lock". Documents in formats that aren't text, such as
+// PDFs, should be checked with CheckName, as they would all look binary.
+func (r *Rules) Check(name string, data []byte) string {
+	if reason := r.CheckName(name); reason != "" {
+		return reason
+	}
+
+	sample := data[:min(len(data), SampleSize)]
+	if extractor.IsBinary(sample) {
+		return KindBinary + ": contains NUL bytes"
+	}
+	if r.MaxNonPrintableRatio > 0 {
+		if ratio := nonPrintableRatio(sample); ratio > r.MaxNonPrintableRatio {
+			return fmt.Sprintf("%s: %.0f%% non-printable characters", KindBinary, ratio*100)
+		}
+	}
+
+	if r.MinifiedLineLength > 0 && slices.Contains(r.MinifiedExtensions, strings.ToLower(filepath.Ext(name))) {
+		// Sampling may cut the last line short, which only lowers the average
+		if length := len(sample) / (bytes.Count(sample, []byte("\n")) + 1); length >= r.MinifiedLineLength {
+			return fmt.Sprintf("%s: average line length %d", KindMinified, length)
+		}
+	}
+
+	head := firstLines(sample, markerLines)
+	for _, marker := range r.GeneratedMarkers {
+		if marker != "" && bytes.Contains(head, []byte(marker)) {
+			return fmt.Sprintf("%s: marked %q", KindGenerated, marker)
+		}
+	}
+	return ""
+}
+
+// firstLines returns the first n lines of data.
+func firstLines(data []byte, n int) []byte {
+	end := 0
+	for range n {
+		i := bytes.IndexByte(data[end:], '\n')
+		if i < 0 {
+			return data
+		}
+		end += i + 1
+	}
+	return data[:end]
+}
+
+// nonPrintableRatio returns the share of the characters of the sample that
+// are control characters other than whitespace and escapes, or couldn't be
+// decoded.
+func nonPrintableRatio(sample []byte) float64 {
+	// Leave out a rune cut by sampling, which would make UTF-8 look invalid
+	for i := 1; i <= min(len(sample), utf8.UTFMax); i++ {
+		if utf8.RuneStart(sample[len(sample)-i]) {
+			if !utf8.FullRune(sample[len(sample)-i:]) {
+				sample = sample[:len(sample)-i]
+			}
+			break
+		}
+	}
+
+	text, _ := extractor.DecodeText(sample)
+	total, nonPrintable := 0, 0
+	for _, r := range string(text) {
+		total++
+		switch {
+		case r == '\t' || r == '\n' || r == '\r' || r == '\f' || r == '\v' || r == '\x1b':
+		case r < 0x20 || r == 0x7f || r == utf8.RuneError:
+			nonPrintable++
+		}
+	}
+	if total == 0 {
+		return 0
+	}
+	return float64(nonPrintable) / float64(total)
+}
@@ -0,0 +1,66 @@
+package junk
+
+import (
+	"math/rand/v2"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+var testRules = &Rules{
+	MaxNonPrintableRatio: 0.1,
+	MinifiedExtensions:   []string{".js", ".css"},
+	MinifiedLineLength:   300,
+	Lockfiles:            []string{"package-lock.json", "go.sum"},
+	GeneratedPatterns:    []string{"*.pb.go"},
+	GeneratedMarkers:     []string{"Code generated", "@generated"},
+}
+
+func TestCheck(t *testing.T) {
+	random := make([]byte, 4096)
+	rng := rand.New(rand.NewPCG(1, 2))
+	for i := range random {
+		// Random bytes without NULs, which are caught on their own
+		random[i] = byte(rng.IntN(255) + 1)
+	}
+	minified := "function a(b){return b+1}var c=" + strings.Repeat("a(1)+", 200) + "0;\n"
+
+	tests := []struct {
+		name   string
+		data   string
+		reason string
+	}{
+		{"notes/plan.md", "# Plan\n\nShip it.\n", ""},
+		{"web/package-lock.json", `{"lockfileVersion": 3}`, "lockfile: package-lock.json"},
+		{"repo.zip!/go.sum", "golang.org/x/net v0.42.0 h1:abc=\n", "lockfile: go.sum"},
+		{"api/service.pb.go", "package api\n", "generated: name matches *.pb.go"},
+		{"image.bin", "GIF89a\x00\x01", "binary: contains NUL bytes"},
+		{"noise.dat", string(random), "binary"},
+		{"app.min.js", minified, "minified: average line length"},
+		{"app.js", "function add(a, b) {\n  return a + b\n}\n", ""},
+		// Minification only applies to code
+		{"essay.txt", strings.Repeat("A long paragraph on one line. ", 50) + "\n", ""},
+		{"model_gen.go", "// Code generated by tool. DO NOT EDIT.\n\npackage model\n", `generated: marked "Code generated"`},
+		{"doc.txt", strings.Repeat("line\n", 10) + "Code generated docs are excluded.\n", ""},
+		// Text in other encodings isn't binary
+		{"latin1.txt", "Caf\xe9 cr\xe8me br\xfbl\xe9e\n", ""},
+		{"utf16.txt", "\xff\xfeH\x00i\x00\n\x00", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reason := testRules.Check(tt.name, []byte(tt.data))
+			if tt.reason == "" {
+				require.Empty(t, reason)
+				return
+			}
+			require.True(t, strings.HasPrefix(reason, tt.reason), "reason %q", reason)
+		})
+	}
+}
+
+func TestCheckName(t *testing.T) {
+	require.Equal(t, "lockfile: package-lock.json", testRules.CheckName("package-lock.json"))
+	require.Empty(t, testRules.CheckName("report.pdf"))
+	require.Empty(t, (&Rules{}).Check("package-lock.json", []byte("{}")))
+}
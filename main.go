@@ -7,36 +7,71 @@ import (
 	"log/slog"
 	"net/http"
 	"os"
+	"time"
 
 	"github.com/MaxIvanyshen/local-rag/chunker"
 	"github.com/MaxIvanyshen/local-rag/config"
 	"github.com/MaxIvanyshen/local-rag/db"
 	"github.com/MaxIvanyshen/local-rag/embedding"
+	"github.com/MaxIvanyshen/local-rag/extractor"
+	"github.com/MaxIvanyshen/local-rag/junk"
 	"github.com/MaxIvanyshen/local-rag/service"
+	"github.com/MaxIvanyshen/local-rag/source"
+	"github.com/MaxIvanyshen/local-rag/watcher"
 
 	_ "github.com/mattn/go-sqlite3"
 )
 
-func createEmbedder(cfg *config.Config) (embedding.Embedder, error) {
-	switch cfg.Embedder.Type {
-	case "ollama":
-		return embedding.NewOllamaEmbedder(cfg.Embedder.Model, embedding.WithBaseURL(cfg.Embedder.BaseURL)), nil
-	case "http":
-		return embedding.NewHTTPEmbedder(cfg.Embedder.BaseURL), nil
-	default:
-		return nil, fmt.Errorf("unknown embedder type: %s", cfg.Embedder.Type)
-	}
+func createExtractor(cfg *config.Config) *extractor.Registry {
+	files := extractor.NewRegistry(
+		&extractor.PDFExtractor{},
+		&extractor.MarkdownExtractor{},
+		&extractor.HTMLExtractor{},
+		&extractor.DOCXExtractor{},
+		&extractor.ODTExtractor{},
+		&extractor.PPTXExtractor{},
+		&extractor.XLSXExtractor{},
+		&extractor.EPUBExtractor{},
+		extractor.NewNotebookExtractor(cfg.Extractor.NotebookOutputs),
+		extractor.NewEmailExtractor(cfg.Extractor.EmailStripQuotes),
+		&extractor.JSONExtractor{
+			TextPaths:     cfg.Extractor.JSON.TextPaths,
+			TitlePath:     cfg.Extractor.JSON.TitlePath,
+			IDPath:        cfg.Extractor.JSON.IDPath,
+			MetadataPaths: cfg.Extractor.JSON.MetadataPaths,
+		},
+	)
+
+	// Files inside archives go through the same extractors as loose files
+	archives := extractor.NewArchiveExtractor(files, extractor.ArchiveLimits{
+		MaxEntries:   cfg.Extractor.Archive.MaxEntries,
+		MaxEntrySize: cfg.Extractor.Archive.MaxEntrySize,
+		MaxTotalSize: cfg.Extractor.Archive.MaxTotalSize,
+	})
+	return extractor.NewRegistry(archives, files)
 }
 
-func createChunker(cfg *config.Config) (chunker.Chunker, error) {
-	switch cfg.Chunker.Type {
-	case "paragraph":
-		return chunker.NewParagraphChunker(cfg.Chunker.OverlapBytes), nil
-	case "fixed":
-		return &chunker.FixedSizeChunker{ChunkSize: cfg.Chunker.ChunkSize}, nil
-	default:
-		return nil, fmt.Errorf("unknown chunker type: %s", cfg.Chunker.Type)
+func createSources(cfg *config.Config) (map[string]source.Source, error) {
+	sources := make(map[string]source.Source, len(cfg.Sources))
+	for _, sc := range cfg.Sources {
+		if sc.Name == "" {
+			return nil, fmt.Errorf("source of type %q has no name", sc.Type)
+		}
+		if _, ok := sources[sc.Name]; ok {
+			return nil, fmt.Errorf("duplicate source name %q", sc.Name)
+		}
+		switch sc.Type {
+		case "filesystem":
+			sources[sc.Name] = &source.FileSystem{Dir: sc.Path, Include: sc.Include, Exclude: sc.Exclude}
+		case "git":
+			sources[sc.Name] = &source.Git{Path: sc.Path, Ref: sc.Ref, SkipDirs: cfg.Git.SkipDirs, MaxFileSize: cfg.Git.MaxFileSize}
+		case "http":
+			sources[sc.Name] = &source.HTTP{URLs: sc.URLs, UserAgent: cfg.Crawler.UserAgent, MaxSize: cfg.Crawler.MaxPageSize}
+		default:
+			return nil, fmt.Errorf("unknown type %q of source %q", sc.Type, sc.Name)
+		}
 	}
+	return sources, nil
 }
 
 func setupLogging(file *os.File) {
@@ -87,26 +122,70 @@ func main() {
 		w.Write([]byte("ok"))
 	})
 
-	contentChunker, err := createChunker(cfg)
+	contentChunker, err := chunker.New(cfg.Chunker)
 	if err != nil {
 		slog.Error("failed to create chunker", slog.String("error", err.Error()))
 		os.Exit(1)
 	}
 
-	embedder, err := createEmbedder(cfg)
+	var contextHeader *chunker.ContextHeader
+	if cfg.Chunker.ContextHeaders {
+		contextHeader, err = chunker.NewContextHeader(cfg.Chunker.ContextHeaderTemplate)
+		if err != nil {
+			slog.Error("failed to create context header", slog.String("error", err.Error()))
+			os.Exit(1)
+		}
+	}
+
+	var junkRules *junk.Rules
+	if cfg.Junk.Enabled {
+		junkRules = &junk.Rules{
+			MaxNonPrintableRatio: cfg.Junk.MaxNonPrintableRatio,
+			MinifiedExtensions:   cfg.Junk.MinifiedExtensions,
+			MinifiedLineLength:   cfg.Junk.MinifiedLineLength,
+			Lockfiles:            cfg.Junk.Lockfiles,
+			GeneratedPatterns:    cfg.Junk.GeneratedPatterns,
+			GeneratedMarkers:     cfg.Junk.GeneratedMarkers,
+		}
+	}
+
+	embedder, err := embedding.New(cfg.Embedder)
 	if err != nil {
 		slog.Error("failed to create embedder", slog.String("error", err.Error()))
 		os.Exit(1)
 	}
 
+	sources, err := createSources(cfg)
+	if err != nil {
+		slog.Error("failed to create sources", slog.String("error", err.Error()))
+		os.Exit(1)
+	}
+
+	documentExtractor := createExtractor(cfg)
 	s := service.NewService(&service.ServiceParameters{
-		DB:       db,
-		Embedder: embedder,
-		Chunker:  contentChunker,
-		Cfg:      cfg,
+		DB:            db,
+		Embedder:      embedder,
+		Chunker:       contentChunker,
+		Extractor:     documentExtractor,
+		ContextHeader: contextHeader,
+		Junk:          junkRules,
+		Sources:       sources,
+		Cfg:           cfg,
 	})
 	s.RegisterRoutes(mux)
 
+	if len(cfg.Watch.Dirs) > 0 {
+		w, err := watcher.New(cfg.Watch.Dirs, s, watcher.Options{
+			Debounce: time.Duration(cfg.Watch.DebounceMillis) * time.Millisecond,
+			Supports: documentExtractor.Supports,
+		})
+		if err != nil {
+			slog.Error("failed to watch directories", slog.String("error", err.Error()))
+			os.Exit(1)
+		}
+		go w.Run(ctx)
+	}
+
 	port := cfg.Port
 
 	httpServer := &http.Server{
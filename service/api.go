@@ -3,8 +3,14 @@ package service
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"log/slog"
+	"mime"
 	"net/http"
+	"strconv"
+	"time"
 )
 
 func (s *Service) RegisterRoutes(mux *http.ServeMux) {
@@ -13,7 +19,13 @@ func (s *Service) RegisterRoutes(mux *http.ServeMux) {
 	mux.HandleFunc("/api/search", makeHandler(s.Search))
 	mux.HandleFunc("/api/process_document", makeHandler(s.ProcessDocument))
 	mux.HandleFunc("/api/delete_document", makeHandler(s.DeleteDocument))
+	mux.HandleFunc("/api/upload_document", s.handleUploadDocument)
 	mux.HandleFunc("/api/batch_process_documents", makeHandler(s.BatchProcessDocuments))
+	mux.HandleFunc("/api/check_documents", makeHandler(s.CheckDocuments))
+	mux.HandleFunc("/api/sync_git_repository", makeHandler(s.SyncGitRepository))
+	mux.HandleFunc("/api/crawl_site", makeHandler(s.CrawlSite))
+	mux.HandleFunc("/api/document_links", makeHandler(s.DocumentLinks))
+	mux.HandleFunc("/api/sync", makeHandler(s.Sync))
 }
 
 func makeHandler[Req, Res any](handler func(context.Context, *Req) (Res, error)) http.HandlerFunc {
@@ -41,3 +53,143 @@ func makeHandler[Req, Res any](handler func(context.Context, *Req) (Res, error))
 		}
 	}
 }
+
+// uploadHeaders are the request headers that may carry the upload fields
+// instead of the query.
+var uploadHeaders = map[string]string{
+	"name":     "X-Document-Name",
+	"metadata": "X-Document-Metadata",
+	"mod_time": "X-Document-Mod-Time",
+	"force":    "X-Document-Force",
+}
+
+// maxUploadFieldSize bounds the form fields of a multipart upload.
+const maxUploadFieldSize = 1 << 20
+
+// handleUploadDocument streams a document to UploadDocument. The body is
+// either the raw document, or multipart/form-data with the document in a
+// "file" part. The document name, metadata as a JSON object, mod_time and
+// force are taken from the query, X-Document-* headers or form fields
+// sent before the file.
+func (s *Service) handleUploadDocument(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost && r.Method != http.MethodPut {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	req := &UploadDocumentRequest{}
+	for field, header := range uploadHeaders {
+		value := r.URL.Query().Get(field)
+		if value == "" {
+			value = r.Header.Get(header)
+		}
+		if err := req.setField(field, value); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	if err := req.setField("content_type", r.URL.Query().Get("content_type")); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType == "multipart/form-data" {
+		if err := req.readMultipart(r); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	} else {
+		req.Body = r.Body
+		if req.ContentType == "" && mediaType != "application/octet-stream" {
+			req.ContentType = r.Header.Get("Content-Type")
+		}
+	}
+	if req.DocumentName == "" {
+		http.Error(w, "missing document name", http.StatusBadRequest)
+		return
+	}
+
+	res, err := s.UploadDocument(r.Context(), req)
+	if errors.Is(err, ErrDocumentTooLarge) {
+		http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+		return
+	}
+	if err != nil {
+		slog.Error("handler error", slog.String("error", err.Error()))
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(res); err != nil {
+		slog.Error("failed to encode response", slog.String("error", err.Error()))
+	}
+}
+
+// readMultipart reads the form fields of a multipart upload up to its file
+// part, which becomes the body. The file part names the document and gives
+// its content type unless they were set already.
+func (req *UploadDocumentRequest) readMultipart(r *http.Request) error {
+	mr, err := r.MultipartReader()
+	if err != nil {
+		return err
+	}
+	for {
+		part, err := mr.NextPart()
+		if errors.Is(err, io.EOF) {
+			return errors.New("missing file part")
+		}
+		if err != nil {
+			return err
+		}
+
+		if part.FormName() == "file" || part.FileName() != "" {
+			if req.DocumentName == "" {
+				req.DocumentName = part.FileName()
+			}
+			if contentType := part.Header.Get("Content-Type"); req.ContentType == "" && contentType != "application/octet-stream" {
+				req.ContentType = contentType
+			}
+			req.Body = part
+			return nil
+		}
+
+		value, err := io.ReadAll(io.LimitReader(part, maxUploadFieldSize))
+		if err != nil {
+			return err
+		}
+		if err := req.setField(part.FormName(), string(value)); err != nil {
+			return err
+		}
+	}
+}
+
+// setField sets an upload field from its text form. Empty values and
+// unknown fields are ignored.
+func (req *UploadDocumentRequest) setField(field, value string) error {
+	if value == "" {
+		return nil
+	}
+	switch field {
+	case "name":
+		req.DocumentName = value
+	case "metadata":
+		if err := json.Unmarshal([]byte(value), &req.Metadata); err != nil {
+			return fmt.Errorf("invalid metadata: %w", err)
+		}
+	case "mod_time":
+		modTime, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return fmt.Errorf("invalid mod_time: %w", err)
+		}
+		req.ModTime = &modTime
+	case "force":
+		force, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("invalid force: %w", err)
+		}
+		req.Force = force
+	case "content_type":
+		req.ContentType = value
+	}
+	return nil
+}
@@ -0,0 +1,205 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/MaxIvanyshen/local-rag/crawler"
+	"github.com/MaxIvanyshen/local-rag/db"
+	"github.com/MaxIvanyshen/local-rag/extractor"
+)
+
+type CrawlSiteRequest struct {
+	// URL is the page or sitemap.xml the crawl starts from.
+	URL string `json:"url"`
+	// MaxDepth is how many links away from the start page the crawl goes.
+	// It defaults to the configured depth.
+	MaxDepth *int `json:"max_depth,omitempty"`
+	// MaxPages stops the crawl after this many pages. Zero uses the
+	// configured limit.
+	MaxPages int `json:"max_pages,omitempty"`
+}
+
+type CrawlSiteResponse struct {
+	Processed int `json:"processed"`
+	// Unchanged are the pages not modified since the previous crawl.
+	Unchanged       int      `json:"unchanged"`
+	Deleted         int      `json:"deleted"`
+	Skipped         int      `json:"skipped"`
+	FailedDocuments []string `json:"failed_documents"`
+}
+
+// CrawlSite indexes the pages of a website, following links on the same host
+// from a start page or the pages listed by a sitemap. Documents are named by
+// the URL of the page and carry the URL and the start URL of the crawl in
+// their metadata. Pages crawled before are requested conditionally with the
+// ETag and Last-Modified recorded for them. Pages that are gone, and pages of
+// earlier crawls that the crawl no longer reached, are deleted, unless the
+// crawl stopped at the page limit.
+func (s *Service) CrawlSite(ctx context.Context, req *CrawlSiteRequest) (*CrawlSiteResponse, error) {
+	slog.Info("received crawl site request", slog.String("url", req.URL))
+
+	opts := crawler.Options{
+		MaxDepth:    s.cfg.Crawler.MaxDepth,
+		MaxPages:    s.cfg.Crawler.MaxPages,
+		MaxPageSize: s.cfg.Crawler.MaxPageSize,
+		UserAgent:   s.cfg.Crawler.UserAgent,
+	}
+	if req.MaxDepth != nil {
+		opts.MaxDepth = *req.MaxDepth
+	}
+	if req.MaxPages > 0 {
+		opts.MaxPages = req.MaxPages
+	}
+
+	crawled, err := db.GetCrawledPages(ctx, s.db, req.URL)
+	if err != nil {
+		return nil, err
+	}
+	states := make(map[string]*db.CrawledPage, len(crawled))
+	for i := range crawled {
+		states[crawled[i].URL] = &crawled[i]
+	}
+	previous := func(url string) *crawler.Previous {
+		state, ok := states[url]
+		if !ok {
+			return nil
+		}
+		return &crawler.Previous{
+			ETag:         state.ETag,
+			LastModified: state.LastModified,
+			Links:        state.Links,
+		}
+	}
+
+	res := &CrawlSiteResponse{
+		FailedDocuments: []string{},
+	}
+	reached := make(map[string]bool)
+	err = crawler.New(opts).Crawl(ctx, req.URL, previous, func(page *crawler.Page) error {
+		reached[page.URL] = true
+		return s.syncCrawledPage(ctx, req.URL, page, states[page.URL] != nil, res)
+	})
+	complete := !errors.Is(err, crawler.ErrPageLimit)
+	if err != nil && complete {
+		return nil, err
+	}
+
+	// Pages no longer linked from the site are gone from it, but a crawl
+	// cut short doesn't tell
+	if complete {
+		for _, state := range crawled {
+			if reached[state.URL] {
+				continue
+			}
+			if _, err := s.DeleteDocument(ctx, &DeleteDocumentRequest{DocumentName: state.URL}); err != nil {
+				res.FailedDocuments = append(res.FailedDocuments, state.URL)
+				continue
+			}
+			if err := db.DeleteCrawledPage(ctx, s.db, state.URL); err != nil {
+				return nil, err
+			}
+			res.Deleted++
+		}
+	}
+
+	slog.Info("crawl completed", slog.String("url", req.URL), slog.Int("processed", res.Processed), slog.Int("unchanged", res.Unchanged), slog.Int("deleted", res.Deleted), slog.Int("skipped", res.Skipped), slog.Int("failed_documents", len(res.FailedDocuments)))
+
+	return res, nil
+}
+
+// syncCrawledPage indexes a fetched page and records its crawl state. Pages
+// that failed are counted and left for the next crawl, only errors storing
+// the crawl state stop the crawl.
+func (s *Service) syncCrawledPage(ctx context.Context, site string, page *crawler.Page, crawledBefore bool, res *CrawlSiteResponse) error {
+	fail := func(err error) error {
+		slog.Error("failed to sync crawled page", slog.String("error", err.Error()), slog.String("document_name", page.URL))
+		res.FailedDocuments = append(res.FailedDocuments, page.URL)
+		return nil
+	}
+
+	switch {
+	case page.Err != nil:
+		return fail(page.Err)
+	case page.StatusCode == http.StatusNotModified:
+		res.Unchanged++
+		return s.saveCrawledPage(ctx, site, page)
+	case page.StatusCode == http.StatusNotFound || page.StatusCode == http.StatusGone:
+		if _, err := s.DeleteDocument(ctx, &DeleteDocumentRequest{DocumentName: page.URL}); err != nil {
+			return fail(err)
+		}
+		if crawledBefore {
+			res.Deleted++
+		}
+		return db.DeleteCrawledPage(ctx, s.db, page.URL)
+	case page.StatusCode < 200 || page.StatusCode > 299:
+		return fail(fmt.Errorf("status %d", page.StatusCode))
+	}
+
+	if reason := s.skipCrawledPage(page); reason != "" {
+		slog.Debug("skipping crawled page", slog.String("document_name", page.URL), slog.String("reason", reason))
+		if _, err := s.DeleteDocument(ctx, &DeleteDocumentRequest{DocumentName: page.URL}); err != nil {
+			return fail(err)
+		}
+		res.Skipped++
+		// Record the page anyway, so its links are followed when it isn't
+		// modified
+		return s.saveCrawledPage(ctx, site, page)
+	}
+
+	processReq := &ProcessDocumentRequest{
+		DocumentName: page.URL,
+		DocumentData: page.Data,
+		ContentType:  page.ContentType,
+		Metadata: map[string]any{
+			"url":  page.URL,
+			"site": site,
+		},
+	}
+	if modTime, err := http.ParseTime(page.LastModified); err == nil {
+		processReq.ModTime = &modTime
+	}
+	processRes, err := s.ProcessDocument(ctx, processReq)
+	if err != nil {
+		return fail(err)
+	}
+	if !processRes.Success {
+		return fail(fmt.Errorf("processing %s was not successful", page.URL))
+	}
+	switch processRes.Status {
+	case StatusUnchanged:
+		res.Unchanged++
+	case StatusSkipped:
+		res.Skipped++
+	default:
+		res.Processed++
+	}
+	return s.saveCrawledPage(ctx, site, page)
+}
+
+// skipCrawledPage returns why a page isn't indexed, or an empty string.
+// Binary pages are indexed only when an extractor handles their format.
+func (s *Service) skipCrawledPage(page *crawler.Page) string {
+	if page.NoIndex {
+		return "robots meta tag"
+	}
+	if extractor.IsBinary(page.Data) && !s.supportsFormat(s.formatName(page.URL, page.ContentType, page.Data), page.Data) {
+		return "binary file"
+	}
+	return ""
+}
+
+func (s *Service) saveCrawledPage(ctx context.Context, site string, page *crawler.Page) error {
+	return db.SaveCrawledPage(ctx, s.db, &db.CrawledPage{
+		URL:          page.URL,
+		Site:         site,
+		ETag:         page.ETag,
+		LastModified: page.LastModified,
+		Links:        page.Links,
+		CrawledAt:    time.Now(),
+	})
+}
@@ -0,0 +1,131 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/MaxIvanyshen/local-rag/db"
+	"github.com/MaxIvanyshen/local-rag/extractor"
+	"gorm.io/gorm"
+)
+
+func TestCrawlSite(t *testing.T) {
+	ctx := context.Background()
+
+	pages := map[string]string{
+		"/":      `<h1>Internal docs</h1><p>Start with the <a href="/guide">guide</a> or the <a href="/faq">FAQ</a>.</p>`,
+		"/guide": `<h1>Guide</h1><p>Rotate the signing keys every quarter.</p>`,
+		"/faq":   `<h1>FAQ</h1><p>Ask in the infrastructure channel.</p>`,
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, ok := pages[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		etag := fmt.Sprintf(`"%x"`, len(body))
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", etag)
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprint(w, body)
+	}))
+	defer srv.Close()
+
+	crawlSvc := NewService(&ServiceParameters{
+		DB:        testDB,
+		Embedder:  svc.embedder,
+		Chunker:   svc.chunker,
+		Extractor: extractor.NewRegistry(&extractor.HTMLExtractor{}),
+		Cfg:       svc.cfg,
+	})
+	site := srv.URL + "/"
+
+	res, err := crawlSvc.CrawlSite(ctx, &CrawlSiteRequest{URL: site})
+	if err != nil {
+		t.Fatalf("failed to crawl site: %v", err)
+	}
+	if res.Processed != 3 || res.Unchanged != 0 || len(res.FailedDocuments) != 0 {
+		t.Fatalf("unexpected first crawl result %+v", res)
+	}
+
+	guideName := srv.URL + "/guide"
+	doc, err := db.GetDocumentByName(ctx, testDB, guideName)
+	if err != nil {
+		t.Fatalf("failed to get document: %v", err)
+	}
+	if doc.Metadata["url"] != guideName || doc.Metadata["site"] != site {
+		t.Fatalf("expected url and site in metadata, got %v", doc.Metadata)
+	}
+	// The page is extracted as HTML although its URL has no extension
+	chunks, err := db.GetChunksByDocumentID(ctx, testDB, doc.ID)
+	if err != nil || len(chunks) == 0 {
+		t.Fatalf("failed to get chunks: %v", err)
+	}
+	for _, c := range chunks {
+		if strings.Contains(string(c.Data), "<h1>") {
+			t.Fatalf("expected extracted text, got %q", c.Data)
+		}
+	}
+
+	// Unmodified pages are answered with 304 and their links still followed
+	res, err = crawlSvc.CrawlSite(ctx, &CrawlSiteRequest{URL: site})
+	if err != nil {
+		t.Fatalf("failed to crawl site again: %v", err)
+	}
+	if res.Processed != 0 || res.Unchanged != 3 {
+		t.Fatalf("unexpected second crawl result %+v", res)
+	}
+
+	// A page that is gone is deleted
+	delete(pages, "/guide")
+	res, err = crawlSvc.CrawlSite(ctx, &CrawlSiteRequest{URL: site})
+	if err != nil {
+		t.Fatalf("failed to crawl site after removing a page: %v", err)
+	}
+	if res.Deleted != 1 || res.Unchanged != 2 {
+		t.Fatalf("unexpected third crawl result %+v", res)
+	}
+	if _, err := db.GetDocumentByName(ctx, testDB, guideName); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Fatalf("expected the removed page to be deleted, got %v", err)
+	}
+
+	// A crawl stopped at the page limit doesn't delete the pages it didn't
+	// reach
+	faqName := srv.URL + "/faq"
+	res, err = crawlSvc.CrawlSite(ctx, &CrawlSiteRequest{URL: site, MaxPages: 1})
+	if err != nil {
+		t.Fatalf("failed to crawl site with a page limit: %v", err)
+	}
+	if res.Deleted != 0 || res.Unchanged != 1 {
+		t.Fatalf("unexpected page limited crawl result %+v", res)
+	}
+	if _, err := db.GetDocumentByName(ctx, testDB, faqName); err != nil {
+		t.Fatalf("expected the unreached page to be kept, got %v", err)
+	}
+
+	// A page no longer linked from the site is deleted, although it still
+	// exists
+	pages["/"] = `<h1>Internal docs</h1><p>Nothing here yet.</p>`
+	res, err = crawlSvc.CrawlSite(ctx, &CrawlSiteRequest{URL: site})
+	if err != nil {
+		t.Fatalf("failed to crawl site after unlinking a page: %v", err)
+	}
+	if res.Deleted != 1 || res.Processed != 1 {
+		t.Fatalf("unexpected crawl result after unlinking a page %+v", res)
+	}
+	if _, err := db.GetDocumentByName(ctx, testDB, faqName); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Fatalf("expected the unlinked page to be deleted, got %v", err)
+	}
+	crawled, err := db.GetCrawledPages(ctx, testDB, site)
+	if err != nil || len(crawled) != 1 {
+		t.Fatalf("expected the crawl state of the remaining page, got %v (%v)", crawled, err)
+	}
+}
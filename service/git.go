@@ -0,0 +1,85 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/MaxIvanyshen/local-rag/extractor"
+	"github.com/MaxIvanyshen/local-rag/gitrepo"
+	"github.com/MaxIvanyshen/local-rag/source"
+)
+
+type SyncGitRepositoryRequest struct {
+	// RepoPath is the path of a local repository on the server.
+	RepoPath string `json:"repo_path"`
+	// Ref is the branch, tag or commit to index. It defaults to HEAD.
+	Ref string `json:"ref,omitempty"`
+}
+
+type SyncGitRepositoryResponse struct {
+	Commit          string   `json:"commit"`
+	Processed       int      `json:"processed"`
+	Unchanged       int      `json:"unchanged"`
+	Deleted         int      `json:"deleted"`
+	Skipped         int      `json:"skipped"`
+	FailedDocuments []string `json:"failed_documents"`
+}
+
+// gitSourcePrefix leads the names of the sources synced by
+// SyncGitRepository, which are followed by the repository path.
+const gitSourcePrefix = "git:"
+
+// SyncGitRepository indexes the files of a local git repository at a ref,
+// as a git source named after the repository path. Documents are named by
+// the absolute path of the file and carry the repository path, commit SHA
+// and relative path in their metadata. Only the files whose blobs changed
+// since the last sync are processed again.
+func (s *Service) SyncGitRepository(ctx context.Context, req *SyncGitRepositoryRequest) (*SyncGitRepositoryResponse, error) {
+	slog.Info("received git repository sync request", slog.String("repo_path", req.RepoPath), slog.String("ref", req.Ref))
+
+	repo, err := gitrepo.Open(ctx, req.RepoPath)
+	if err != nil {
+		return nil, err
+	}
+	ref := req.Ref
+	if ref == "" {
+		ref = "HEAD"
+	}
+	// Listing the resolved commit keeps a commit made during the sync out of it
+	commit, err := repo.ResolveRef(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	src := &source.Git{
+		Path:        repo.Path,
+		Ref:         commit,
+		SkipDirs:    s.cfg.Git.SkipDirs,
+		MaxFileSize: s.cfg.Git.MaxFileSize,
+	}
+	sourceRes, err := s.SyncSource(ctx, gitSourcePrefix+repo.Path, src)
+	if err != nil {
+		return nil, err
+	}
+	return &SyncGitRepositoryResponse{
+		Commit:          commit,
+		Processed:       sourceRes.Added + sourceRes.Updated,
+		Unchanged:       sourceRes.Unchanged,
+		Deleted:         sourceRes.Deleted,
+		Skipped:         sourceRes.Skipped,
+		FailedDocuments: sourceRes.FailedDocuments,
+	}, nil
+}
+
+// supportsFormat reports whether the extractor handles the document as
+// something other than plain text.
+func (s *Service) supportsFormat(name string, data []byte) bool {
+	switch e := s.extractor.(type) {
+	case nil:
+		return false
+	case *extractor.Registry:
+		return e.Supports(name, data)
+	default:
+		return e.Match(name, data)
+	}
+}
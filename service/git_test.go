@@ -0,0 +1,151 @@
+package service
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/MaxIvanyshen/local-rag/config"
+	"github.com/MaxIvanyshen/local-rag/db"
+)
+
+func runGit(t *testing.T, dir string, args ...string) string {
+	cmd := exec.Command("git", append([]string{"-C", dir, "-c", "user.name=Test", "-c", "user.email=test@example.com"}, args...)...)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %v failed: %v: %s", args, err, out)
+	}
+	return string(out)
+}
+
+func writeRepoFile(t *testing.T, dir, name, content string) {
+	path := filepath.Join(dir, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("failed to create directory: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+}
+
+func commitRepo(t *testing.T, dir string) string {
+	runGit(t, dir, "add", "-A")
+	runGit(t, dir, "commit", "-q", "-m", "update")
+	return runGit(t, dir, "rev-parse", "HEAD")[:40]
+}
+
+func TestSyncGitRepository(t *testing.T) {
+	ctx := context.Background()
+
+	dir, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatalf("failed to resolve temp dir: %v", err)
+	}
+	runGit(t, dir, "init", "-q", "-b", "main")
+	writeRepoFile(t, dir, ".gitignore", "*.log\n")
+	writeRepoFile(t, dir, "README.md", "# Monorepo\n\nThe deploy script lives in tools.\n")
+	writeRepoFile(t, dir, "docs/setup.md", "# Setup\n\nInstall the toolchain first.\n")
+	writeRepoFile(t, dir, "vendor/lib/README.md", "Vendored library docs.\n")
+	writeRepoFile(t, dir, "assets/logo.png", "\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+	writeRepoFile(t, dir, "debug.log", "ignored by git\n")
+	first := commitRepo(t, dir)
+
+	cfg := *svc.cfg
+	cfg.Git = config.GitConfig{SkipDirs: []string{"vendor"}, MaxFileSize: 1024}
+	gitSvc := NewService(&ServiceParameters{
+		DB:       testDB,
+		Embedder: svc.embedder,
+		Chunker:  svc.chunker,
+		Cfg:      &cfg,
+	})
+
+	res, err := gitSvc.SyncGitRepository(ctx, &SyncGitRepositoryRequest{RepoPath: filepath.Join(dir, "docs")})
+	if err != nil {
+		t.Fatalf("failed to sync repository: %v", err)
+	}
+	// .gitignore, README.md and docs/setup.md, the binary file is skipped and
+	// the vendored one isn't listed
+	if res.Commit != first || res.Processed != 3 || res.Skipped != 1 || len(res.FailedDocuments) != 0 {
+		t.Fatalf("unexpected first sync result %+v", res)
+	}
+
+	setupName := filepath.Join(dir, "docs", "setup.md")
+	doc, err := db.GetDocumentByName(ctx, testDB, setupName)
+	if err != nil {
+		t.Fatalf("failed to get document: %v", err)
+	}
+	if doc.Metadata["repo"] != dir || doc.Metadata["commit"] != first || doc.Metadata["path"] != "docs/setup.md" {
+		t.Fatalf("expected repository, commit and path in metadata, got %v", doc.Metadata)
+	}
+	for _, name := range []string{"vendor/lib/README.md", "assets/logo.png", "debug.log"} {
+		if _, err := db.GetDocumentByName(ctx, testDB, filepath.Join(dir, name)); err == nil {
+			t.Fatalf("expected %s not to be indexed", name)
+		}
+	}
+
+	// Change one file and delete another
+	writeRepoFile(t, dir, "README.md", "# Monorepo\n\nThe deploy script lives in scripts.\n")
+	for _, name := range []string{".gitignore", "debug.log"} {
+		if err := os.Remove(filepath.Join(dir, name)); err != nil {
+			t.Fatalf("failed to remove file: %v", err)
+		}
+	}
+	second := commitRepo(t, dir)
+
+	res, err = gitSvc.SyncGitRepository(ctx, &SyncGitRepositoryRequest{RepoPath: dir, Ref: "main"})
+	if err != nil {
+		t.Fatalf("failed to resync repository: %v", err)
+	}
+	if res.Commit != second || res.Processed != 1 || res.Unchanged != 2 || res.Deleted != 1 || res.Skipped != 0 {
+		t.Fatalf("expected only the changed files to be synced, got %+v", res)
+	}
+
+	if _, err := db.GetDocumentByName(ctx, testDB, filepath.Join(dir, ".gitignore")); err == nil {
+		t.Fatalf("expected the deleted file's document to be removed")
+	}
+	// The unchanged document keeps its ID and is now at the new commit
+	unchanged, err := db.GetDocumentByName(ctx, testDB, setupName)
+	if err != nil {
+		t.Fatalf("failed to get document: %v", err)
+	}
+	if unchanged.ID != doc.ID || unchanged.Metadata["commit"] != second {
+		t.Fatalf("expected the unchanged document at the new commit, got %+v", unchanged)
+	}
+
+	// Syncing the indexed commit again does nothing
+	res, err = gitSvc.SyncGitRepository(ctx, &SyncGitRepositoryRequest{RepoPath: dir})
+	if err != nil {
+		t.Fatalf("failed to resync repository: %v", err)
+	}
+	if res.Processed != 0 || res.Deleted != 0 {
+		t.Fatalf("expected nothing to sync, got %+v", res)
+	}
+	// Submodules and symlinks aren't files to index, nor failures
+	if err := os.Symlink("README.md", filepath.Join(dir, "link.md")); err != nil {
+		t.Fatalf("failed to create symlink: %v", err)
+	}
+	runGit(t, dir, "add", "-A")
+	runGit(t, dir, "update-index", "--add", "--cacheinfo", "160000,"+first+",sub")
+	runGit(t, dir, "commit", "-q", "-m", "add submodule")
+	third := runGit(t, dir, "rev-parse", "HEAD")[:40]
+
+	res, err = gitSvc.SyncGitRepository(ctx, &SyncGitRepositoryRequest{RepoPath: dir})
+	if err != nil {
+		t.Fatalf("failed to resync repository: %v", err)
+	}
+	if res.Commit != third || res.Processed != 0 || len(res.FailedDocuments) != 0 {
+		t.Fatalf("expected the submodule and symlink to be left out, got %+v", res)
+	}
+	if _, err := db.GetDocumentByName(ctx, testDB, filepath.Join(dir, "link.md")); err == nil {
+		t.Fatalf("expected the symlink not to be indexed")
+	}
+	unchanged, err = db.GetDocumentByName(ctx, testDB, setupName)
+	if err != nil {
+		t.Fatalf("failed to get document: %v", err)
+	}
+	if unchanged.Metadata["commit"] != third {
+		t.Fatalf("expected the commit with the submodule to be indexed, got %v", unchanged.Metadata)
+	}
+}
@@ -0,0 +1,193 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"path/filepath"
+	"strings"
+
+	"github.com/MaxIvanyshen/local-rag/db"
+	"gorm.io/gorm"
+)
+
+type DocumentLinksRequest struct {
+	DocumentName string `json:"document_name"`
+}
+
+type DocumentLinksResponse struct {
+	// Links are the [[links]] and ![[embeds]] of the document. A link to a
+	// note that isn't indexed has no document name.
+	Links []DocumentLink `json:"links"`
+	// Backlinks are the documents that link to or embed the document.
+	Backlinks []DocumentLink `json:"backlinks"`
+}
+
+type DocumentLink struct {
+	// Target is the link as written, such as "Roadmap" for [[Roadmap]].
+	Target       string `json:"target,omitempty"`
+	DocumentName string `json:"document_name,omitempty"`
+	Embed        bool   `json:"embed,omitempty"`
+}
+
+// DocumentLinks returns the documents a document links to and the ones
+// linking to it, following the wiki links of Markdown notes. Backlinks are
+// returned for documents that aren't indexed yet too, as notes may link to
+// notes that don't exist.
+func (s *Service) DocumentLinks(ctx context.Context, req *DocumentLinksRequest) (*DocumentLinksResponse, error) {
+	res := &DocumentLinksResponse{
+		Links:     []DocumentLink{},
+		Backlinks: []DocumentLink{},
+	}
+
+	doc, err := db.GetDocumentByName(ctx, s.db, req.DocumentName)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	if doc != nil {
+		links := documentLinks(doc.Metadata)
+		targets := make([]string, 0, len(links))
+		for _, link := range links {
+			targets = append(targets, link.Target)
+		}
+		linked, err := s.resolveLinks(ctx, targets)
+		if err != nil {
+			return nil, err
+		}
+		for _, link := range links {
+			if d, ok := linked[link.Target]; ok {
+				link.DocumentName = d.Name
+			}
+			res.Links = append(res.Links, link)
+		}
+	}
+
+	linking, err := db.GetLinkingDocuments(ctx, s.db, linkTargets(req.DocumentName))
+	if err != nil {
+		return nil, err
+	}
+	for _, d := range linking {
+		if d.Name != req.DocumentName {
+			res.Backlinks = append(res.Backlinks, DocumentLink{DocumentName: d.Name})
+		}
+	}
+	return res, nil
+}
+
+// documentLinks returns the links recorded in the metadata of a document,
+// links before embeds.
+func documentLinks(metadata db.Metadata) []DocumentLink {
+	var links []DocumentLink
+	for _, key := range []string{"links", "embeds"} {
+		values, _ := metadata[key].([]any)
+		for _, value := range values {
+			if target, ok := value.(string); ok {
+				links = append(links, DocumentLink{Target: target, Embed: key == "embeds"})
+			}
+		}
+	}
+	return links
+}
+
+// resolveLinks returns the documents link targets refer to, by target.
+// Targets that refer to no document are left out. As in Obsidian, a target
+// without the .md extension names a note, and a target that is only part of
+// a path matches the end of a document name. Of several matches the one with
+// the shortest name wins.
+func (s *Service) resolveLinks(ctx context.Context, targets []string) (map[string]db.Document, error) {
+	paths := make([]string, 0, 2*len(targets))
+	for _, target := range targets {
+		paths = append(paths, target+".md", target)
+	}
+	docs, err := db.GetDocumentsByPaths(ctx, s.db, paths)
+	if err != nil {
+		return nil, err
+	}
+
+	linked := make(map[string]db.Document, len(targets))
+	for _, target := range targets {
+		for _, path := range []string{target + ".md", target} {
+			if doc, ok := docs[path]; ok {
+				linked[target] = doc
+				break
+			}
+		}
+	}
+	return linked, nil
+}
+
+// linkTargets returns the link targets that may refer to a document: the
+// trailing parts of its name, with and without the extension of a Markdown
+// note, so "vault/Projects/Alpha.md" is linked by [[Alpha]] and
+// [[Projects/Alpha]] among others.
+func linkTargets(name string) []string {
+	parts := strings.Split(filepath.ToSlash(name), "/")
+	var targets []string
+	for i := range parts {
+		path := strings.Join(parts[i:], "/")
+		if path == "" {
+			continue
+		}
+		targets = append(targets, path)
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".md", ".markdown":
+			targets = append(targets, strings.TrimSuffix(path, filepath.Ext(path)))
+		}
+	}
+	return targets
+}
+
+// boostLinkedResults lowers the distance of the results whose documents are
+// linked from the documents of the top hits, which are the first results.
+// Linked documents that weren't retrieved are added with their chunk closest
+// to the query, as long as they match the filters, so a boost can bring in a
+// note the search alone ranked too low.
+func (s *Service) boostLinkedResults(ctx context.Context, results []db.SearchResult, queryEmbedding []float32, filters map[string]any) ([]db.SearchResult, error) {
+	var targets []string
+	hits := make(map[string]bool)
+	for _, result := range results {
+		if len(hits) == s.cfg.Search.LinkBoostHits {
+			break
+		}
+		if hits[result.DocumentID] {
+			continue
+		}
+		hits[result.DocumentID] = true
+
+		for _, link := range documentLinks(result.DocumentMetadata) {
+			targets = append(targets, link.Target)
+		}
+	}
+	resolved, err := s.resolveLinks(ctx, targets)
+	if err != nil {
+		return nil, err
+	}
+
+	linked := make(map[string]bool, len(resolved))
+	for _, doc := range resolved {
+		linked[doc.ID] = true
+	}
+	retrieved := make(map[string]bool, len(results))
+	for _, result := range results {
+		retrieved[result.DocumentID] = true
+	}
+	var missing []string
+	for id := range linked {
+		if !retrieved[id] {
+			missing = append(missing, id)
+		}
+	}
+	added, err := db.SearchDocumentChunks(ctx, s.db, queryEmbedding, missing, filters)
+	if err != nil {
+		return nil, err
+	}
+	results = append(results, added...)
+
+	for i := range results {
+		if linked[results[i].DocumentID] {
+			slog.Debug("boosting linked search result", slog.String("document_name", results[i].DocumentName))
+			results[i].Distance *= 1 - s.cfg.Search.LinkBoost
+		}
+	}
+	return results, nil
+}
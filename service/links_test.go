@@ -0,0 +1,117 @@
+package service
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/MaxIvanyshen/local-rag/db"
+	"github.com/MaxIvanyshen/local-rag/extractor"
+)
+
+func TestDocumentLinks(t *testing.T) {
+	ctx := context.Background()
+
+	cfg := *svc.cfg
+	cfg.Search.LinkBoost = 0.5
+	cfg.Search.LinkBoostHits = 1
+	vaultSvc := NewService(&ServiceParameters{
+		DB:        testDB,
+		Embedder:  svc.embedder,
+		Chunker:   svc.chunker,
+		Extractor: extractor.NewRegistry(&extractor.MarkdownExtractor{}),
+		Cfg:       &cfg,
+	})
+
+	notes := map[string]string{
+		"vault/Projects/Alpha.md": "# Alpha\n\nShips after the [[Roadmap#Q3|Q3 roadmap]] review. ![[diagram.png]] #project\n\nOwner: [[People/Ana]].\n",
+		"vault/Roadmap.md":        "# Roadmap\n\nQ3 goals for [[Alpha]].\n",
+		"vault/Standup.md":        "# Standup\n\nTalked about [[roadmap]].\n",
+	}
+	for name, text := range notes {
+		if _, err := vaultSvc.ProcessDocument(ctx, &ProcessDocumentRequest{DocumentName: name, DocumentData: []byte(text)}); err != nil {
+			t.Fatalf("failed to process %s: %v", name, err)
+		}
+	}
+
+	res, err := vaultSvc.DocumentLinks(ctx, &DocumentLinksRequest{DocumentName: "vault/Projects/Alpha.md"})
+	if err != nil {
+		t.Fatalf("failed to get links: %v", err)
+	}
+	expectedLinks := []DocumentLink{
+		{Target: "Roadmap", DocumentName: "vault/Roadmap.md"},
+		// Links to notes that aren't indexed are returned without a document
+		{Target: "People/Ana"},
+		{Target: "diagram.png", Embed: true},
+	}
+	if !slices.Equal(res.Links, expectedLinks) {
+		t.Fatalf("expected links %v, got %v", expectedLinks, res.Links)
+	}
+	if !slices.Equal(res.Backlinks, []DocumentLink{{DocumentName: "vault/Roadmap.md"}}) {
+		t.Fatalf("unexpected backlinks %v", res.Backlinks)
+	}
+
+	// Links are matched ignoring case, and a note can be linked before it exists
+	res, err = vaultSvc.DocumentLinks(ctx, &DocumentLinksRequest{DocumentName: "vault/Roadmap.md"})
+	if err != nil {
+		t.Fatalf("failed to get links: %v", err)
+	}
+	if !slices.Equal(res.Backlinks, []DocumentLink{{DocumentName: "vault/Projects/Alpha.md"}, {DocumentName: "vault/Standup.md"}}) {
+		t.Fatalf("unexpected backlinks %v", res.Backlinks)
+	}
+	res, err = vaultSvc.DocumentLinks(ctx, &DocumentLinksRequest{DocumentName: "vault/People/Ana.md"})
+	if err != nil {
+		t.Fatalf("failed to get links: %v", err)
+	}
+	if len(res.Links) != 0 || !slices.Equal(res.Backlinks, []DocumentLink{{DocumentName: "vault/Projects/Alpha.md"}}) {
+		t.Fatalf("unexpected links of a missing note %+v", res)
+	}
+
+	// Documents linked from the top hit move up
+	alpha, err := db.GetDocumentByName(ctx, testDB, "vault/Projects/Alpha.md")
+	if err != nil {
+		t.Fatalf("failed to get document: %v", err)
+	}
+	roadmap, err := db.GetDocumentByName(ctx, testDB, "vault/Roadmap.md")
+	if err != nil {
+		t.Fatalf("failed to get document: %v", err)
+	}
+	results := []db.SearchResult{
+		{DocumentID: alpha.ID, DocumentMetadata: alpha.Metadata, Distance: 0.2},
+		{DocumentID: "other", Distance: 0.5},
+		{DocumentID: roadmap.ID, DocumentMetadata: roadmap.Metadata, Distance: 0.6},
+	}
+	query, err := svc.embedder.GenerateEmbedding(ctx, []byte("roadmap"))
+	if err != nil {
+		t.Fatalf("failed to generate embedding: %v", err)
+	}
+	results, err = vaultSvc.boostLinkedResults(ctx, results, query, nil)
+	if err != nil {
+		t.Fatalf("failed to boost results: %v", err)
+	}
+	// Only the links of the top hit count, so Alpha isn't boosted by Roadmap
+	if len(results) != 3 || results[0].Distance != 0.2 || results[1].Distance != 0.5 || results[2].Distance != 0.3 {
+		t.Fatalf("unexpected boosted distances %+v", results)
+	}
+
+	// Linked documents the search didn't retrieve are added with their
+	// closest chunk, unless the filters leave them out
+	results, err = vaultSvc.boostLinkedResults(ctx, []db.SearchResult{{DocumentID: alpha.ID, DocumentMetadata: alpha.Metadata, Distance: 0.2}}, query, nil)
+	if err != nil {
+		t.Fatalf("failed to boost results: %v", err)
+	}
+	if len(results) != 2 || results[1].DocumentName != "vault/Roadmap.md" || results[1].Content == "" {
+		t.Fatalf("expected the linked note to be added, got %+v", results)
+	}
+	results, err = vaultSvc.boostLinkedResults(ctx, []db.SearchResult{{DocumentID: alpha.ID, DocumentMetadata: alpha.Metadata, Distance: 0.2}}, query, map[string]any{"tags": "missing"})
+	if err != nil {
+		t.Fatalf("failed to boost results: %v", err)
+	}
+	if len(results) != 1 {
+		t.Fatalf("expected the filters to leave out the linked note, got %+v", results)
+	}
+
+	if _, err := vaultSvc.Search(ctx, &SearchRequest{Query: "roadmap", BoostLinks: true}); err != nil {
+		t.Fatalf("failed to search with link boost: %v", err)
+	}
+}
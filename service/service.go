@@ -2,42 +2,75 @@ package service
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"log/slog"
+	"maps"
+	"mime"
+	"slices"
 	"sync"
+	"time"
 
 	"github.com/MaxIvanyshen/local-rag/chunker"
 	"github.com/MaxIvanyshen/local-rag/config"
 	"github.com/MaxIvanyshen/local-rag/db"
 	"github.com/MaxIvanyshen/local-rag/embedding"
+	"github.com/MaxIvanyshen/local-rag/extractor"
+	"github.com/MaxIvanyshen/local-rag/junk"
+	"github.com/MaxIvanyshen/local-rag/source"
 	"gorm.io/gorm"
 )
 
 type Service struct {
-	db       *gorm.DB
-	embedder embedding.Embedder
-	chunker  chunker.Chunker
-	cfg      *config.Config
+	db            *gorm.DB
+	embedder      embedding.Embedder
+	chunker       chunker.Chunker
+	extractor     extractor.Extractor
+	contextHeader *chunker.ContextHeader
+	junk          *junk.Rules
+	sources       map[string]source.Source
+	cfg           *config.Config
 }
 
 type ServiceParameters struct {
 	DB       *gorm.DB
 	Embedder embedding.Embedder
 	Chunker  chunker.Chunker
-	Cfg      *config.Config
+	// Extractor is optional. When nil, document data is chunked as plain text.
+	Extractor extractor.Extractor
+	// ContextHeader is optional. When set, it is prepended to chunks before embedding.
+	ContextHeader *chunker.ContextHeader
+	// Junk is optional. When set, documents it matches are skipped instead
+	// of indexed.
+	Junk *junk.Rules
+	// Sources are the sources kept in sync by Sync, by name.
+	Sources map[string]source.Source
+	Cfg     *config.Config
 }
 
 func NewService(params *ServiceParameters) *Service {
 	return &Service{
-		db:       params.DB,
-		embedder: params.Embedder,
-		chunker:  params.Chunker,
-		cfg:      params.Cfg,
+		db:            params.DB,
+		embedder:      params.Embedder,
+		chunker:       params.Chunker,
+		extractor:     params.Extractor,
+		contextHeader: params.ContextHeader,
+		junk:          params.Junk,
+		sources:       params.Sources,
+		cfg:           params.Cfg,
 	}
 }
 
 type SearchRequest struct {
 	Query string `json:"query"`
+	// Filters restrict the search to documents whose metadata holds every
+	// key/value pair. A value also matches a list containing it, and a list
+	// of values matches when all of them do.
+	Filters map[string]any `json:"filters,omitempty"`
+	// BoostLinks ranks the documents linked from the top hits higher, as
+	// the notes a relevant note links to tend to be relevant too.
+	BoostLinks bool `json:"boost_links,omitempty"`
 }
 
 func (s *Service) Search(ctx context.Context, req *SearchRequest) ([]db.SearchResult, error) {
@@ -51,13 +84,13 @@ func (s *Service) Search(ctx context.Context, req *SearchRequest) ([]db.SearchRe
 	}
 
 	// Search chunks using the generated embedding
-	chunkResults, err := db.SearchChunks(ctx, s.db, queryEmbedding, s.cfg.Search.TopK)
+	chunkResults, err := db.SearchChunksWithFilters(ctx, s.db, queryEmbedding, s.cfg.Search.TopK, req.Filters)
 	if err != nil {
 		return nil, err
 	}
 
 	// Search document names using the same embedding
-	nameResults, err := db.SearchDocumentNames(ctx, s.db, queryEmbedding, s.cfg.Search.TopK)
+	nameResults, err := db.SearchDocumentNamesWithFilters(ctx, s.db, queryEmbedding, s.cfg.Search.TopK, req.Filters)
 	if err != nil {
 		return nil, err
 	}
@@ -81,10 +114,11 @@ func (s *Service) Search(ctx context.Context, req *SearchRequest) ([]db.SearchRe
 				continue
 			}
 			mergedResults = append(mergedResults, db.SearchResult{
-				DocumentID:   nameResult.DocumentID,
-				DocumentName: doc.Name,
-				Distance:     nameResult.Distance,
-				IsNameMatch:  true,
+				DocumentID:       nameResult.DocumentID,
+				DocumentName:     doc.Name,
+				DocumentMetadata: doc.Metadata,
+				Distance:         nameResult.Distance,
+				IsNameMatch:      true,
 				// Other fields left empty/zero for name-only matches
 			})
 		}
@@ -93,6 +127,14 @@ func (s *Service) Search(ctx context.Context, req *SearchRequest) ([]db.SearchRe
 	// Sort merged results by distance
 	sortResultsByDistance(mergedResults)
 
+	if req.BoostLinks && s.cfg.Search.LinkBoost > 0 {
+		mergedResults, err = s.boostLinkedResults(ctx, mergedResults, queryEmbedding, req.Filters)
+		if err != nil {
+			return nil, err
+		}
+		sortResultsByDistance(mergedResults)
+	}
+
 	// Truncate to TopK
 	if len(mergedResults) > s.cfg.Search.TopK {
 		mergedResults = mergedResults[:s.cfg.Search.TopK]
@@ -115,6 +157,43 @@ func sortResultsByDistance(results []db.SearchResult) {
 type ProcessDocumentRequest struct {
 	DocumentName string `json:"document_name"`
 	DocumentData []byte `json:"document_data"`
+	// Metadata is optional. It is added to the metadata of every document
+	// extracted from the file.
+	Metadata map[string]any `json:"metadata,omitempty"`
+	// ModTime is the optional modification time of the file, stored with
+	// its documents.
+	ModTime *time.Time `json:"mod_time,omitempty"`
+	// Force processes the document even when its content is unchanged, for
+	// example after changing the chunker settings.
+	Force bool `json:"force,omitempty"`
+	// ContentType is the optional media type of the data. It selects the
+	// extractor when the name doesn't tell the format, such as for a web
+	// page named by its URL.
+	ContentType string `json:"content_type,omitempty"`
+}
+
+const (
+	StatusProcessed = "processed"
+	// StatusUnchanged means the document was indexed with the same content
+	// before and was left as it is.
+	StatusUnchanged = "unchanged"
+	// StatusSkipped means the document is junk, such as a binary or
+	// generated file, and wasn't indexed.
+	StatusSkipped = "skipped"
+)
+
+type ProcessDocumentResponse struct {
+	Success bool   `json:"success"`
+	Status  string `json:"status,omitempty"`
+	// Reason tells why a skipped document is junk, such as
+	// "lockfile: yarn.lock".
+	Reason string `json:"reason,omitempty"`
+	// Warnings tell about problems reading the document that didn't stop
+	// it from being indexed, such as an unknown encoding.
+	Warnings []string `json:"warnings,omitempty"`
+	// SkippedDocuments are the junk documents split out of the file, such
+	// as a lockfile in an archive, that weren't indexed.
+	SkippedDocuments []SkippedDocument `json:"skipped_documents,omitempty"`
 }
 
 type DeleteDocumentRequest struct {
@@ -129,58 +208,591 @@ func Success(s bool) *SuccessResponse {
 	return &SuccessResponse{Success: s}
 }
 
-func (s *Service) ProcessDocument(ctx context.Context, req *ProcessDocumentRequest) (*SuccessResponse, error) {
+// ProcessDocument extracts, chunks and embeds a document, replacing its
+// previous version. A document whose content hash matches the indexed one is
+// left as it is, unless the request forces processing. Changes to the
+// request metadata alone don't count as a change. Junk documents are skipped,
+// and a previously indexed version of them is deleted.
+func (s *Service) ProcessDocument(ctx context.Context, req *ProcessDocumentRequest) (*ProcessDocumentResponse, error) {
 	slog.Info("received process document request", slog.String("document_name", req.DocumentName))
 
-	// Delete existing document with this name if it exists
-	err := db.DeleteDocumentByName(ctx, s.db, req.DocumentName)
-	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
-		slog.Error("failed to delete existing document", slog.String("error", err.Error()), slog.String("document_name", req.DocumentName))
-		return Success(false), err
+	if reason := s.junkReason(req.DocumentName, req.ContentType, req.DocumentData); reason != "" {
+		return s.skipDocument(ctx, req.DocumentName, reason)
+	}
+
+	hash := ContentHash(req.DocumentData)
+	if !req.Force {
+		unchanged, err := s.unchanged(ctx, req, hash)
+		if err != nil {
+			slog.Error("failed to look up indexed document", slog.String("error", err.Error()), slog.String("document_name", req.DocumentName))
+			return &ProcessDocumentResponse{Success: false}, err
+		}
+		if unchanged {
+			slog.Info("document is unchanged, skipping", slog.String("document_name", req.DocumentName))
+			return &ProcessDocumentResponse{Success: true, Status: StatusUnchanged}, nil
+		}
 	}
+	return s.processDocument(ctx, req, hash)
+}
 
-	// Save document to the database
-	documentID, err := db.SaveDocument(ctx, s.db, req.DocumentName)
+// processDocument extracts and stores a document whose content hash was
+// checked.
+func (s *Service) processDocument(ctx context.Context, req *ProcessDocumentRequest, hash string) (*ProcessDocumentResponse, error) {
+	// Extract the text before touching the stored document, so a file that
+	// can't be read doesn't remove its previous version
+	extracted, err := s.extract(s.formatName(req.DocumentName, req.ContentType, req.DocumentData), req.DocumentData)
 	if err != nil {
-		slog.Error("failed to save document", slog.String("error", err.Error()), slog.String("document_name", req.DocumentName))
-		return Success(false), err
+		slog.Error("failed to extract document text", slog.String("error", err.Error()), slog.String("document_name", req.DocumentName))
+		return &ProcessDocumentResponse{Success: false}, err
 	}
 
-	// Generate and save document name embedding
-	nameEmbedding, err := s.embedder.GenerateEmbedding(ctx, []byte(req.DocumentName))
+	saved := make(map[string]bool, len(extracted))
+	var warnings []string
+	var skipped []SkippedDocument
+	textFormat := contentTypeFormat(req.ContentType)
+	for _, doc := range extracted {
+		// Leaving a junk document out of saved deletes its previous version
+		if reason := s.splitJunkReason(doc); reason != "" {
+			slog.Info("skipping junk document", slog.String("document_name", doc.Name), slog.String("reason", reason))
+			skipped = append(skipped, SkippedDocument{DocumentName: doc.Name, Reason: reason})
+			continue
+		}
+		warnings = append(warnings, doc.Warnings...)
+		for i := range doc.Sections {
+			if doc.Sections[i].Format == "" {
+				doc.Sections[i].Format = textFormat
+			}
+		}
+		if len(req.Metadata) > 0 {
+			if doc.Metadata == nil {
+				doc.Metadata = make(map[string]any, len(req.Metadata))
+			}
+			maps.Copy(doc.Metadata, req.Metadata)
+		}
+		if err := s.saveDocument(ctx, req, hash, int64(len(req.DocumentData)), doc, extractedSections(doc)); err != nil {
+			return &ProcessDocumentResponse{Success: false}, err
+		}
+		saved[doc.Name] = true
+	}
+
+	if err := s.deleteRemovedDocuments(ctx, req.DocumentName, saved); err != nil {
+		return &ProcessDocumentResponse{Success: false}, err
+	}
+
+	slog.Info("successfully processed document", slog.String("document_name", req.DocumentName), slog.Int("documents", len(extracted)))
+
+	return &ProcessDocumentResponse{Success: true, Status: StatusProcessed, Warnings: warnings, SkippedDocuments: skipped}, nil
+}
+
+// junkReason returns why a document is junk, or an empty string. Documents in
+// formats an extractor handles are only judged by their name, as their data
+// isn't text.
+func (s *Service) junkReason(name, contentType string, data []byte) string {
+	if s.junk == nil {
+		return ""
+	}
+	if s.supportsFormat(s.formatName(name, contentType, data), data) {
+		return s.junk.CheckName(name)
+	}
+	return s.junk.Check(name, data)
+}
+
+// splitJunkReason returns why a document split out of a file, such as an
+// archive entry or a mailbox message, is junk, or an empty string. Its
+// extracted text stands in for its data, which is why documents in any
+// format get the full check.
+func (s *Service) splitJunkReason(doc *extractor.Document) string {
+	if s.junk == nil || doc.Name == "" {
+		return ""
+	}
+	var text []byte
+	for _, section := range doc.Sections {
+		if len(text) >= junk.SampleSize {
+			break
+		}
+		text = append(text, section.Text...)
+	}
+	return s.junk.Check(doc.Name, text)
+}
+
+// skipDocument reports a junk document as skipped, deleting the version of it
+// indexed before.
+func (s *Service) skipDocument(ctx context.Context, name, reason string) (*ProcessDocumentResponse, error) {
+	slog.Info("skipping junk document", slog.String("document_name", name), slog.String("reason", reason))
+	if _, err := s.DeleteDocument(ctx, &DeleteDocumentRequest{DocumentName: name}); err != nil {
+		return &ProcessDocumentResponse{Success: false}, err
+	}
+	return &ProcessDocumentResponse{Success: true, Status: StatusSkipped, Reason: reason}, nil
+}
+
+// deleteRemovedDocuments deletes the documents split out of the previous
+// version of a file that it no longer has.
+func (s *Service) deleteRemovedDocuments(ctx context.Context, name string, saved map[string]bool) error {
+	previous, err := db.GetDocumentsBySource(ctx, s.db, name)
 	if err != nil {
-		slog.Error("failed to generate embedding for document name", slog.String("error", err.Error()), slog.String("document_name", req.DocumentName))
-		return Success(false), err
+		slog.Error("failed to get documents of previous version", slog.String("error", err.Error()), slog.String("document_name", name))
+		return err
+	}
+	for _, doc := range previous {
+		if saved[doc.Name] {
+			continue
+		}
+		if err := db.DeleteDocument(ctx, s.db, doc.ID); err != nil {
+			slog.Error("failed to delete document of previous version", slog.String("error", err.Error()), slog.String("document_name", doc.Name))
+			return err
+		}
 	}
+	return nil
+}
 
-	err = db.SaveDocumentNameEmbedding(ctx, s.db, documentID, nameEmbedding)
+// unchanged reports whether the file was indexed with the same content
+// hash, updating the stored modification time when it was.
+func (s *Service) unchanged(ctx context.Context, req *ProcessDocumentRequest, hash string) (bool, error) {
+	hashes, err := db.GetDocumentHashes(ctx, s.db, []string{req.DocumentName})
 	if err != nil {
-		slog.Error("failed to save document name embedding", slog.String("error", err.Error()), slog.String("document_name", req.DocumentName))
-		return Success(false), err
+		return false, err
+	}
+	if hashes[req.DocumentName] != hash {
+		return false, nil
+	}
+	if req.ModTime != nil {
+		if err := db.SetDocumentModTime(ctx, s.db, req.DocumentName, *req.ModTime); err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// ContentHash returns the hex SHA-256 of document data, as stored with its
+// documents and expected by CheckDocuments.
+func ContentHash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// sectionSaver chunks and stores a section of a document. lineOffset and
+// byteOffset place the section text within the document when it is a window
+// of a larger text, and are zero for the sections of extracted documents. A
+// window continues the text of the section saved before it, and the headings
+// in effect at its start.
+type sectionSaver func(section extractor.Section, lineOffset, byteOffset int) error
+
+// extractedSections feeds the sections of an extracted document to a
+// sectionSaver.
+func extractedSections(extracted *extractor.Document) func(sectionSaver) error {
+	return func(save sectionSaver) error {
+		for _, section := range extracted.Sections {
+			if err := save(section, 0, 0); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// saveDocument stores an extracted document and the chunks of the sections
+// fed to it. A document with the same name is updated in place: chunks it
+// already has keep their embeddings, new chunks are embedded and the ones
+// that are gone are deleted, so the document stays searchable throughout.
+// Documents split out of the uploaded file are stored under their own name
+// with the file as their source.
+func (s *Service) saveDocument(ctx context.Context, req *ProcessDocumentRequest, hash string, size int64, extracted *extractor.Document, sections func(sectionSaver) error) error {
+	name, source := req.DocumentName, ""
+	if extracted.Name != "" {
+		name, source = extracted.Name, req.DocumentName
 	}
 
-	// Chunk the document
-	chunkResults := s.chunker.Chunk(req.DocumentData)
+	// The content hash is recorded once all chunks are saved, so a document
+	// that failed midway isn't taken as unchanged
+	document := &db.Document{
+		Name:     name,
+		Metadata: documentMetadata(extracted),
+		Source:   source,
+		ModTime:  req.ModTime,
+		Size:     size,
+	}
 
-	for i, chunkResult := range chunkResults {
-		// Generate embedding for the chunk
-		embedding, err := s.embedder.GenerateEmbedding(ctx, chunkResult.Data)
+	var stored []db.Chunk
+	existing, err := db.GetDocumentByName(ctx, s.db, name)
+	switch {
+	case err == nil:
+		document.ID = existing.ID
+		if err := db.UpdateDocument(ctx, s.db, document); err != nil {
+			slog.Error("failed to update document", slog.String("error", err.Error()), slog.String("document_name", name))
+			return err
+		}
+		stored, err = db.GetChunksByDocumentID(ctx, s.db, document.ID)
 		if err != nil {
-			slog.Error("failed to generate embedding for chunk", slog.String("error", err.Error()), slog.String("document_name", req.DocumentName), slog.Int("chunk_index", i))
-			return Success(false), err
+			slog.Error("failed to get chunks of document", slog.String("error", err.Error()), slog.String("document_name", name))
+			return err
 		}
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		if err := s.createDocument(ctx, document); err != nil {
+			return err
+		}
+	default:
+		slog.Error("failed to get existing document", slog.String("error", err.Error()), slog.String("document_name", name))
+		return err
+	}
 
-		// Save chunk and its embedding to the database
-		err = db.SaveChunk(ctx, s.db, documentID, i, chunkResult.StartLine, chunkResult.EndLine, chunkResult.Data, embedding)
+	// Chunk every section of the document
+	doc := newProcessedDocument(document.ID, name, extracted, stored)
+	chunkIndex := 0
+	var headings *chunker.HeadingIndex
+	err = sections(func(section extractor.Section, lineOffset, byteOffset int) error {
+		// Chunk the normalized text, but keep positions in the extracted one
+		var offsets *extractor.OffsetMap
+		section.Text, offsets = extractor.NormalizeTextOffsets(section.Text)
+		var chunkResults []chunker.ChunkResult
+		if formatChunker, ok := s.chunker.(chunker.FormatChunker); ok {
+			chunkResults = formatChunker.ChunkFormat(section.Text, section.Format)
+		} else {
+			chunkResults = s.chunker.Chunk(section.Text)
+		}
+		for i := range chunkResults {
+			placeChunk(&chunkResults[i], offsets, lineOffset+section.LineOffset, byteOffset+section.ByteOffset)
+		}
+		if headings == nil || (lineOffset == 0 && byteOffset == 0) {
+			headings = &chunker.HeadingIndex{}
+		}
+		headings.Add(section.Text, lineOffset)
+		processed := newProcessedSection(section, headings)
+		var err error
+		chunkIndex, err = s.saveChunks(ctx, doc, processed, chunkIndex, chunkResults)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	// Chunks left over from the previous version are gone from the document
+	removed := doc.leftoverChunks()
+	if err := db.DeleteChunks(ctx, s.db, removed); err != nil {
+		slog.Error("failed to delete removed chunks", slog.String("error", err.Error()), slog.String("document_name", name))
+		return err
+	}
+
+	// Streamed documents gather metadata as their text is read, such as the
+	// links of a note
+	document.Metadata = documentMetadata(extracted)
+	document.ContentHash = hash
+	if err := db.UpdateDocument(ctx, s.db, document); err != nil {
+		slog.Error("failed to update document", slog.String("error", err.Error()), slog.String("document_name", name))
+		return err
+	}
+
+	slog.Debug("saved document chunks", slog.String("document_name", name), slog.Int("kept", doc.kept), slog.Int("embedded", doc.embedded), slog.Int("deleted", len(removed)))
+	return nil
+}
+
+// createDocument saves a new document with the embedding of its name.
+func (s *Service) createDocument(ctx context.Context, document *db.Document) error {
+	if err := db.SaveDocument(ctx, s.db, document); err != nil {
+		slog.Error("failed to save document", slog.String("error", err.Error()), slog.String("document_name", document.Name))
+		return err
+	}
+
+	// Generate and save document name embedding
+	nameEmbedding, err := s.embedder.GenerateEmbedding(ctx, []byte(document.Name))
+	if err != nil {
+		slog.Error("failed to generate embedding for document name", slog.String("error", err.Error()), slog.String("document_name", document.Name))
+		return err
+	}
+
+	err = db.SaveDocumentNameEmbedding(ctx, s.db, document.ID, nameEmbedding)
+	if err != nil {
+		slog.Error("failed to save document name embedding", slog.String("error", err.Error()), slog.String("document_name", document.Name))
+		return err
+	}
+	return nil
+}
+
+// extract turns the raw document data into the documents it holds, usually
+// a single one.
+func (s *Service) extract(name string, data []byte) ([]*extractor.Document, error) {
+	var docs []*extractor.Document
+	switch e := s.extractor.(type) {
+	case nil:
+		docs = []*extractor.Document{extractor.PlainText(name, data)}
+	case extractor.MultiExtractor:
+		var err error
+		docs, err = e.ExtractAll(name, data)
 		if err != nil {
-			slog.Error("failed to save chunk", slog.String("error", err.Error()), slog.String("document_name", req.DocumentName), slog.Int("chunk_index", i))
-			return Success(false), err
+			return nil, err
 		}
+	default:
+		doc, err := e.Extract(name, data)
+		if err != nil {
+			return nil, err
+		}
+		docs = []*extractor.Document{doc}
 	}
 
-	slog.Info("successfully processed document", slog.String("document_name", req.DocumentName))
+	return docs, nil
+}
 
-	return Success(true), nil
+// formatName returns the name the extractor matches a document by. When the
+// document name doesn't select a format, the extension of its content type
+// is appended, so a web page at /docs/setup is extracted as HTML.
+func (s *Service) formatName(name, contentType string, data []byte) string {
+	if contentType == "" || s.supportsFormat(name, data) {
+		return name
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return name
+	}
+	exts, _ := mime.ExtensionsByType(mediaType)
+	for _, ext := range exts {
+		if s.supportsFormat(name+ext, data) {
+			return name + ext
+		}
+	}
+	return name
+}
+
+// contentTypeFormat returns the chunker format of text sent with a content
+// type, for text whose name doesn't tell its format.
+func contentTypeFormat(contentType string) string {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return ""
+	}
+	switch mediaType {
+	case "text/csv":
+		return chunker.FormatCSV
+	case "text/tab-separated-values":
+		return chunker.FormatTSV
+	case "text/markdown":
+		return chunker.FormatMarkdown
+	}
+	return ""
+}
+
+// documentMetadata returns the metadata stored with an extracted document,
+// including its title when the format records one.
+func documentMetadata(extracted *extractor.Document) db.Metadata {
+	metadata := make(db.Metadata, len(extracted.Metadata)+1)
+	maps.Copy(metadata, extracted.Metadata)
+	if extracted.Title != "" {
+		metadata["title"] = extracted.Title
+	}
+	return metadata
+}
+
+// processedDocument is the document a chunk being saved belongs to.
+type processedDocument struct {
+	ID    string
+	Name  string
+	Title string
+
+	// stored are the chunks of the previous version of the document by
+	// content hash. They are taken as new chunks match them.
+	stored   map[string][]db.Chunk
+	kept     int
+	embedded int
+}
+
+func newProcessedDocument(id, name string, extracted *extractor.Document, stored []db.Chunk) *processedDocument {
+	// Prefer the title recorded by the format, then the first top-level heading
+	title := extracted.Title
+	for _, section := range extracted.Sections {
+		if title != "" {
+			break
+		}
+		title = chunker.NewHeadingIndex(extractor.NormalizeText(section.Text)).Title()
+	}
+	if title == "" {
+		title = name
+	}
+	doc := &processedDocument{
+		ID:     id,
+		Name:   name,
+		Title:  title,
+		stored: make(map[string][]db.Chunk),
+	}
+	for _, chunk := range stored {
+		doc.stored[chunk.ContentHash] = append(doc.stored[chunk.ContentHash], chunk)
+	}
+	return doc
+}
+
+// takeChunk returns a stored chunk with the content hash and removes it from
+// the leftovers. parent tells whether a parent chunk, which has no embedding
+// of its own, is wanted.
+func (d *processedDocument) takeChunk(hash string, parent bool) (db.Chunk, bool) {
+	// Chunks stored before hashes were recorded have none and are replaced
+	if hash == "" {
+		return db.Chunk{}, false
+	}
+	chunks := d.stored[hash]
+	for i, chunk := range chunks {
+		if (chunk.EmbeddingRowID == 0) == parent {
+			d.stored[hash] = slices.Delete(chunks, i, i+1)
+			return chunk, true
+		}
+	}
+	return db.Chunk{}, false
+}
+
+// leftoverChunks returns the IDs of the stored chunks no new chunk matched.
+func (d *processedDocument) leftoverChunks() []string {
+	var ids []string
+	for _, chunks := range d.stored {
+		for _, chunk := range chunks {
+			ids = append(ids, chunk.ID)
+		}
+	}
+	return ids
+}
+
+// processedSection is the section of a document a chunk being saved was cut from.
+type processedSection struct {
+	Title    string
+	Page     int
+	Metadata db.Metadata
+	// headings index the section text, numbering its lines from lineOffset,
+	// the number of lines of the file before it.
+	headings   *chunker.HeadingIndex
+	lineOffset int
+}
+
+func newProcessedSection(section extractor.Section, headings *chunker.HeadingIndex) *processedSection {
+	return &processedSection{
+		Title:      section.Title,
+		Page:       section.Page,
+		Metadata:   section.Metadata,
+		headings:   headings,
+		lineOffset: section.LineOffset,
+	}
+}
+
+// headingPath returns the headings in effect at the given line, led by the
+// section title unless the text repeats it as its first heading.
+func (s *processedSection) headingPath(line int) []string {
+	path := s.headings.Path(line - s.lineOffset)
+	if s.Title == "" || (len(path) > 0 && path[0] == s.Title) {
+		return path
+	}
+	return append([]string{s.Title}, path...)
+}
+
+// placeChunk moves a chunk cut from normalized text back to the byte offsets
+// of the extracted text, and from a window of a text to its place in the
+// whole text. Windows start on a new line, so columns stay the same.
+func placeChunk(c *chunker.ChunkResult, offsets *extractor.OffsetMap, lines, bytes int) {
+	c.StartLine += lines
+	c.EndLine += lines
+	c.StartByte = offsets.Original(c.StartByte) + bytes
+	c.EndByte = offsets.Original(c.EndByte) + bytes
+	for i := range c.Children {
+		placeChunk(&c.Children[i], offsets, lines, bytes)
+	}
+}
+
+// saveChunks embeds and stores the chunks of a document section, numbering
+// them from chunkIndex, and returns the index for the next chunk. Chunks with
+// children are stored without an embedding and their children are embedded instead.
+func (s *Service) saveChunks(ctx context.Context, doc *processedDocument, section *processedSection, chunkIndex int, chunkResults []chunker.ChunkResult) (int, error) {
+	for _, chunkResult := range chunkResults {
+		if len(chunkResult.Children) == 0 {
+			if err := s.saveChunk(ctx, doc, section, "", chunkIndex, chunkResult); err != nil {
+				return chunkIndex, err
+			}
+			chunkIndex++
+			continue
+		}
+
+		parent := newChunk(doc.ID, section, chunkIndex, chunkResult)
+		parent.ContentHash = ContentHash(parent.Data)
+		if stored, ok := doc.takeChunk(parent.ContentHash, true); ok {
+			parent.ID = stored.ID
+			if err := db.UpdateChunk(ctx, s.db, parent); err != nil {
+				slog.Error("failed to update parent chunk", slog.String("error", err.Error()), slog.String("document_name", doc.Name), slog.Int("chunk_index", chunkIndex))
+				return chunkIndex, err
+			}
+		} else if err := db.SaveParentChunk(ctx, s.db, parent); err != nil {
+			slog.Error("failed to save parent chunk", slog.String("error", err.Error()), slog.String("document_name", doc.Name), slog.Int("chunk_index", chunkIndex))
+			return chunkIndex, err
+		}
+		chunkIndex++
+
+		for _, child := range chunkResult.Children {
+			if err := s.saveChunk(ctx, doc, section, parent.ID, chunkIndex, child); err != nil {
+				return chunkIndex, err
+			}
+			chunkIndex++
+		}
+	}
+	return chunkIndex, nil
+}
+
+// saveChunk embeds a single chunk and stores it, under parentID if it is not
+// empty. A matching chunk of the previous version is moved instead.
+func (s *Service) saveChunk(ctx context.Context, doc *processedDocument, section *processedSection, parentID string, chunkIndex int, chunkResult chunker.ChunkResult) error {
+	// Prepend the context header to what gets embedded, the stored data stays clean
+	embeddingInput := chunkResult.Data
+	if s.contextHeader != nil {
+		var err error
+		embeddingInput, err = s.contextHeader.Prepend(chunker.ContextHeaderData{
+			DocumentName: doc.Name,
+			Title:        doc.Title,
+			Headings:     section.headingPath(chunkResult.StartLine),
+		}, chunkResult.Data)
+		if err != nil {
+			slog.Error("failed to render context header", slog.String("error", err.Error()), slog.String("document_name", doc.Name), slog.Int("chunk_index", chunkIndex))
+			return err
+		}
+	}
+
+	chunk := newChunk(doc.ID, section, chunkIndex, chunkResult)
+	chunk.ContentHash = ContentHash(embeddingInput)
+	if parentID != "" {
+		chunk.ParentID = &parentID
+	}
+
+	// A chunk the previous version had keeps its embedding and only moves
+	if stored, ok := doc.takeChunk(chunk.ContentHash, false); ok {
+		chunk.ID = stored.ID
+		if err := db.UpdateChunk(ctx, s.db, chunk); err != nil {
+			slog.Error("failed to update chunk", slog.String("error", err.Error()), slog.String("document_name", doc.Name), slog.Int("chunk_index", chunkIndex))
+			return err
+		}
+		doc.kept++
+		return nil
+	}
+
+	// Generate embedding for the chunk
+	embedding, err := s.embedder.GenerateEmbedding(ctx, embeddingInput)
+	if err != nil {
+		slog.Error("failed to generate embedding for chunk", slog.String("error", err.Error()), slog.String("document_name", doc.Name), slog.Int("chunk_index", chunkIndex))
+		return err
+	}
+
+	// Save chunk and its embedding to the database
+	if err := db.SaveChunk(ctx, s.db, chunk, embedding); err != nil {
+		slog.Error("failed to save chunk", slog.String("error", err.Error()), slog.String("document_name", doc.Name), slog.Int("chunk_index", chunkIndex))
+		return err
+	}
+	doc.embedded++
+	return nil
+}
+
+// newChunk converts a chunker result into the chunk row stored for it.
+func newChunk(documentID string, section *processedSection, chunkIndex int, chunkResult chunker.ChunkResult) *db.Chunk {
+	return &db.Chunk{
+		DocumentID:  documentID,
+		ChunkIndex:  chunkIndex,
+		Data:        chunkResult.Data,
+		StartLine:   chunkResult.StartLine,
+		EndLine:     chunkResult.EndLine,
+		StartByte:   chunkResult.StartByte,
+		EndByte:     chunkResult.EndByte,
+		StartColumn: chunkResult.StartColumn,
+		EndColumn:   chunkResult.EndColumn,
+		Page:        section.Page,
+		Metadata:    section.Metadata,
+	}
 }
 
 func (s *Service) DeleteDocument(ctx context.Context, req *DeleteDocumentRequest) (*SuccessResponse, error) {
@@ -189,6 +801,11 @@ func (s *Service) DeleteDocument(ctx context.Context, req *DeleteDocumentRequest
 		slog.Error("failed to delete document", slog.String("error", err.Error()), slog.String("document_name", req.DocumentName))
 		return Success(false), err
 	}
+	// Also delete the documents split out of the file
+	if err := db.DeleteDocumentsBySource(ctx, s.db, req.DocumentName); err != nil {
+		slog.Error("failed to delete documents of file", slog.String("error", err.Error()), slog.String("document_name", req.DocumentName))
+		return Success(false), err
+	}
 	return Success(true), nil
 }
 
@@ -197,14 +814,32 @@ type BatchProcessDocumentsRequest struct {
 }
 
 type BatchProcessResponse struct {
-	FailedDocuments []string `json:"failed_documents"` // Names of documents that failed to process
+	FailedDocuments    []string          `json:"failed_documents"`          // Names of documents that failed to process
+	UnchangedDocuments []string          `json:"unchanged_documents"`       // Names of documents skipped because their content is unchanged
+	SkippedDocuments   []SkippedDocument `json:"skipped_documents"`         // Junk documents that weren't indexed
+	SkippedEntries     []SkippedDocument `json:"skipped_entries,omitempty"` // Junk documents split out of files, such as archive entries, that weren't indexed
+	Warnings           []DocumentWarning `json:"warnings,omitempty"`        // Problems reading documents that were indexed anyway
+}
+
+// DocumentWarning is a problem reading a document that was indexed anyway.
+type DocumentWarning struct {
+	DocumentName string `json:"document_name"`
+	Warning      string `json:"warning"`
+}
+
+// SkippedDocument is a junk document and why it wasn't indexed.
+type SkippedDocument struct {
+	DocumentName string `json:"document_name"`
+	Reason       string `json:"reason"`
 }
 
 func (s *Service) BatchProcessDocuments(ctx context.Context, req *BatchProcessDocumentsRequest) (*BatchProcessResponse, error) {
 	if len(req.Documents) == 0 {
 		slog.Info("nothing to process in batch request. Returning....")
 		return &BatchProcessResponse{
-			FailedDocuments: []string{},
+			FailedDocuments:    []string{},
+			UnchangedDocuments: []string{},
+			SkippedDocuments:   []SkippedDocument{},
 		}, nil
 	}
 
@@ -215,6 +850,10 @@ func (s *Service) BatchProcessDocuments(ctx context.Context, req *BatchProcessDo
 	wg.Add(s.cfg.BatchProcessing.WorkerCount)
 
 	failed := make(chan string, len(req.Documents))
+	unchangedDocuments := make([]string, 0)
+	skippedDocuments := make([]SkippedDocument, 0)
+	var skippedEntries []SkippedDocument
+	var warnings []DocumentWarning
 
 	for range s.cfg.BatchProcessing.WorkerCount {
 		go func() {
@@ -228,6 +867,26 @@ func (s *Service) BatchProcessDocuments(ctx context.Context, req *BatchProcessDo
 				if !s.Success {
 					slog.Error("processing document in batch was not successful", slog.String("document_name", req.DocumentName))
 					failed <- req.DocumentName
+				} else if s.Status == StatusUnchanged {
+					mu.Lock()
+					unchangedDocuments = append(unchangedDocuments, req.DocumentName)
+					mu.Unlock()
+				} else if s.Status == StatusSkipped {
+					mu.Lock()
+					skippedDocuments = append(skippedDocuments, SkippedDocument{DocumentName: req.DocumentName, Reason: s.Reason})
+					mu.Unlock()
+				}
+				if len(s.SkippedDocuments) > 0 {
+					mu.Lock()
+					skippedEntries = append(skippedEntries, s.SkippedDocuments...)
+					mu.Unlock()
+				}
+				if len(s.Warnings) > 0 {
+					mu.Lock()
+					for _, warning := range s.Warnings {
+						warnings = append(warnings, DocumentWarning{DocumentName: req.DocumentName, Warning: warning})
+					}
+					mu.Unlock()
 				}
 			}
 		}()
@@ -253,9 +912,53 @@ func (s *Service) BatchProcessDocuments(ctx context.Context, req *BatchProcessDo
 	close(failed)
 	<-collectorDone
 
-	slog.Info("batch processing completed", slog.Int("total_documents", len(req.Documents)), slog.Int("failed_documents", len(failedDocuments)))
+	slog.Info("batch processing completed", slog.Int("total_documents", len(req.Documents)), slog.Int("failed_documents", len(failedDocuments)), slog.Int("unchanged_documents", len(unchangedDocuments)), slog.Int("skipped_documents", len(skippedDocuments)))
 
 	return &BatchProcessResponse{
-		FailedDocuments: failedDocuments,
+		FailedDocuments:    failedDocuments,
+		UnchangedDocuments: unchangedDocuments,
+		SkippedDocuments:   skippedDocuments,
+		SkippedEntries:     skippedEntries,
+		Warnings:           warnings,
 	}, nil
 }
+
+type CheckDocumentsRequest struct {
+	// Documents maps document names to the hex SHA-256 of their data, see
+	// ContentHash.
+	Documents map[string]string `json:"documents"`
+}
+
+type CheckDocumentsResponse struct {
+	// Changed are the documents that aren't indexed or whose content
+	// differs, and need to be uploaded.
+	Changed []string `json:"changed"`
+	// Unchanged are the documents indexed with the same content.
+	Unchanged []string `json:"unchanged"`
+}
+
+// CheckDocuments compares a manifest of content hashes with the indexed
+// documents, so clients only upload the files that changed.
+func (s *Service) CheckDocuments(ctx context.Context, req *CheckDocumentsRequest) (*CheckDocumentsResponse, error) {
+	names := slices.Sorted(maps.Keys(req.Documents))
+	hashes, err := db.GetDocumentHashes(ctx, s.db, names)
+	if err != nil {
+		slog.Error("failed to look up indexed documents", slog.String("error", err.Error()))
+		return nil, err
+	}
+
+	res := &CheckDocumentsResponse{
+		Changed:   []string{},
+		Unchanged: []string{},
+	}
+	for _, name := range names {
+		if hash, ok := hashes[name]; ok && hash != "" && hash == req.Documents[name] {
+			res.Unchanged = append(res.Unchanged, name)
+		} else {
+			res.Changed = append(res.Changed, name)
+		}
+	}
+
+	slog.Info("checked documents", slog.Int("total_documents", len(names)), slog.Int("changed_documents", len(res.Changed)))
+	return res, nil
+}
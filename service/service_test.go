@@ -1,41 +1,26 @@
 package service
 
 import (
+	"archive/zip"
+	"bytes"
 	"context"
 	"errors"
-	"fmt"
 	"os"
+	"slices"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/MaxIvanyshen/local-rag/chunker"
 	"github.com/MaxIvanyshen/local-rag/config"
 	"github.com/MaxIvanyshen/local-rag/db"
 	"github.com/MaxIvanyshen/local-rag/embedding"
+	"github.com/MaxIvanyshen/local-rag/extractor"
+	"github.com/MaxIvanyshen/local-rag/junk"
 	"gorm.io/gorm"
 )
 
-func createEmbedder(cfg *config.Config) (embedding.Embedder, error) {
-	switch cfg.Embedder.Type {
-	case "ollama":
-		return embedding.NewOllamaEmbedder(cfg.Embedder.Model, embedding.WithBaseURL(cfg.Embedder.BaseURL)), nil
-	case "http":
-		return embedding.NewHTTPEmbedder(cfg.Embedder.BaseURL), nil
-	default:
-		return nil, fmt.Errorf("unknown embedder type: %s", cfg.Embedder.Type)
-	}
-}
-
-func createChunker(cfg *config.Config) (chunker.Chunker, error) {
-	switch cfg.Chunker.Type {
-	case "paragraph":
-		return chunker.NewParagraphChunker(cfg.Chunker.OverlapBytes), nil
-	case "fixed":
-		return &chunker.FixedSizeChunker{ChunkSize: cfg.Chunker.ChunkSize}, nil
-	default:
-		return nil, fmt.Errorf("unknown chunker type: %s", cfg.Chunker.Type)
-	}
-}
-
 var (
 	svc    *Service
 	testDB *gorm.DB
@@ -47,12 +32,12 @@ func TestMain(m *testing.M) {
 
 	testDB = db.SetupTestDB()
 
-	embedder, err := createEmbedder(cfg)
+	embedder, err := embedding.New(cfg.Embedder)
 	if err != nil {
 		panic(err)
 	}
 
-	chunker, err := createChunker(cfg)
+	contentChunker, err := chunker.New(cfg.Chunker)
 	if err != nil {
 		panic(err)
 	}
@@ -60,7 +45,7 @@ func TestMain(m *testing.M) {
 	svc = NewService(&ServiceParameters{
 		DB:       testDB,
 		Embedder: embedder,
-		Chunker:  chunker,
+		Chunker:  contentChunker,
 		Cfg:      cfg,
 	})
 
@@ -131,11 +116,11 @@ func TestDeleteDocument(t *testing.T) {
 	delReq := &DeleteDocumentRequest{
 		DocumentName: documentName,
 	}
-	s, err = svc.DeleteDocument(ctx, delReq)
+	delRes, err := svc.DeleteDocument(ctx, delReq)
 	if err != nil {
 		t.Fatalf("failed to delete document: %v", err)
 	}
-	if !s.Success {
+	if !delRes.Success {
 		t.Fatalf("document deletion reported failure")
 	}
 
@@ -247,7 +232,7 @@ func TestReprocessDocument(t *testing.T) {
 		t.Fatalf("reprocess document reported failure")
 	}
 
-	// Verify document was replaced (different ID)
+	// Verify document was updated in place (same ID)
 	newDoc, err := db.GetDocumentByName(ctx, testDB, documentName)
 	if err != nil {
 		t.Fatalf("failed to get new document: %v", err)
@@ -255,8 +240,8 @@ func TestReprocessDocument(t *testing.T) {
 	if newDoc == nil {
 		t.Fatalf("new document was not found")
 	}
-	if newDoc.ID == initialID {
-		t.Fatalf("document ID was not changed, expected replacement")
+	if newDoc.ID != initialID {
+		t.Fatalf("document ID was changed, expected an update in place")
 	}
 
 	// Search for new unique phrase - should find
@@ -272,6 +257,154 @@ func TestReprocessDocument(t *testing.T) {
 	}
 }
 
+func TestProcessUnchangedDocument(t *testing.T) {
+	ctx := context.Background()
+
+	documentName := "Unchanged Test Document"
+	data := []byte("This document stays the same between uploads.")
+	modTime := time.Date(2026, 4, 5, 12, 0, 0, 0, time.UTC)
+
+	res, err := svc.ProcessDocument(ctx, &ProcessDocumentRequest{
+		DocumentName: documentName,
+		DocumentData: data,
+		ModTime:      &modTime,
+	})
+	if err != nil {
+		t.Fatalf("failed to process document: %v", err)
+	}
+	if res.Status != StatusProcessed {
+		t.Fatalf("expected status %q, got %q", StatusProcessed, res.Status)
+	}
+	doc, err := db.GetDocumentByName(ctx, testDB, documentName)
+	if err != nil {
+		t.Fatalf("failed to get document: %v", err)
+	}
+	if doc.ContentHash != ContentHash(data) || doc.Size != int64(len(data)) {
+		t.Fatalf("expected hash %s and size %d, got %s and %d", ContentHash(data), len(data), doc.ContentHash, doc.Size)
+	}
+
+	// The same content is left as it is, only the modification time changes
+	later := modTime.Add(time.Hour)
+	res, err = svc.ProcessDocument(ctx, &ProcessDocumentRequest{
+		DocumentName: documentName,
+		DocumentData: data,
+		ModTime:      &later,
+	})
+	if err != nil {
+		t.Fatalf("failed to process unchanged document: %v", err)
+	}
+	if !res.Success || res.Status != StatusUnchanged {
+		t.Fatalf("expected unchanged document to succeed with status %q, got %+v", StatusUnchanged, res)
+	}
+	unchanged, err := db.GetDocumentByName(ctx, testDB, documentName)
+	if err != nil {
+		t.Fatalf("failed to get document: %v", err)
+	}
+	if unchanged.ID != doc.ID {
+		t.Fatalf("unchanged document was replaced")
+	}
+	if unchanged.ModTime == nil || !unchanged.ModTime.Equal(later) {
+		t.Fatalf("expected modification time %v, got %v", later, unchanged.ModTime)
+	}
+
+	// Forcing reprocesses it
+	res, err = svc.ProcessDocument(ctx, &ProcessDocumentRequest{
+		DocumentName: documentName,
+		DocumentData: data,
+		Force:        true,
+	})
+	if err != nil {
+		t.Fatalf("failed to force processing: %v", err)
+	}
+	if res.Status != StatusProcessed {
+		t.Fatalf("expected forced document to be processed, got status %q", res.Status)
+	}
+
+	// The manifest check tells which files need uploading
+	check, err := svc.CheckDocuments(ctx, &CheckDocumentsRequest{
+		Documents: map[string]string{
+			documentName:         ContentHash(data),
+			"Never Uploaded":     ContentHash([]byte("new")),
+			"Reprocess Mismatch": ContentHash([]byte("other")),
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to check documents: %v", err)
+	}
+	if !slices.Equal(check.Unchanged, []string{documentName}) {
+		t.Fatalf("expected only %q to be unchanged, got %v", documentName, check.Unchanged)
+	}
+	if !slices.Equal(check.Changed, []string{"Never Uploaded", "Reprocess Mismatch"}) {
+		t.Fatalf("expected the other documents to be changed, got %v", check.Changed)
+	}
+
+	// Unchanged documents are reported by batches
+	batch, err := svc.BatchProcessDocuments(ctx, &BatchProcessDocumentsRequest{
+		Documents: []*ProcessDocumentRequest{{DocumentName: documentName, DocumentData: data}},
+	})
+	if err != nil {
+		t.Fatalf("failed to process batch: %v", err)
+	}
+	if !slices.Equal(batch.UnchangedDocuments, []string{documentName}) {
+		t.Fatalf("expected batch to report %q as unchanged, got %v", documentName, batch.UnchangedDocuments)
+	}
+}
+
+func TestProcessJunkDocumentSkipped(t *testing.T) {
+	ctx := context.Background()
+
+	junkSvc := NewService(&ServiceParameters{
+		DB:       testDB,
+		Embedder: svc.embedder,
+		Chunker:  svc.chunker,
+		Junk: &junk.Rules{
+			MinifiedExtensions: []string{".js"},
+			MinifiedLineLength: 300,
+			Lockfiles:          []string{"package-lock.json"},
+		},
+		Cfg: svc.cfg,
+	})
+
+	// A lockfile indexed before the rules existed is deleted
+	if _, err := svc.ProcessDocument(ctx, &ProcessDocumentRequest{DocumentName: "web/package-lock.json", DocumentData: []byte(`{"lockfileVersion": 3}`)}); err != nil {
+		t.Fatalf("failed to process document: %v", err)
+	}
+	res, err := junkSvc.ProcessDocument(ctx, &ProcessDocumentRequest{DocumentName: "web/package-lock.json", DocumentData: []byte(`{"lockfileVersion": 3}`)})
+	if err != nil {
+		t.Fatalf("failed to process lockfile: %v", err)
+	}
+	if !res.Success || res.Status != StatusSkipped || res.Reason != "lockfile: package-lock.json" {
+		t.Fatalf("expected lockfile to be skipped, got %+v", res)
+	}
+	if _, err := db.GetDocumentByName(ctx, testDB, "web/package-lock.json"); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Fatalf("expected the indexed lockfile to be deleted, got %v", err)
+	}
+
+	// Skipped documents are reported by batches with their reasons
+	minified := []byte("var a=1;" + strings.Repeat("function f(b){return b+1};", 20))
+	batch, err := junkSvc.BatchProcessDocuments(ctx, &BatchProcessDocumentsRequest{
+		Documents: []*ProcessDocumentRequest{
+			{DocumentName: "web/app.min.js", DocumentData: minified},
+			{DocumentName: "web/blob.txt", DocumentData: []byte("data\x00\x01\x02")},
+			{DocumentName: "web/notes.txt", DocumentData: []byte("Deploy the web app on Fridays.")},
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to process batch: %v", err)
+	}
+	slices.SortFunc(batch.SkippedDocuments, func(a, b SkippedDocument) int { return strings.Compare(a.DocumentName, b.DocumentName) })
+	expected := []SkippedDocument{
+		{DocumentName: "web/app.min.js", Reason: "minified: average line length 528"},
+		{DocumentName: "web/blob.txt", Reason: "binary: contains NUL bytes"},
+	}
+	if !slices.Equal(batch.SkippedDocuments, expected) {
+		t.Fatalf("expected skipped documents %v, got %v", expected, batch.SkippedDocuments)
+	}
+	if _, err := db.GetDocumentByName(ctx, testDB, "web/notes.txt"); err != nil {
+		t.Fatalf("expected other documents to be processed: %v", err)
+	}
+}
+
 func TestDocumentNameSemanticSearch(t *testing.T) {
 	ctx := context.Background()
 
@@ -372,11 +505,11 @@ func TestDocumentNameEmbeddingCleanup(t *testing.T) {
 	delReq := &DeleteDocumentRequest{
 		DocumentName: documentName,
 	}
-	s, err = svc.DeleteDocument(ctx, delReq)
+	delRes, err := svc.DeleteDocument(ctx, delReq)
 	if err != nil {
 		t.Fatalf("failed to delete document: %v", err)
 	}
-	if !s.Success {
+	if !delRes.Success {
 		t.Fatalf("document deletion reported failure")
 	}
 
@@ -386,3 +519,772 @@ func TestDocumentNameEmbeddingCleanup(t *testing.T) {
 		t.Fatalf("expected 0 name embeddings after deletion, but found %d", count)
 	}
 }
+
+func TestParentChildSearchReturnsSection(t *testing.T) {
+	ctx := context.Background()
+
+	embedder, err := embedding.New(svc.cfg.Embedder)
+	if err != nil {
+		t.Fatalf("failed to create embedder: %v", err)
+	}
+	parentChildSvc := NewService(&ServiceParameters{
+		DB:       testDB,
+		Embedder: embedder,
+		Chunker:  chunker.NewParentChildChunker(&chunker.HeadingChunker{}, chunker.NewParagraphChunker(0)),
+		Cfg:      svc.cfg,
+	})
+
+	documentName := "Parent Child Test Document"
+	section := "# Deployment\n\nRun the command below.\n\nThe zqv789 flag enables canary rollout.\n\n"
+	documentData := []byte("# Intro\n\nSome introduction.\n\n" + section)
+
+	s, err := parentChildSvc.ProcessDocument(ctx, &ProcessDocumentRequest{
+		DocumentName: documentName,
+		DocumentData: documentData,
+	})
+	if err != nil {
+		t.Fatalf("failed to process document: %v", err)
+	}
+	if !s.Success {
+		t.Fatalf("document processing reported failure")
+	}
+
+	results, err := parentChildSvc.Search(ctx, &SearchRequest{Query: "zqv789 flag canary rollout"})
+	if err != nil {
+		t.Fatalf("search failed: %v", err)
+	}
+
+	for _, result := range results {
+		if result.DocumentName != documentName || result.IsNameMatch {
+			continue
+		}
+		if result.Content != section {
+			t.Fatalf("expected the whole section as content, got %q", result.Content)
+		}
+		if result.StartLine != 5 || result.EndLine != 11 {
+			t.Fatalf("expected section lines 5-11, got %d-%d", result.StartLine, result.EndLine)
+		}
+		return
+	}
+	t.Fatalf("expected to find the section in search results, got %+v", results)
+}
+
+// recordingEmbedder records every input passed to the wrapped embedder.
+type recordingEmbedder struct {
+	embedding.Embedder
+
+	mu     sync.Mutex
+	inputs []string
+}
+
+func (r *recordingEmbedder) GenerateEmbedding(ctx context.Context, input []byte) ([]float32, error) {
+	r.mu.Lock()
+	r.inputs = append(r.inputs, string(input))
+	r.mu.Unlock()
+	return r.Embedder.GenerateEmbedding(ctx, input)
+}
+
+func TestReprocessDocumentKeepsUnchangedChunks(t *testing.T) {
+	ctx := context.Background()
+
+	recorder := &recordingEmbedder{Embedder: svc.embedder}
+	diffSvc := NewService(&ServiceParameters{
+		DB:       testDB,
+		Embedder: recorder,
+		Chunker:  chunker.NewSizedParagraphChunker(0, 0, 0),
+		Cfg:      svc.cfg,
+	})
+
+	documentName := "Chunk Diff Test Document"
+	_, err := diffSvc.ProcessDocument(ctx, &ProcessDocumentRequest{
+		DocumentName: documentName,
+		DocumentData: []byte("Alpha paragraph.\n\nBeta paragraph.\n\nGamma paragraph."),
+	})
+	if err != nil {
+		t.Fatalf("failed to process document: %v", err)
+	}
+	doc, err := db.GetDocumentByName(ctx, testDB, documentName)
+	if err != nil {
+		t.Fatalf("failed to get document: %v", err)
+	}
+	initial, err := db.GetChunksByDocumentID(ctx, testDB, doc.ID)
+	if err != nil {
+		t.Fatalf("failed to get chunks: %v", err)
+	}
+	initialIDs := make(map[string]string, len(initial))
+	for _, chunk := range initial {
+		initialIDs[strings.TrimSpace(string(chunk.Data))] = chunk.ID
+	}
+
+	// Add a paragraph before the others and edit the middle one
+	recorder.inputs = nil
+	_, err = diffSvc.ProcessDocument(ctx, &ProcessDocumentRequest{
+		DocumentName: documentName,
+		DocumentData: []byte("Intro paragraph.\n\nAlpha paragraph.\n\nBeta changed.\n\nGamma paragraph."),
+	})
+	if err != nil {
+		t.Fatalf("failed to reprocess document: %v", err)
+	}
+
+	// Only the new chunks are embedded, the document name isn't embedded again
+	var embedded []string
+	for _, input := range recorder.inputs {
+		embedded = append(embedded, strings.TrimSpace(input))
+	}
+	if !slices.Equal(embedded, []string{"Intro paragraph.", "Beta changed."}) {
+		t.Fatalf("expected only the new chunks to be embedded, got %q", embedded)
+	}
+
+	updated, err := db.GetDocumentByName(ctx, testDB, documentName)
+	if err != nil {
+		t.Fatalf("failed to get document: %v", err)
+	}
+	if updated.ID != doc.ID {
+		t.Fatalf("document ID was changed")
+	}
+	chunks, err := db.GetChunksByDocumentID(ctx, testDB, doc.ID)
+	if err != nil {
+		t.Fatalf("failed to get chunks: %v", err)
+	}
+	var texts []string
+	for i, chunk := range chunks {
+		text := strings.TrimSpace(string(chunk.Data))
+		texts = append(texts, text)
+		if chunk.ChunkIndex != i {
+			t.Fatalf("expected chunk %q to have index %d, got %d", text, i, chunk.ChunkIndex)
+		}
+	}
+	if !slices.Equal(texts, []string{"Intro paragraph.", "Alpha paragraph.", "Beta changed.", "Gamma paragraph."}) {
+		t.Fatalf("unexpected chunks after reprocessing: %q", texts)
+	}
+	if chunks[1].ID != initialIDs["Alpha paragraph."] || chunks[3].ID != initialIDs["Gamma paragraph."] {
+		t.Fatalf("unchanged chunks were not kept")
+	}
+	if chunks[3].StartLine != 7 {
+		t.Fatalf("expected the kept chunk to move to line 7, got %d", chunks[3].StartLine)
+	}
+
+	// The embedding of the removed chunk is deleted with it
+	var embeddings int
+	err = testDB.Raw("SELECT COUNT(*) FROM chunk_embeddings WHERE chunk_id IN (SELECT id FROM chunks WHERE document_id = ?)", doc.ID).Scan(&embeddings).Error
+	if err != nil {
+		t.Fatalf("failed to count embeddings: %v", err)
+	}
+	var orphaned int
+	err = testDB.Raw("SELECT COUNT(*) FROM chunk_embeddings WHERE chunk_id = ?", initialIDs["Beta paragraph."]).Scan(&orphaned).Error
+	if err != nil {
+		t.Fatalf("failed to count embeddings: %v", err)
+	}
+	if embeddings != 4 || orphaned != 0 {
+		t.Fatalf("expected 4 embeddings and none for the removed chunk, got %d and %d", embeddings, orphaned)
+	}
+}
+
+func TestContextHeaderPrependedBeforeEmbedding(t *testing.T) {
+	ctx := context.Background()
+
+	embedder, err := embedding.New(svc.cfg.Embedder)
+	if err != nil {
+		t.Fatalf("failed to create embedder: %v", err)
+	}
+	recorder := &recordingEmbedder{Embedder: embedder}
+	header, err := chunker.NewContextHeader("{{.DocumentName}} | {{.HeadingPath}}\n")
+	if err != nil {
+		t.Fatalf("failed to create context header: %v", err)
+	}
+	headerSvc := NewService(&ServiceParameters{
+		DB:            testDB,
+		Embedder:      recorder,
+		Chunker:       chunker.NewParagraphChunker(0),
+		ContextHeader: header,
+		Cfg:           svc.cfg,
+	})
+
+	documentName := "Context Header Test Document"
+	s, err := headerSvc.ProcessDocument(ctx, &ProcessDocumentRequest{
+		DocumentName: documentName,
+		DocumentData: []byte("# Setup\n\nRun the command below."),
+	})
+	if err != nil {
+		t.Fatalf("failed to process document: %v", err)
+	}
+	if !s.Success {
+		t.Fatalf("document processing reported failure")
+	}
+
+	expectedInput := documentName + " | Setup\nRun the command below."
+	if !slices.Contains(recorder.inputs, expectedInput) {
+		t.Fatalf("expected embedding input %q, got %q", expectedInput, recorder.inputs)
+	}
+
+	// The stored chunk keeps the clean text
+	doc, err := db.GetDocumentByName(ctx, testDB, documentName)
+	if err != nil {
+		t.Fatalf("failed to get document: %v", err)
+	}
+	var data []string
+	testDB.Raw("SELECT data FROM chunks WHERE document_id = ? ORDER BY chunk_index", doc.ID).Scan(&data)
+	if len(data) != 2 || data[1] != "Run the command below." {
+		t.Fatalf("expected clean chunk data, got %q", data)
+	}
+}
+
+// newExtractorService returns a service like svc that extracts text with the
+// given extractors.
+func newExtractorService(extractors ...extractor.Extractor) *Service {
+	return NewService(&ServiceParameters{
+		DB:        testDB,
+		Embedder:  svc.embedder,
+		Chunker:   svc.chunker,
+		Extractor: extractor.NewRegistry(extractors...),
+		Cfg:       svc.cfg,
+	})
+}
+
+// processTestFile processes a file of test_data under its own name.
+func processTestFile(t *testing.T, s *Service, name string) {
+	t.Helper()
+
+	data, err := os.ReadFile("../test_data/" + name)
+	if err != nil {
+		t.Fatalf("failed to read test data file: %v", err)
+	}
+	res, err := s.ProcessDocument(context.Background(), &ProcessDocumentRequest{
+		DocumentName: name,
+		DocumentData: data,
+	})
+	if err != nil {
+		t.Fatalf("failed to process document: %v", err)
+	}
+	if !res.Success {
+		t.Fatalf("document processing reported failure")
+	}
+}
+
+// searchResult searches for query and returns the first chunk of the document
+// that contains text.
+func searchResult(t *testing.T, s *Service, query, documentName, text string) db.SearchResult {
+	t.Helper()
+
+	results, err := s.Search(context.Background(), &SearchRequest{Query: query})
+	if err != nil {
+		t.Fatalf("search failed: %v", err)
+	}
+	for _, result := range results {
+		if result.DocumentName == documentName && !result.IsNameMatch && strings.Contains(result.Content, text) {
+			return result
+		}
+	}
+	t.Fatalf("expected to find %q of %s in search results, got %+v", text, documentName, results)
+	return db.SearchResult{}
+}
+
+func TestProcessPDFDocument(t *testing.T) {
+	ctx := context.Background()
+
+	pdfSvc := newExtractorService(&extractor.PDFExtractor{})
+	processTestFile(t, pdfSvc, "sample.pdf")
+
+	doc, err := db.GetDocumentByName(ctx, testDB, "sample.pdf")
+	if err != nil {
+		t.Fatalf("failed to get document: %v", err)
+	}
+	var chunks []string
+	testDB.Raw("SELECT data FROM chunks WHERE document_id = ? ORDER BY chunk_index", doc.ID).Scan(&chunks)
+	if len(chunks) == 0 {
+		t.Fatalf("expected chunks for the PDF")
+	}
+	for _, chunk := range chunks {
+		if strings.Contains(chunk, "%PDF") {
+			t.Fatalf("expected extracted text, got raw PDF data: %q", chunk)
+		}
+	}
+
+	result := searchResult(t, pdfSvc, "zqx settings", "sample.pdf", "zqx settings")
+	if result.Page != 2 {
+		t.Fatalf("expected match on page 2, got page %d", result.Page)
+	}
+}
+
+func TestProcessHTMLDocument(t *testing.T) {
+	ctx := context.Background()
+
+	processTestFile(t, newExtractorService(&extractor.HTMLExtractor{}), "sample.html")
+
+	doc, err := db.GetDocumentByName(ctx, testDB, "sample.html")
+	if err != nil {
+		t.Fatalf("failed to get document: %v", err)
+	}
+	if doc.Metadata["title"] != "Widget Setup Guide" {
+		t.Fatalf("expected page title in document metadata, got %v", doc.Metadata)
+	}
+
+	var chunks []string
+	testDB.Raw("SELECT data FROM chunks WHERE document_id = ? ORDER BY chunk_index", doc.ID).Scan(&chunks)
+	for _, chunk := range chunks {
+		if strings.Contains(chunk, "<") || strings.Contains(chunk, "analytics") || strings.Contains(chunk, "Copyright") {
+			t.Fatalf("expected clean text without markup or boilerplate, got %q", chunk)
+		}
+	}
+}
+
+func TestProcessPresentationRecordsSlide(t *testing.T) {
+	officeSvc := newExtractorService(&extractor.PPTXExtractor{})
+	processTestFile(t, officeSvc, "sample.pptx")
+
+	result := searchResult(t, officeSvc, "Mention the pricing change", "sample.pptx", "pricing change")
+	if result.Metadata["slide"] != float64(2) {
+		t.Fatalf("expected match on slide 2, got metadata %v", result.Metadata)
+	}
+}
+
+func TestProcessEPUBNamesChapter(t *testing.T) {
+	embedder, err := embedding.New(svc.cfg.Embedder)
+	if err != nil {
+		t.Fatalf("failed to create embedder: %v", err)
+	}
+	recorder := &recordingEmbedder{Embedder: embedder}
+	header, err := chunker.NewContextHeader("{{.HeadingPath}}\n")
+	if err != nil {
+		t.Fatalf("failed to create context header: %v", err)
+	}
+	epubSvc := NewService(&ServiceParameters{
+		DB:            testDB,
+		Embedder:      recorder,
+		Chunker:       chunker.NewParagraphChunker(0),
+		Extractor:     extractor.NewRegistry(&extractor.EPUBExtractor{}),
+		ContextHeader: header,
+		Cfg:           svc.cfg,
+	})
+	processTestFile(t, epubSvc, "sample.epub")
+
+	// The second chapter has no heading of its own, the title comes from the table of contents
+	expectedInput := "Chapter 2: The Lamp\nThe keeper trimmed the lamp wick every evening.\n"
+	if !slices.Contains(recorder.inputs, expectedInput) {
+		t.Fatalf("expected embedding input %q, got %q", expectedInput, recorder.inputs)
+	}
+
+	result := searchResult(t, epubSvc, "keeper trimmed the lamp wick", "sample.epub", "lamp wick")
+	if result.Metadata["chapter"] != "Chapter 2: The Lamp" {
+		t.Fatalf("expected the chapter in search result metadata, got %v", result.Metadata)
+	}
+}
+
+func TestProcessNotebookMapsChunksToCells(t *testing.T) {
+	notebookSvc := newExtractorService(extractor.NewNotebookExtractor(false))
+	processTestFile(t, notebookSvc, "sample.ipynb")
+
+	result := searchResult(t, notebookSvc, "df groupby plan churn mean", "sample.ipynb", "groupby")
+	if result.Metadata["cell"] != float64(4) || result.Metadata["cell_type"] != "code" {
+		t.Fatalf("expected match on code cell 4, got metadata %v", result.Metadata)
+	}
+	if result.StartLine != 1 {
+		t.Fatalf("expected lines relative to the cell, got start line %d", result.StartLine)
+	}
+}
+
+func TestProcessJSONLinesReturnsRecordID(t *testing.T) {
+	jsonSvc := newExtractorService(&extractor.JSONExtractor{
+		TextPaths: []string{"fields.description", "comments.*.body"},
+		TitlePath: "title",
+		IDPath:    "id",
+	})
+	processTestFile(t, jsonSvc, "tickets.jsonl")
+
+	result := searchResult(t, jsonSvc, "CSV export of large projects never finishes", "tickets.jsonl", "CSV export")
+	if result.Metadata["record_id"] != float64(1043) {
+		t.Fatalf("expected record 1043, got metadata %v", result.Metadata)
+	}
+	if strings.Contains(result.Content, "{") || strings.Contains(result.Content, "password") {
+		t.Fatalf("expected only the text of the record, got %q", result.Content)
+	}
+}
+
+func TestProcessMailboxStoresMessagesAsDocuments(t *testing.T) {
+	ctx := context.Background()
+
+	mailSvc := newExtractorService(extractor.NewEmailExtractor(true))
+	mailboxName := "dev-list.mbox"
+	processTestFile(t, mailSvc, mailboxName)
+
+	docs, err := db.GetDocumentsBySource(ctx, testDB, mailboxName)
+	if err != nil {
+		t.Fatalf("failed to get documents: %v", err)
+	}
+	if len(docs) != 2 || docs[0].Name != "dev-list.mbox#build-1@example.com" || docs[1].Name != "dev-list.mbox#build-2@example.com" {
+		t.Fatalf("expected a document per message, got %+v", docs)
+	}
+	if docs[1].Metadata["from"] != "Bo Chen <bo@example.com>" || docs[1].Metadata["date"] != "2026-02-02T10:30:00Z" {
+		t.Fatalf("expected message headers in metadata, got %v", docs[1].Metadata)
+	}
+
+	// Processing the mailbox again without the second message removes it
+	data, err := os.ReadFile("../test_data/dev-list.mbox")
+	if err != nil {
+		t.Fatalf("failed to read test data file: %v", err)
+	}
+	firstMessage := data[:strings.Index(string(data), "From bo@example.com")]
+	_, err = mailSvc.ProcessDocument(ctx, &ProcessDocumentRequest{
+		DocumentName: mailboxName,
+		DocumentData: firstMessage,
+	})
+	if err != nil {
+		t.Fatalf("failed to reprocess document: %v", err)
+	}
+	if _, err := db.GetDocumentByName(ctx, testDB, "dev-list.mbox#build-2@example.com"); err == nil {
+		t.Fatalf("expected the removed message to be deleted")
+	}
+
+	// The same message in another mailbox is a document of its own
+	_, err = mailSvc.ProcessDocument(ctx, &ProcessDocumentRequest{
+		DocumentName: "archive.mbox",
+		DocumentData: firstMessage,
+	})
+	if err != nil {
+		t.Fatalf("failed to process document: %v", err)
+	}
+
+	_, err = mailSvc.DeleteDocument(ctx, &DeleteDocumentRequest{DocumentName: mailboxName})
+	if err != nil {
+		t.Fatalf("failed to delete document: %v", err)
+	}
+	docs, err = db.GetDocumentsBySource(ctx, testDB, mailboxName)
+	if err != nil {
+		t.Fatalf("failed to get documents: %v", err)
+	}
+	if len(docs) != 0 {
+		t.Fatalf("expected deleting the mailbox to delete its messages, got %+v", docs)
+	}
+	if _, err := db.GetDocumentByName(ctx, testDB, "archive.mbox#build-1@example.com"); err != nil {
+		t.Fatalf("expected the other mailbox to keep its copy of the message: %v", err)
+	}
+}
+
+// zipFiles returns a zip archive of the files, keyed by their path.
+func zipFiles(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range files {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatalf("failed to create archive entry: %v", err)
+		}
+		if _, err := w.Write([]byte(content)); err != nil {
+			t.Fatalf("failed to write archive entry: %v", err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("failed to close archive: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestProcessArchiveStoresEntriesAsDocuments(t *testing.T) {
+	ctx := context.Background()
+
+	data := zipFiles(t, map[string]string{
+		"docs/install.md": "# Install\n\nRun the installer and restart the terminal.\n",
+		"docs/faq.html":   "<html><body><h1>FAQ</h1><p>Archives are unpacked on upload.</p></body></html>",
+	})
+
+	files := extractor.NewRegistry(&extractor.HTMLExtractor{})
+	archiveSvc := newExtractorService(extractor.NewArchiveExtractor(files, extractor.ArchiveLimits{MaxEntries: 10}), files)
+
+	archiveName := "handbook.zip"
+	s, err := archiveSvc.ProcessDocument(ctx, &ProcessDocumentRequest{
+		DocumentName: archiveName,
+		DocumentData: data,
+	})
+	if err != nil {
+		t.Fatalf("failed to process document: %v", err)
+	}
+	if !s.Success {
+		t.Fatalf("document processing reported failure")
+	}
+
+	docs, err := db.GetDocumentsBySource(ctx, testDB, archiveName)
+	if err != nil {
+		t.Fatalf("failed to get documents: %v", err)
+	}
+	var names []string
+	for _, doc := range docs {
+		names = append(names, doc.Name)
+	}
+	slices.Sort(names)
+	if !slices.Equal(names, []string{"handbook.zip!/docs/faq.html", "handbook.zip!/docs/install.md"}) {
+		t.Fatalf("expected a document per archive entry, got %v", names)
+	}
+
+	results, err := archiveSvc.Search(ctx, &SearchRequest{Query: "Archives are unpacked on upload."})
+	if err != nil {
+		t.Fatalf("search failed: %v", err)
+	}
+	if len(results) == 0 || results[0].DocumentName != "handbook.zip!/docs/faq.html" {
+		t.Fatalf("expected the HTML entry to be found, got %+v", results)
+	}
+
+	_, err = archiveSvc.DeleteDocument(ctx, &DeleteDocumentRequest{DocumentName: archiveName})
+	if err != nil {
+		t.Fatalf("failed to delete document: %v", err)
+	}
+	docs, err = db.GetDocumentsBySource(ctx, testDB, archiveName)
+	if err != nil {
+		t.Fatalf("failed to get documents: %v", err)
+	}
+	if len(docs) != 0 {
+		t.Fatalf("expected deleting the archive to delete its entries, got %+v", docs)
+	}
+}
+
+func TestProcessArchiveSkipsJunkEntries(t *testing.T) {
+	ctx := context.Background()
+
+	data := zipFiles(t, map[string]string{
+		"web/package-lock.json": `{"lockfileVersion": 3}`,
+		"web/app.min.js":        "var a=1;" + strings.Repeat("function f(b){return b+1};", 20),
+		"web/logo.bin":          "data\x00\x01\x02",
+		"web/notes.txt":         "Deploy the web app on Fridays.",
+	})
+
+	junkSvc := NewService(&ServiceParameters{
+		DB:        testDB,
+		Embedder:  svc.embedder,
+		Chunker:   svc.chunker,
+		Extractor: extractor.NewRegistry(extractor.NewArchiveExtractor(nil, extractor.ArchiveLimits{MaxEntries: 10})),
+		Junk: &junk.Rules{
+			MinifiedExtensions: []string{".js"},
+			MinifiedLineLength: 300,
+			Lockfiles:          []string{"package-lock.json"},
+		},
+		Cfg: svc.cfg,
+	})
+
+	res, err := junkSvc.ProcessDocument(ctx, &ProcessDocumentRequest{DocumentName: "site.zip", DocumentData: data})
+	if err != nil {
+		t.Fatalf("failed to process archive: %v", err)
+	}
+	if !res.Success || res.Status != StatusProcessed {
+		t.Fatalf("expected archive to be processed, got %+v", res)
+	}
+	slices.SortFunc(res.SkippedDocuments, func(a, b SkippedDocument) int { return strings.Compare(a.DocumentName, b.DocumentName) })
+	expected := []SkippedDocument{
+		{DocumentName: "site.zip!/web/app.min.js", Reason: "minified: average line length 528"},
+		{DocumentName: "site.zip!/web/logo.bin", Reason: "binary: contains NUL bytes"},
+		{DocumentName: "site.zip!/web/package-lock.json", Reason: "lockfile: package-lock.json"},
+	}
+	if !slices.Equal(res.SkippedDocuments, expected) {
+		t.Fatalf("expected skipped entries %v, got %v", expected, res.SkippedDocuments)
+	}
+
+	docs, err := db.GetDocumentsBySource(ctx, testDB, "site.zip")
+	if err != nil {
+		t.Fatalf("failed to get documents: %v", err)
+	}
+	if len(docs) != 1 || docs[0].Name != "site.zip!/web/notes.txt" {
+		t.Fatalf("expected only the notes to be indexed, got %+v", docs)
+	}
+
+	// Batches report skipped entries apart from the files they came from
+	batch, err := junkSvc.BatchProcessDocuments(ctx, &BatchProcessDocumentsRequest{
+		Documents: []*ProcessDocumentRequest{{DocumentName: "site-copy.zip", DocumentData: data}},
+	})
+	if err != nil {
+		t.Fatalf("failed to process batch: %v", err)
+	}
+	if len(batch.SkippedDocuments) != 0 || len(batch.SkippedEntries) != len(expected) {
+		t.Fatalf("expected %d skipped entries and no skipped files, got %+v", len(expected), batch)
+	}
+}
+
+func TestProcessDocumentConvertsEncoding(t *testing.T) {
+	ctx := context.Background()
+
+	documents := map[string]struct {
+		data     []byte
+		encoding string
+	}{
+		// "Crème brûlée recipe" in UTF-16LE with a byte order mark
+		"recipe-utf16.txt": {
+			data:     []byte("\xff\xfeC\x00r\x00\xe8\x00m\x00e\x00 \x00b\x00r\x00\xfb\x00l\x00\xe9\x00e\x00 \x00r\x00e\x00c\x00i\x00p\x00e\x00"),
+			encoding: "utf-16le",
+		},
+		// "“Crème brûlée” recipe" in Windows-1252
+		"recipe-1252.txt": {
+			data:     []byte("\x93Cr\xe8me br\xfbl\xe9e\x94 recipe"),
+			encoding: "windows-1252",
+		},
+	}
+	for name, document := range documents {
+		_, err := svc.ProcessDocument(ctx, &ProcessDocumentRequest{
+			DocumentName: name,
+			DocumentData: document.data,
+		})
+		if err != nil {
+			t.Fatalf("failed to process document %s: %v", name, err)
+		}
+
+		doc, err := db.GetDocumentByName(ctx, testDB, name)
+		if err != nil {
+			t.Fatalf("failed to get document: %v", err)
+		}
+		if doc.Metadata["encoding"] != document.encoding {
+			t.Fatalf("expected encoding %s on %s, got %v", document.encoding, name, doc.Metadata)
+		}
+
+		var chunks []string
+		testDB.Raw("SELECT data FROM chunks WHERE document_id = ? ORDER BY chunk_index", doc.ID).Scan(&chunks)
+		if len(chunks) != 1 || !strings.Contains(chunks[0], "Crème brûlée") {
+			t.Fatalf("expected chunk text converted to UTF-8 for %s, got %q", name, chunks)
+		}
+	}
+}
+
+func TestSearchFiltersOnFrontMatter(t *testing.T) {
+	ctx := context.Background()
+
+	notesSvc := newExtractorService(&extractor.MarkdownExtractor{})
+
+	notes := map[string]string{
+		"vault/garden.md":  "---\ntitle: Garden Plan\ntags: [home, garden]\n---\n\nPlant the tomatoes zqw481 in May.\n",
+		"vault/kitchen.md": "---\ntitle: Kitchen Plan\ntags: [home]\n---\n\nPlant the herbs zqw481 on the windowsill.\n",
+	}
+	for name, note := range notes {
+		_, err := notesSvc.ProcessDocument(ctx, &ProcessDocumentRequest{
+			DocumentName: name,
+			DocumentData: []byte(note),
+		})
+		if err != nil {
+			t.Fatalf("failed to process document %s: %v", name, err)
+		}
+	}
+
+	results, err := notesSvc.Search(ctx, &SearchRequest{
+		Query:   "Plant the herbs zqw481",
+		Filters: map[string]any{"tags": "garden"},
+	})
+	if err != nil {
+		t.Fatalf("search failed: %v", err)
+	}
+	if len(results) == 0 {
+		t.Fatalf("expected results for the garden tag")
+	}
+	for _, result := range results {
+		if result.DocumentName != "vault/garden.md" {
+			t.Fatalf("expected only documents tagged garden, got %s", result.DocumentName)
+		}
+		if strings.Contains(result.Content, "title:") {
+			t.Fatalf("expected front matter to be left out of chunks, got %q", result.Content)
+		}
+		if result.DocumentMetadata["title"] != "Garden Plan" {
+			t.Fatalf("expected front matter returned with results, got %v", result.DocumentMetadata)
+		}
+		// Positions count the lines and bytes of the front matter
+		note := notes[result.DocumentName]
+		if result.StartLine != 6 || note[result.StartByte:result.EndByte] != result.Content {
+			t.Fatalf("expected the chunk at line 6 and bytes %d-%d of the note, got line %d and %q", result.StartByte, result.EndByte, result.StartLine, note[result.StartByte:result.EndByte])
+		}
+	}
+}
+
+func TestProcessDocumentKeepsOffsetsOfCRLFText(t *testing.T) {
+	ctx := context.Background()
+
+	data := []byte("\ufefffirst para\r\n\r\nsecond para\r\n")
+	custom := NewService(&ServiceParameters{
+		DB:       testDB,
+		Embedder: svc.embedder,
+		Chunker:  chunker.NewSizedParagraphChunker(0, 1, 12),
+		Cfg:      svc.cfg,
+	})
+	if _, err := custom.ProcessDocument(ctx, &ProcessDocumentRequest{DocumentName: "crlf.txt", DocumentData: data}); err != nil {
+		t.Fatalf("failed to process document: %v", err)
+	}
+
+	doc, err := db.GetDocumentByName(ctx, testDB, "crlf.txt")
+	if err != nil {
+		t.Fatalf("failed to get document: %v", err)
+	}
+	var chunks []db.Chunk
+	testDB.Where("document_id = ?", doc.ID).Order("chunk_index").Find(&chunks)
+	if len(chunks) != 2 {
+		t.Fatalf("expected 2 chunks, got %d", len(chunks))
+	}
+	for _, chunk := range chunks {
+		original := strings.TrimSpace(string(data[chunk.StartByte:chunk.EndByte]))
+		if original != strings.TrimSpace(string(chunk.Data)) {
+			t.Fatalf("expected bytes %d-%d to hold %q, got %q", chunk.StartByte, chunk.EndByte, chunk.Data, original)
+		}
+	}
+}
+
+func TestProcessDocumentWarnsOfUnknownEncoding(t *testing.T) {
+	ctx := context.Background()
+
+	res, err := svc.ProcessDocument(ctx, &ProcessDocumentRequest{
+		DocumentName: "unknown-encoding.txt",
+		DocumentData: []byte("caf\x81 menu"),
+	})
+	if err != nil {
+		t.Fatalf("failed to process document: %v", err)
+	}
+	if len(res.Warnings) != 1 {
+		t.Fatalf("expected a warning about the encoding, got %v", res.Warnings)
+	}
+}
+
+func TestProcessDocumentChunksTablesByFormat(t *testing.T) {
+	ctx := context.Background()
+
+	tableSvc := NewService(&ServiceParameters{
+		DB:       testDB,
+		Embedder: svc.embedder,
+		Chunker:  chunker.NewTableChunker(1, chunker.NewSizedParagraphChunker(0, 1, 20)),
+		Cfg:      svc.cfg,
+	})
+	documents := []struct {
+		name        string
+		contentType string
+		data        string
+		chunks      []string
+	}{
+		{
+			name:   "prices.csv",
+			data:   "item,price\napple,1\npear,2\n",
+			chunks: []string{"item,price\napple,1\n", "item,price\npear,2\n"},
+		},
+		{
+			name:        "prices-export",
+			contentType: "text/csv",
+			data:        "item,price\napple,1\npear,2\n",
+			chunks:      []string{"item,price\napple,1\n", "item,price\npear,2\n"},
+		},
+		// Prose that parses as CSV is still prose
+		{
+			name:   "greeting.txt",
+			data:   "Hello, world.\n\nGoodbye, friends.\n",
+			chunks: []string{"Hello, world.\n\n", "Goodbye, friends.\n"},
+		},
+	}
+	for _, document := range documents {
+		_, err := tableSvc.ProcessDocument(ctx, &ProcessDocumentRequest{
+			DocumentName: document.name,
+			DocumentData: []byte(document.data),
+			ContentType:  document.contentType,
+		})
+		if err != nil {
+			t.Fatalf("failed to process document %s: %v", document.name, err)
+		}
+
+		doc, err := db.GetDocumentByName(ctx, testDB, document.name)
+		if err != nil {
+			t.Fatalf("failed to get document: %v", err)
+		}
+		var chunks []string
+		testDB.Raw("SELECT data FROM chunks WHERE document_id = ? ORDER BY chunk_index", doc.ID).Scan(&chunks)
+		if !slices.Equal(chunks, document.chunks) {
+			t.Fatalf("expected chunks %q for %s, got %q", document.chunks, document.name, chunks)
+		}
+	}
+}
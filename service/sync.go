@@ -0,0 +1,187 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/MaxIvanyshen/local-rag/db"
+	"github.com/MaxIvanyshen/local-rag/extractor"
+	"github.com/MaxIvanyshen/local-rag/source"
+)
+
+type SyncRequest struct {
+	// Sources are the names of the sources to sync. Every source is synced
+	// when it is empty.
+	Sources []string `json:"sources,omitempty"`
+}
+
+type SyncResponse struct {
+	Sources []*SyncSourceResponse `json:"sources"`
+}
+
+type SyncSourceResponse struct {
+	Source    string `json:"source"`
+	Added     int    `json:"added"`
+	Updated   int    `json:"updated"`
+	Unchanged int    `json:"unchanged"`
+	Deleted   int    `json:"deleted"`
+	// Skipped are the junk and binary items, which aren't indexed.
+	Skipped         int      `json:"skipped"`
+	FailedDocuments []string `json:"failed_documents"`
+	// Error tells why the source couldn't be synced at all, such as an
+	// unknown name or a directory that is gone. Nothing is deleted then.
+	Error string `json:"error,omitempty"`
+}
+
+// Sync brings the index up to date with the configured sources, one after
+// another. A source that can't be listed is reported and the others are
+// still synced.
+func (s *Service) Sync(ctx context.Context, req *SyncRequest) (*SyncResponse, error) {
+	names := req.Sources
+	if len(names) == 0 {
+		names = slices.Sorted(maps.Keys(s.sources))
+	}
+	slog.Info("received sync request", slog.Any("sources", names))
+
+	res := &SyncResponse{Sources: []*SyncSourceResponse{}}
+	for _, name := range names {
+		src, ok := s.sources[name]
+		if !ok {
+			res.Sources = append(res.Sources, &SyncSourceResponse{Source: name, FailedDocuments: []string{}, Error: "unknown source"})
+			continue
+		}
+		sourceRes, err := s.SyncSource(ctx, name, src)
+		if err != nil {
+			slog.Error("failed to sync source", slog.String("error", err.Error()), slog.String("source", name))
+			sourceRes = &SyncSourceResponse{Source: name, FailedDocuments: []string{}, Error: err.Error()}
+		}
+		res.Sources = append(res.Sources, sourceRes)
+	}
+	return res, nil
+}
+
+// SyncSource indexes the items of a source added or changed since its last
+// sync, and deletes the documents of the items it no longer has. The
+// version of every item indexed is stored, so unchanged items aren't read
+// again. Items that failed keep their previous state and are retried by the
+// next sync.
+func (s *Service) SyncSource(ctx context.Context, name string, src source.Source) (*SyncSourceResponse, error) {
+	items, err := src.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	synced, err := db.GetSourceItems(ctx, s.db, name)
+	if err != nil {
+		return nil, err
+	}
+	states := make(map[string]*db.SourceItem, len(synced))
+	for i := range synced {
+		states[synced[i].ItemID] = &synced[i]
+	}
+
+	res := &SyncSourceResponse{
+		Source:          name,
+		FailedDocuments: []string{},
+	}
+	listed := make(map[string]bool, len(items))
+	for _, item := range items {
+		listed[item.ID] = true
+		state := states[item.ID]
+		if state != nil && item.Version != "" && state.Version == item.Version && state.DocumentName == item.Name {
+			res.Unchanged++
+			continue
+		}
+		if err := s.syncSourceItem(ctx, name, src, item, state, res); err != nil {
+			slog.Error("failed to sync source item", slog.String("error", err.Error()), slog.String("source", name), slog.String("document_name", item.Name))
+			res.FailedDocuments = append(res.FailedDocuments, item.Name)
+		}
+	}
+
+	for _, state := range synced {
+		if listed[state.ItemID] {
+			continue
+		}
+		if _, err := s.DeleteDocument(ctx, &DeleteDocumentRequest{DocumentName: state.DocumentName}); err != nil {
+			res.FailedDocuments = append(res.FailedDocuments, state.DocumentName)
+			continue
+		}
+		if err := db.DeleteSourceItem(ctx, s.db, name, state.ItemID); err != nil {
+			return nil, err
+		}
+		res.Deleted++
+	}
+
+	if stamper, ok := src.(source.Stamper); ok && len(res.FailedDocuments) == 0 {
+		match, stamp := stamper.Stamp(items)
+		for _, key := range slices.Sorted(maps.Keys(stamp)) {
+			if err := db.SetDocumentsMetadata(ctx, s.db, match, key, stamp[key]); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	slog.Info("source sync completed", slog.String("source", name), slog.Int("added", res.Added), slog.Int("updated", res.Updated), slog.Int("unchanged", res.Unchanged), slog.Int("deleted", res.Deleted), slog.Int("skipped", res.Skipped), slog.Int("failed_documents", len(res.FailedDocuments)))
+
+	return res, nil
+}
+
+// syncSourceItem processes an item that is new or changed, and records its
+// version. Binary items are indexed only when an extractor handles their
+// format, otherwise they are skipped and a previously indexed version of
+// them is deleted.
+func (s *Service) syncSourceItem(ctx context.Context, name string, src source.Source, item source.Item, state *db.SourceItem, res *SyncSourceResponse) error {
+	data, err := src.Read(ctx, item)
+	if err != nil {
+		return err
+	}
+	status := StatusSkipped
+	if extractor.IsBinary(data) && !s.supportsFormat(item.Name, data) {
+		slog.Debug("skipping binary source item", slog.String("source", name), slog.String("document_name", item.Name))
+		if _, err := s.DeleteDocument(ctx, &DeleteDocumentRequest{DocumentName: item.Name}); err != nil {
+			return err
+		}
+	} else {
+		processRes, err := s.ProcessDocument(ctx, &ProcessDocumentRequest{
+			DocumentName: item.Name,
+			DocumentData: data,
+			Metadata:     item.Metadata,
+			ModTime:      item.ModTime,
+			ContentType:  item.ContentType,
+		})
+		if err != nil {
+			return err
+		}
+		if !processRes.Success {
+			return fmt.Errorf("processing %s was not successful", item.Name)
+		}
+		status = processRes.Status
+	}
+	// An item that is now indexed under another name leaves its old document
+	if state != nil && state.DocumentName != item.Name {
+		if _, err := s.DeleteDocument(ctx, &DeleteDocumentRequest{DocumentName: state.DocumentName}); err != nil {
+			return err
+		}
+	}
+
+	switch {
+	case status == StatusSkipped:
+		res.Skipped++
+	case status == StatusUnchanged:
+		res.Unchanged++
+	case state == nil:
+		res.Added++
+	default:
+		res.Updated++
+	}
+	return db.SaveSourceItem(ctx, s.db, &db.SourceItem{
+		Source:       name,
+		ItemID:       item.ID,
+		Version:      item.Version,
+		DocumentName: item.Name,
+		SyncedAt:     time.Now(),
+	})
+}
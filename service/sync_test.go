@@ -0,0 +1,97 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/MaxIvanyshen/local-rag/db"
+	"github.com/MaxIvanyshen/local-rag/source"
+	"gorm.io/gorm"
+)
+
+func TestSyncSources(t *testing.T) {
+	ctx := context.Background()
+
+	dir := t.TempDir()
+	write := func(name, content string) {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+	write("runbook.md", "Restart the ingest workers when the queue backs up.")
+	write("oncall.md", "The on-call rotation changes every Monday.")
+	write("logo.png", "\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+	syncSvc := NewService(&ServiceParameters{
+		DB:       testDB,
+		Embedder: svc.embedder,
+		Chunker:  svc.chunker,
+		Sources: map[string]source.Source{
+			"notes":   &source.FileSystem{Dir: dir},
+			"missing": &source.FileSystem{Dir: filepath.Join(dir, "missing")},
+		},
+		Cfg: svc.cfg,
+	})
+
+	res, err := syncSvc.Sync(ctx, &SyncRequest{Sources: []string{"notes"}})
+	if err != nil {
+		t.Fatalf("failed to sync: %v", err)
+	}
+	// Binary files in formats no extractor handles are skipped
+	if len(res.Sources) != 1 || res.Sources[0].Added != 2 || res.Sources[0].Skipped != 1 || len(res.Sources[0].FailedDocuments) != 0 {
+		t.Fatalf("unexpected first sync result %+v", res.Sources[0])
+	}
+	doc, err := db.GetDocumentByName(ctx, testDB, filepath.Join(dir, "runbook.md"))
+	if err != nil {
+		t.Fatalf("failed to get document: %v", err)
+	}
+	if doc.Metadata["path"] != "runbook.md" {
+		t.Fatalf("expected the path in the metadata, got %v", doc.Metadata)
+	}
+
+	// Unchanged files aren't read again, and removed files are deleted
+	write("runbook.md", "Restart the ingest workers, then drain the queue.")
+	write("escalation.md", "Page the database team for replication lag.")
+	if err := os.Remove(filepath.Join(dir, "oncall.md")); err != nil {
+		t.Fatalf("failed to remove file: %v", err)
+	}
+	res, err = syncSvc.Sync(ctx, &SyncRequest{Sources: []string{"notes"}})
+	if err != nil {
+		t.Fatalf("failed to sync again: %v", err)
+	}
+	got := res.Sources[0]
+	if got.Added != 1 || got.Updated != 1 || got.Unchanged != 1 || got.Deleted != 1 {
+		t.Fatalf("unexpected second sync result %+v", got)
+	}
+	if _, err := db.GetDocumentByName(ctx, testDB, filepath.Join(dir, "oncall.md")); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Fatalf("expected the removed file to be deleted, got %v", err)
+	}
+
+	res, err = syncSvc.Sync(ctx, &SyncRequest{Sources: []string{"notes"}})
+	if err != nil {
+		t.Fatalf("failed to sync again: %v", err)
+	}
+	if got := res.Sources[0]; got.Unchanged != 3 || got.Added+got.Updated+got.Deleted != 0 {
+		t.Fatalf("unexpected unchanged sync result %+v", got)
+	}
+	items, err := db.GetSourceItems(ctx, testDB, "notes")
+	if err != nil || len(items) != 3 {
+		t.Fatalf("expected the sync state of three files, got %v (%v)", items, err)
+	}
+
+	// A source that can't be listed fails on its own, and so do unknown ones
+	res, err = syncSvc.Sync(ctx, &SyncRequest{})
+	if err != nil {
+		t.Fatalf("failed to sync all sources: %v", err)
+	}
+	if len(res.Sources) != 2 || res.Sources[0].Source != "missing" || res.Sources[0].Error == "" || res.Sources[1].Error != "" {
+		t.Fatalf("unexpected result of syncing all sources %+v %+v", res.Sources[0], res.Sources[1])
+	}
+	res, err = syncSvc.Sync(ctx, &SyncRequest{Sources: []string{"wiki"}})
+	if err != nil || res.Sources[0].Error != "unknown source" {
+		t.Fatalf("expected an unknown source error, got %+v %v", res.Sources, err)
+	}
+}
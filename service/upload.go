@@ -0,0 +1,344 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"os"
+	"time"
+	"unicode/utf8"
+
+	"github.com/MaxIvanyshen/local-rag/chunker"
+	"github.com/MaxIvanyshen/local-rag/extractor"
+	"github.com/MaxIvanyshen/local-rag/junk"
+)
+
+// ErrDocumentTooLarge is returned for uploads larger than the configured
+// maximum size.
+var ErrDocumentTooLarge = errors.New("document is too large")
+
+// uploadWindowSize is about how many bytes of an uploaded text are chunked
+// at a time.
+var uploadWindowSize = 1 << 20
+
+// uploadSniffLength is how many bytes of an upload are looked at to tell its
+// format and whether it is junk.
+const uploadSniffLength = junk.SampleSize
+
+type UploadDocumentRequest struct {
+	DocumentName string
+	// Metadata, ModTime, Force and ContentType are as in
+	// ProcessDocumentRequest.
+	Metadata    map[string]any
+	ModTime     *time.Time
+	Force       bool
+	ContentType string
+	// Body is the document data. It is read once, up to its end.
+	Body io.Reader
+}
+
+// UploadDocument processes a document read from a stream, such as the body of
+// a large upload, without holding it in memory. The data is spooled to a
+// temporary file while its content hash is computed and it is checked to be
+// UTF-8 text, and plain text and Markdown are chunked in windows as they are
+// read back. Formats whose extractors need the whole file, such as PDFs and
+// archives, and text in other encodings are read into memory as with
+// ProcessDocument.
+func (s *Service) UploadDocument(ctx context.Context, req *UploadDocumentRequest) (*ProcessDocumentResponse, error) {
+	slog.Info("received upload document request", slog.String("document_name", req.DocumentName))
+
+	f, size, hash, utf8Text, err := s.spoolUpload(req.Body)
+	if err != nil {
+		slog.Error("failed to read uploaded document", slog.String("error", err.Error()), slog.String("document_name", req.DocumentName))
+		return &ProcessDocumentResponse{Success: false}, err
+	}
+	defer func() {
+		f.Close()
+		os.Remove(f.Name())
+	}()
+
+	head := make([]byte, min(size, uploadSniffLength))
+	if _, err := f.ReadAt(head, 0); err != nil {
+		return &ProcessDocumentResponse{Success: false}, err
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return &ProcessDocumentResponse{Success: false}, err
+	}
+	if reason := s.junkReason(req.DocumentName, req.ContentType, head); reason != "" {
+		return s.skipDocument(ctx, req.DocumentName, reason)
+	}
+
+	processReq := &ProcessDocumentRequest{
+		DocumentName: req.DocumentName,
+		Metadata:     req.Metadata,
+		ModTime:      req.ModTime,
+		Force:        req.Force,
+		ContentType:  req.ContentType,
+	}
+	if !req.Force {
+		unchanged, err := s.unchanged(ctx, processReq, hash)
+		if err != nil {
+			slog.Error("failed to look up indexed document", slog.String("error", err.Error()), slog.String("document_name", req.DocumentName))
+			return &ProcessDocumentResponse{Success: false}, err
+		}
+		if unchanged {
+			slog.Info("document is unchanged, skipping", slog.String("document_name", req.DocumentName))
+			return &ProcessDocumentResponse{Success: true, Status: StatusUnchanged}, nil
+		}
+	}
+
+	if windows, ok := s.streamable(s.formatName(req.DocumentName, req.ContentType, head), head, utf8Text); ok {
+		if err := s.streamDocument(ctx, processReq, hash, size, f, windows); err != nil {
+			return &ProcessDocumentResponse{Success: false}, err
+		}
+		slog.Info("successfully processed uploaded document", slog.String("document_name", req.DocumentName), slog.Int64("size", size))
+		return &ProcessDocumentResponse{Success: true, Status: StatusProcessed}, nil
+	}
+
+	// What is read into memory is bounded by the upload size limit, as the
+	// spooled file is
+	processReq.DocumentData = make([]byte, size)
+	if _, err := io.ReadFull(f, processReq.DocumentData); err != nil {
+		return &ProcessDocumentResponse{Success: false}, err
+	}
+	return s.processDocument(ctx, processReq, hash)
+}
+
+// spoolUpload copies an upload to a temporary file, and returns the file
+// with the size and content hash of the data, and whether it is UTF-8 text
+// without NUL bytes.
+func (s *Service) spoolUpload(body io.Reader) (*os.File, int64, string, bool, error) {
+	f, err := os.CreateTemp(s.cfg.Upload.TempDir, "local-rag-upload-*")
+	if err != nil {
+		return nil, 0, "", false, fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	discard := func() {
+		f.Close()
+		os.Remove(f.Name())
+	}
+
+	maxSize := s.cfg.Upload.MaxSize
+	if maxSize > 0 {
+		body = io.LimitReader(body, maxSize+1)
+	}
+	h := sha256.New()
+	text := &textChecker{}
+	size, err := io.Copy(io.MultiWriter(f, h, text), body)
+	if err != nil {
+		discard()
+		return nil, 0, "", false, err
+	}
+	if maxSize > 0 && size > maxSize {
+		discard()
+		return nil, 0, "", false, fmt.Errorf("%w: larger than %d bytes", ErrDocumentTooLarge, maxSize)
+	}
+	return f, size, hex.EncodeToString(h.Sum(nil)), text.valid(), nil
+}
+
+// textChecker checks whether the data written to it is UTF-8 text without
+// NUL bytes, so the way an upload is processed is chosen before any of it is
+// embedded.
+type textChecker struct {
+	// partial is a rune split at the end of the last write.
+	partial []byte
+	invalid bool
+}
+
+func (c *textChecker) Write(p []byte) (int, error) {
+	if c.invalid {
+		return len(p), nil
+	}
+	data := p
+	if len(c.partial) > 0 {
+		data = append(c.partial, p...)
+	}
+	end := runeBoundary(data)
+	if !utf8.Valid(data[:end]) || bytes.IndexByte(data, 0) >= 0 {
+		c.invalid = true
+		return len(p), nil
+	}
+	c.partial = append(c.partial[:0:0], data[end:]...)
+	return len(p), nil
+}
+
+// valid reports whether all of the data written was UTF-8 text without NUL
+// bytes.
+func (c *textChecker) valid() bool {
+	return !c.invalid && len(c.partial) == 0
+}
+
+// streamable reports whether an upload is chunked as it is read, which
+// plain UTF-8 text is, and text in a format with a WindowExtractor such as
+// Markdown, which is returned. Other formats are read whole, as their
+// extractors need all of the data, and so is text in other encodings, which
+// is decoded whole, and everything when table-aware chunking needs the header
+// row of a table for every chunk.
+func (s *Service) streamable(name string, head []byte, utf8Text bool) (extractor.WindowExtractor, bool) {
+	if _, ok := s.chunker.(*chunker.TableChunker); ok {
+		return nil, false
+	}
+	if !utf8Text || extractor.IsBinary(head) {
+		return nil, false
+	}
+	switch e := s.extractor.(type) {
+	case nil:
+		return nil, true
+	case *extractor.Registry:
+		found := e.Lookup(name, head)
+		if found == nil {
+			return nil, true
+		}
+		windows, ok := found.(extractor.WindowExtractor)
+		return windows, ok
+	default:
+		if !e.Match(name, head) {
+			return nil, true
+		}
+		windows, ok := e.(extractor.WindowExtractor)
+		return windows, ok
+	}
+}
+
+// streamDocument stores UTF-8 text read from r, chunking it one window at a
+// time. Chunks keep their lines, offsets and headings in the whole text,
+// though the chunk overlap doesn't reach across windows. Text in a format
+// with a WindowExtractor is extracted by it a window at a time, otherwise it
+// is plain text.
+func (s *Service) streamDocument(ctx context.Context, req *ProcessDocumentRequest, hash string, size int64, r io.Reader, windowExtractor extractor.WindowExtractor) error {
+	windows := &textWindows{r: r, size: uploadWindowSize}
+	first, err := windows.next()
+	if err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+
+	// The first window stands in for the text when a title is looked for
+	extracted := &extractor.Document{
+		Sections: []extractor.Section{{Text: first}},
+		Metadata: map[string]any{"encoding": extractor.EncodingUTF8},
+	}
+	if windowExtractor != nil {
+		extracted, err = windowExtractor.Extract(req.DocumentName, first)
+		if err != nil {
+			return err
+		}
+	}
+	if len(req.Metadata) > 0 {
+		if extracted.Metadata == nil {
+			extracted.Metadata = make(map[string]any, len(req.Metadata))
+		}
+		maps.Copy(extracted.Metadata, req.Metadata)
+	}
+
+	sections := func(save sectionSaver) error {
+		window, section := first, extracted.Sections[0]
+		lines, offset := 0, 0
+		for len(window) > 0 {
+			if err := save(section, lines, offset); err != nil {
+				return err
+			}
+			lines += bytes.Count(window, []byte("\n"))
+			offset += len(window)
+
+			next, err := windows.next()
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			window, section = next, extractor.Section{Text: next}
+			if windowExtractor != nil {
+				section = windowExtractor.ExtractWindow(extracted, window)
+			}
+		}
+		return nil
+	}
+	if err := s.saveDocument(ctx, req, hash, size, extracted, sections); err != nil {
+		return err
+	}
+	// The file now holds a single document
+	return s.deleteRemovedDocuments(ctx, req.DocumentName, nil)
+}
+
+// textWindows reads a text in windows of about size bytes, cut after a
+// paragraph break where there is one so that windows chunk like the whole
+// text would, or else after a line break.
+type textWindows struct {
+	r    io.Reader
+	size int
+	// pending is what was read past the end of the last window.
+	pending []byte
+	eof     bool
+}
+
+// next returns the next window, or io.EOF after the last one.
+func (w *textWindows) next() ([]byte, error) {
+	if !w.eof && len(w.pending) < w.size {
+		buf := make([]byte, len(w.pending), w.size)
+		copy(buf, w.pending)
+		n, err := io.ReadFull(w.r, buf[len(buf):w.size])
+		w.pending = buf[:len(buf)+n]
+		switch {
+		case errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF):
+			w.eof = true
+		case err != nil:
+			return nil, err
+		}
+	}
+	if len(w.pending) == 0 {
+		return nil, io.EOF
+	}
+
+	end := len(w.pending)
+	if !w.eof {
+		end = windowEnd(w.pending)
+	}
+	window := w.pending[:end]
+	w.pending = w.pending[end:]
+	return window, nil
+}
+
+// windowEnd returns where a window of data ends: after its last paragraph
+// break in its second half, or else after its last line break, or else
+// before a rune split at its end.
+func windowEnd(data []byte) int {
+	for i := len(data) - 1; i >= len(data)/2; i-- {
+		if data[i] != '\n' || i == 0 {
+			continue
+		}
+		// Paragraphs may be separated by CRLF line breaks
+		j := i - 1
+		if data[j] == '\r' && j > 0 {
+			j--
+		}
+		if data[j] == '\n' {
+			return i + 1
+		}
+	}
+	if i := bytes.LastIndexByte(data, '\n'); i >= 0 {
+		return i + 1
+	}
+	if end := runeBoundary(data); end > 0 {
+		return end
+	}
+	return len(data)
+}
+
+// runeBoundary returns the length of data without a rune split at its end.
+func runeBoundary(data []byte) int {
+	for i := len(data) - 1; i >= 0 && i >= len(data)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(data[i]) {
+			if !utf8.FullRune(data[i:]) {
+				return i
+			}
+			break
+		}
+	}
+	return len(data)
+}
@@ -0,0 +1,316 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/MaxIvanyshen/local-rag/chunker"
+	"github.com/MaxIvanyshen/local-rag/db"
+	"github.com/MaxIvanyshen/local-rag/extractor"
+)
+
+func TestUploadDocumentStreamsText(t *testing.T) {
+	ctx := context.Background()
+
+	// Windows larger than a paragraph but much smaller than the text
+	windowSize := uploadWindowSize
+	uploadWindowSize = 200
+	t.Cleanup(func() { uploadWindowSize = windowSize })
+
+	// A byte order mark and CRLF line breaks don't shift the offsets, and the
+	// heading applies to the chunks of later windows
+	var sb strings.Builder
+	sb.WriteString("\ufeff# Upload log\r\n\r\n")
+	for i := range 20 {
+		fmt.Fprintf(&sb, "Entry %d: the nightly job finished with naïve timing.\nSecond line of entry %d.\n\n", i, i)
+	}
+	text := []byte(sb.String())
+
+	contextHeader, err := chunker.NewContextHeader("Section: {{.HeadingPath}}\n\n")
+	if err != nil {
+		t.Fatalf("failed to create context header: %v", err)
+	}
+	uploadSvc := NewService(&ServiceParameters{
+		DB:            testDB,
+		Embedder:      svc.embedder,
+		Chunker:       svc.chunker,
+		ContextHeader: contextHeader,
+		Cfg:           svc.cfg,
+	})
+	if _, err := uploadSvc.ProcessDocument(ctx, &ProcessDocumentRequest{DocumentName: "upload-whole.txt", DocumentData: text}); err != nil {
+		t.Fatalf("failed to process document: %v", err)
+	}
+	res, err := uploadSvc.UploadDocument(ctx, &UploadDocumentRequest{
+		DocumentName: "upload-streamed.txt",
+		Metadata:     map[string]any{"source": "upload"},
+		Body:         bytes.NewReader(text),
+	})
+	if err != nil || !res.Success || res.Status != StatusProcessed {
+		t.Fatalf("failed to upload document: %+v %v", res, err)
+	}
+
+	whole, err := db.GetDocumentByName(ctx, testDB, "upload-whole.txt")
+	if err != nil {
+		t.Fatalf("failed to get document: %v", err)
+	}
+	streamed, err := db.GetDocumentByName(ctx, testDB, "upload-streamed.txt")
+	if err != nil {
+		t.Fatalf("failed to get document: %v", err)
+	}
+	if streamed.ContentHash != ContentHash(text) || streamed.Size != int64(len(text)) {
+		t.Fatalf("expected the content hash and size of the upload, got %q and %d", streamed.ContentHash, streamed.Size)
+	}
+	if streamed.Metadata["source"] != "upload" || streamed.Metadata["encoding"] != "utf-8" {
+		t.Fatalf("unexpected metadata %v", streamed.Metadata)
+	}
+
+	// Chunks are the same as when the text is processed whole
+	wholeChunks, err := db.GetChunksByDocumentID(ctx, testDB, whole.ID)
+	if err != nil {
+		t.Fatalf("failed to get chunks: %v", err)
+	}
+	streamedChunks, err := db.GetChunksByDocumentID(ctx, testDB, streamed.ID)
+	if err != nil {
+		t.Fatalf("failed to get chunks: %v", err)
+	}
+	if len(streamedChunks) != len(wholeChunks) {
+		t.Fatalf("expected %d chunks, got %d", len(wholeChunks), len(streamedChunks))
+	}
+	for i, w := range wholeChunks {
+		c := streamedChunks[i]
+		if string(c.Data) != string(w.Data) || c.StartLine != w.StartLine || c.EndLine != w.EndLine || c.StartByte != w.StartByte || c.EndByte != w.EndByte || c.StartColumn != w.StartColumn || c.ContentHash != w.ContentHash {
+			t.Fatalf("chunk %d differs: streamed %+v, whole %+v", i, c, w)
+		}
+	}
+
+	last := streamedChunks[len(streamedChunks)-1]
+	if string(text[last.StartByte:last.EndByte]) != string(last.Data) {
+		t.Fatalf("expected bytes %d-%d of the upload to hold the last chunk, got %q", last.StartByte, last.EndByte, text[last.StartByte:last.EndByte])
+	}
+
+	res, err = uploadSvc.UploadDocument(ctx, &UploadDocumentRequest{DocumentName: "upload-streamed.txt", Body: bytes.NewReader(text)})
+	if err != nil || res.Status != StatusUnchanged {
+		t.Fatalf("expected the same upload to be unchanged, got %+v %v", res, err)
+	}
+}
+
+func TestUploadDocumentStreamsMarkdown(t *testing.T) {
+	ctx := context.Background()
+
+	windowSize := uploadWindowSize
+	uploadWindowSize = 200
+	t.Cleanup(func() { uploadWindowSize = windowSize })
+
+	// The front matter is in the first window and a link in the last one
+	var sb strings.Builder
+	sb.WriteString("---\ntitle: Release Notes\ntags: [release]\n---\n\n# Changes\n\n")
+	for i := range 20 {
+		fmt.Fprintf(&sb, "Change %d: the exporter writes smaller files.\n\n", i)
+	}
+	sb.WriteString("See [[Upgrade Guide]] before upgrading.\n")
+	text := []byte(sb.String())
+
+	notesSvc := newExtractorService(&extractor.MarkdownExtractor{})
+	if _, ok := notesSvc.streamable("upload-notes.md", text, true); !ok {
+		t.Fatalf("expected Markdown to be streamed")
+	}
+	if _, err := notesSvc.ProcessDocument(ctx, &ProcessDocumentRequest{DocumentName: "upload-notes-whole.md", DocumentData: text}); err != nil {
+		t.Fatalf("failed to process document: %v", err)
+	}
+	res, err := notesSvc.UploadDocument(ctx, &UploadDocumentRequest{DocumentName: "upload-notes.md", Body: bytes.NewReader(text)})
+	if err != nil || !res.Success {
+		t.Fatalf("failed to upload document: %+v %v", res, err)
+	}
+
+	whole, err := db.GetDocumentByName(ctx, testDB, "upload-notes-whole.md")
+	if err != nil {
+		t.Fatalf("failed to get document: %v", err)
+	}
+	streamed, err := db.GetDocumentByName(ctx, testDB, "upload-notes.md")
+	if err != nil {
+		t.Fatalf("failed to get document: %v", err)
+	}
+	if !reflect.DeepEqual(streamed.Metadata, whole.Metadata) || streamed.Metadata["title"] != "Release Notes" {
+		t.Fatalf("expected the metadata of the whole note, got %v and %v", streamed.Metadata, whole.Metadata)
+	}
+
+	wholeChunks, err := db.GetChunksByDocumentID(ctx, testDB, whole.ID)
+	if err != nil {
+		t.Fatalf("failed to get chunks: %v", err)
+	}
+	streamedChunks, err := db.GetChunksByDocumentID(ctx, testDB, streamed.ID)
+	if err != nil {
+		t.Fatalf("failed to get chunks: %v", err)
+	}
+	if len(streamedChunks) != len(wholeChunks) {
+		t.Fatalf("expected %d chunks, got %d", len(wholeChunks), len(streamedChunks))
+	}
+	for i, w := range wholeChunks {
+		c := streamedChunks[i]
+		if string(c.Data) != string(w.Data) || c.StartLine != w.StartLine || c.StartByte != w.StartByte || c.EndByte != w.EndByte {
+			t.Fatalf("chunk %d differs: streamed %+v, whole %+v", i, c, w)
+		}
+	}
+}
+
+func TestUploadDocumentFallsBackForLateInvalidText(t *testing.T) {
+	ctx := context.Background()
+
+	windowSize := uploadWindowSize
+	uploadWindowSize = 32 << 10
+	t.Cleanup(func() { uploadWindowSize = windowSize })
+
+	// Latin-1 text past the sniffed start is found while the upload is
+	// spooled, so it is decoded whole rather than streamed
+	text := []byte(strings.Repeat("Plain line of the menu.\n", 3000) + "\nCaf\xe9 au lait.\n")
+	res, err := svc.UploadDocument(ctx, &UploadDocumentRequest{DocumentName: "upload-late-latin1.txt", Body: bytes.NewReader(text)})
+	if err != nil || !res.Success {
+		t.Fatalf("failed to upload document: %+v %v", res, err)
+	}
+
+	doc, err := db.GetDocumentByName(ctx, testDB, "upload-late-latin1.txt")
+	if err != nil {
+		t.Fatalf("failed to get document: %v", err)
+	}
+	if doc.Metadata["encoding"] != "iso-8859-1" || doc.ContentHash != ContentHash(text) {
+		t.Fatalf("expected the document decoded whole, got %+v", doc)
+	}
+	chunks, err := db.GetChunksByDocumentID(ctx, testDB, doc.ID)
+	if err != nil {
+		t.Fatalf("failed to get chunks: %v", err)
+	}
+	if !strings.Contains(string(chunks[len(chunks)-1].Data), "Café au lait.") {
+		t.Fatalf("expected the last chunk converted to UTF-8, got %q", chunks[len(chunks)-1].Data)
+	}
+}
+
+func TestTextChecker(t *testing.T) {
+	for _, tc := range []struct {
+		writes []string
+		valid  bool
+	}{
+		// A rune split between writes
+		{[]string{"caf\xc3", "\xa9 au lait"}, true},
+		{[]string{"caf\xc3"}, false},
+		{[]string{"caf\xe9 au lait"}, false},
+		{[]string{"text", "with\x00NUL"}, false},
+	} {
+		c := &textChecker{}
+		for _, w := range tc.writes {
+			c.Write([]byte(w))
+		}
+		if c.valid() != tc.valid {
+			t.Fatalf("expected valid %v for %q", tc.valid, tc.writes)
+		}
+	}
+}
+
+func TestTextWindows(t *testing.T) {
+	// A line longer than a window is cut between runes
+	text := strings.Repeat("żółw ", 40) + "\nend\n"
+	w := &textWindows{r: strings.NewReader(text), size: 16}
+	var joined strings.Builder
+	for {
+		window, err := w.next()
+		if err != nil {
+			break
+		}
+		if len(window) > 16 || !utf8.Valid(window) {
+			t.Fatalf("unexpected window %q", window)
+		}
+		joined.Write(window)
+	}
+	if joined.String() != text {
+		t.Fatalf("windows don't add up to the text: %q", joined.String())
+	}
+}
+
+func TestUploadDocumentHandler(t *testing.T) {
+	cfg := *svc.cfg
+	cfg.Upload.MaxSize = 1024
+	uploadSvc := NewService(&ServiceParameters{
+		DB:       testDB,
+		Embedder: svc.embedder,
+		Chunker:  svc.chunker,
+		Cfg:      &cfg,
+	})
+	mux := http.NewServeMux()
+	uploadSvc.RegisterRoutes(mux)
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	// Multipart upload with the fields before the file
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	mw.WriteField("metadata", `{"team": "infra"}`)
+	fw, err := mw.CreateFormFile("file", "upload-form.txt")
+	if err != nil {
+		t.Fatalf("failed to create form file: %v", err)
+	}
+	fw.Write([]byte("Runbook for the upload endpoint.\n"))
+	mw.Close()
+	resp, err := http.Post(srv.URL+"/api/upload_document", mw.FormDataContentType(), &body)
+	if err != nil {
+		t.Fatalf("failed to upload: %v", err)
+	}
+	var res ProcessDocumentResponse
+	json.NewDecoder(resp.Body).Decode(&res)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || !res.Success {
+		t.Fatalf("unexpected multipart upload response %d %+v", resp.StatusCode, res)
+	}
+	doc, err := db.GetDocumentByName(context.Background(), testDB, "upload-form.txt")
+	if err != nil {
+		t.Fatalf("failed to get document: %v", err)
+	}
+	if doc.Metadata["team"] != "infra" {
+		t.Fatalf("expected form metadata, got %v", doc.Metadata)
+	}
+
+	// Raw body with the name in a header
+	req, _ := http.NewRequest(http.MethodPost, srv.URL+"/api/upload_document?mod_time=2026-04-01T10:00:00Z", strings.NewReader("Raw upload.\n"))
+	req.Header.Set("X-Document-Name", "upload-raw.txt")
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("failed to upload: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("unexpected raw upload status %d", resp.StatusCode)
+	}
+	doc, err = db.GetDocumentByName(context.Background(), testDB, "upload-raw.txt")
+	if err != nil {
+		t.Fatalf("failed to get document: %v", err)
+	}
+	if doc.ModTime == nil || doc.ModTime.Year() != 2026 {
+		t.Fatalf("expected mod time from the query, got %v", doc.ModTime)
+	}
+
+	for _, tc := range []struct {
+		query  string
+		body   string
+		status int
+	}{
+		{"", "no name", http.StatusBadRequest},
+		{"?name=bad.txt&metadata=[1", "bad metadata", http.StatusBadRequest},
+		{"?name=big.txt", strings.Repeat("x", 2000), http.StatusRequestEntityTooLarge},
+	} {
+		resp, err := http.Post(srv.URL+"/api/upload_document"+tc.query, "text/plain", strings.NewReader(tc.body))
+		if err != nil {
+			t.Fatalf("failed to upload: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != tc.status {
+			t.Fatalf("expected status %d for %q, got %d", tc.status, tc.query, resp.StatusCode)
+		}
+	}
+}
@@ -0,0 +1,28 @@
+package service
+
+import (
+	"context"
+	"fmt"
+)
+
+// ProcessFile processes a file reported by a directory watcher.
+func (s *Service) ProcessFile(ctx context.Context, name string, data []byte) error {
+	res, err := s.ProcessDocument(ctx, &ProcessDocumentRequest{
+		DocumentName: name,
+		DocumentData: data,
+	})
+	if err != nil {
+		return err
+	}
+	if !res.Success {
+		return fmt.Errorf("processing %s was not successful", name)
+	}
+	return nil
+}
+
+// DeleteFile deletes the documents of a file a directory watcher reported as
+// removed.
+func (s *Service) DeleteFile(ctx context.Context, name string) error {
+	_, err := s.DeleteDocument(ctx, &DeleteDocumentRequest{DocumentName: name})
+	return err
+}
@@ -0,0 +1,67 @@
+package source
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/MaxIvanyshen/local-rag/ignore"
+)
+
+// FileSystem is a directory tree. Its files are selected like rag ingest
+// selects them, see ignore.Filter.
+type FileSystem struct {
+	// Dir is the root of the tree.
+	Dir string
+	// Include and Exclude are globs in .gitignore syntax relative to Dir.
+	// When Include is empty, every file not excluded is listed.
+	Include []string
+	Exclude []string
+}
+
+// List returns the files of the tree, named by their absolute paths, with
+// their modification time and size as the version.
+func (f *FileSystem) List(ctx context.Context) ([]Item, error) {
+	root, err := filepath.Abs(f.Dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var items []Item
+	err = ignore.NewFilter(root, f.Include, f.Exclude).Walk(root, func(path, rel string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		modTime := info.ModTime()
+		items = append(items, Item{
+			ID:       rel,
+			Version:  fmt.Sprintf("%d-%d", modTime.UnixNano(), info.Size()),
+			Name:     path,
+			Metadata: map[string]any{"dir": root, "path": rel},
+			ModTime:  &modTime,
+			location: path,
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %s: %w", f.Dir, err)
+	}
+	return items, nil
+}
+
+func (f *FileSystem) Read(ctx context.Context, item Item) ([]byte, error) {
+	return os.ReadFile(item.location)
+}
@@ -0,0 +1,91 @@
+package source
+
+import (
+	"context"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/MaxIvanyshen/local-rag/gitrepo"
+)
+
+// Git is a local git repository at a ref. Only committed regular files are
+// listed. Their documents carry the repository path, commit SHA and path
+// in their metadata.
+type Git struct {
+	// Path is the root of the repository or a directory in it. The files
+	// of the whole repository are listed either way.
+	Path string
+	// Ref is the branch, tag or commit listed. It defaults to HEAD.
+	Ref string
+	// SkipDirs are names of directories whose files are left out, such as
+	// vendored dependencies.
+	SkipDirs []string
+	// MaxFileSize leaves out larger files when it isn't zero.
+	MaxFileSize int64
+}
+
+// List returns the files of the repository at the ref, named by their
+// absolute paths, with the SHA of their blob as the version.
+func (g *Git) List(ctx context.Context) ([]Item, error) {
+	repo, err := gitrepo.Open(ctx, g.Path)
+	if err != nil {
+		return nil, err
+	}
+	ref := g.Ref
+	if ref == "" {
+		ref = "HEAD"
+	}
+	commit, err := repo.ResolveRef(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+	files, err := repo.Files(ctx, commit)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]Item, 0, len(files))
+	for _, f := range files {
+		if g.skipped(f) {
+			continue
+		}
+		items = append(items, Item{
+			ID:       f.Path,
+			Version:  f.Blob,
+			Name:     filepath.Join(repo.Path, filepath.FromSlash(f.Path)),
+			Metadata: map[string]any{"repo": repo.Path, "commit": commit, "path": f.Path},
+			location: commit,
+		})
+	}
+	return items, nil
+}
+
+func (g *Git) skipped(f gitrepo.File) bool {
+	if g.MaxFileSize > 0 && f.Size > g.MaxFileSize {
+		return true
+	}
+	dirs := strings.Split(f.Path, "/")
+	for _, dir := range dirs[:len(dirs)-1] {
+		if slices.Contains(g.SkipDirs, dir) {
+			return true
+		}
+	}
+	return false
+}
+
+// Stamp sets the commit listed on the documents of the repository.
+func (g *Git) Stamp(items []Item) (map[string]any, map[string]any) {
+	if len(items) == 0 {
+		return nil, nil
+	}
+	return map[string]any{"repo": items[0].Metadata["repo"]}, map[string]any{"commit": items[0].Metadata["commit"]}
+}
+
+func (g *Git) Read(ctx context.Context, item Item) ([]byte, error) {
+	repo, err := gitrepo.Open(ctx, g.Path)
+	if err != nil {
+		return nil, err
+	}
+	return repo.ReadFile(ctx, item.location, item.ID)
+}
@@ -0,0 +1,102 @@
+package source
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// HTTP is a set of web pages or files, fetched from their URLs.
+type HTTP struct {
+	URLs []string
+	// UserAgent is sent with the requests when set.
+	UserAgent string
+	// MaxSize fails reading larger responses when it isn't zero.
+	MaxSize int64
+	// Client defaults to http.DefaultClient.
+	Client *http.Client
+}
+
+// List asks for the headers of every URL, and uses the ETag or
+// Last-Modified header as the version. URLs answered with 404 or 410 are
+// left out, so their documents are deleted, while other failures fail the
+// listing.
+func (h *HTTP) List(ctx context.Context) ([]Item, error) {
+	var items []Item
+	for _, url := range h.URLs {
+		resp, err := h.do(ctx, http.MethodHead, url)
+		if err != nil {
+			return nil, err
+		}
+		resp.Body.Close()
+
+		switch {
+		case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone:
+			continue
+		case resp.StatusCode == http.StatusMethodNotAllowed || resp.StatusCode == http.StatusNotImplemented:
+			// Without HEAD the URL is read every time
+			items = append(items, Item{ID: url, Name: url, Metadata: map[string]any{"url": url}, location: url})
+			continue
+		case resp.StatusCode < 200 || resp.StatusCode > 299:
+			return nil, fmt.Errorf("failed to list %s: status %d", url, resp.StatusCode)
+		}
+
+		item := Item{
+			ID:          url,
+			Name:        url,
+			Metadata:    map[string]any{"url": url},
+			ContentType: resp.Header.Get("Content-Type"),
+			location:    url,
+		}
+		if etag := resp.Header.Get("ETag"); etag != "" {
+			item.Version = etag
+		} else if lastModified := resp.Header.Get("Last-Modified"); lastModified != "" {
+			item.Version = lastModified + " " + resp.Header.Get("Content-Length")
+		}
+		if modTime, err := http.ParseTime(resp.Header.Get("Last-Modified")); err == nil {
+			item.ModTime = &modTime
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+func (h *HTTP) Read(ctx context.Context, item Item) ([]byte, error) {
+	resp, err := h.do(ctx, http.MethodGet, item.location)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to read %s: status %d", item.location, resp.StatusCode)
+	}
+
+	var body io.Reader = resp.Body
+	if h.MaxSize > 0 {
+		body = io.LimitReader(resp.Body, h.MaxSize+1)
+	}
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return nil, err
+	}
+	if h.MaxSize > 0 && int64(len(data)) > h.MaxSize {
+		return nil, fmt.Errorf("%s is larger than %d bytes", item.location, h.MaxSize)
+	}
+	return data, nil
+}
+
+func (h *HTTP) do(ctx context.Context, method, url string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if h.UserAgent != "" {
+		req.Header.Set("User-Agent", h.UserAgent)
+	}
+	client := h.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return client.Do(req)
+}
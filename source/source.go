@@ -0,0 +1,52 @@
+// Package source lists the documents of places such as a directory, a git
+// repository or a set of web pages, with versions that tell when they
+// changed, so that the index can be kept in sync with them.
+package source
+
+import (
+	"context"
+	"time"
+)
+
+// Item is a document held by a source.
+type Item struct {
+	// ID identifies the item within its source and stays the same between
+	// listings, such as the path of a file relative to its directory.
+	ID string
+	// Version changes whenever the content of the item does, such as the
+	// modification time and size of a file or the SHA of a git blob. Items
+	// without a version are read on every sync.
+	Version string
+	// Name is the name of the document indexed from the item.
+	Name string
+	// Metadata is stored with the document, such as the path of the file.
+	Metadata map[string]any
+	// ModTime and ContentType are optional, as in a process document
+	// request.
+	ModTime     *time.Time
+	ContentType string
+
+	// location is where the source reads the item from.
+	location string
+}
+
+// Source is a connector to a place documents are indexed from.
+type Source interface {
+	// List returns the items the source holds now. Items missing from the
+	// list are taken as deleted, so an error is returned rather than a
+	// partial list.
+	List(ctx context.Context) ([]Item, error)
+	// Read returns the content of an item returned by List.
+	Read(ctx context.Context, item Item) ([]byte, error)
+}
+
+// Stamper is a source whose documents share metadata that changes without
+// their content, such as the commit of a git repository. As unchanged items
+// aren't processed again, the metadata is set on all the documents of the
+// source once a sync has indexed every item.
+type Stamper interface {
+	Source
+	// Stamp returns the metadata selecting the documents of the listed
+	// items, and the metadata to set on them.
+	Stamp(items []Item) (match, stamp map[string]any)
+}
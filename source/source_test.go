@@ -0,0 +1,135 @@
+package source
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func writeFile(t *testing.T, dir, name, content string) {
+	path := filepath.Join(dir, name)
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+}
+
+func itemIDs(items []Item) []string {
+	ids := make([]string, len(items))
+	for i, item := range items {
+		ids[i] = item.ID
+	}
+	return ids
+}
+
+func TestFileSystem(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "notes/todo.md", "Buy milk\n")
+	writeFile(t, dir, "notes/draft.md", "Unfinished\n")
+	writeFile(t, dir, "notes/.ragignore", "draft.md\n")
+	writeFile(t, dir, ".hidden/secret.md", "Hidden\n")
+	writeFile(t, dir, "build/out.txt", "Generated\n")
+	writeFile(t, dir, "readme.txt", "Readme\n")
+
+	fsys := &FileSystem{Dir: dir, Exclude: []string{"build/"}}
+	items, err := fsys.List(t.Context())
+	require.NoError(t, err)
+	require.Equal(t, []string{"notes/todo.md", "readme.txt"}, itemIDs(items))
+	require.Equal(t, filepath.Join(dir, "notes", "todo.md"), items[0].Name)
+	require.Equal(t, "notes/todo.md", items[0].Metadata["path"])
+
+	data, err := fsys.Read(t.Context(), items[0])
+	require.NoError(t, err)
+	require.Equal(t, "Buy milk\n", string(data))
+
+	// The version changes with the file
+	version := items[0].Version
+	writeFile(t, dir, "notes/todo.md", "Buy milk and eggs\n")
+	items, err = fsys.List(t.Context())
+	require.NoError(t, err)
+	require.NotEqual(t, version, items[0].Version)
+
+	items, err = (&FileSystem{Dir: dir, Include: []string{"*.md"}}).List(t.Context())
+	require.NoError(t, err)
+	require.Equal(t, []string{"notes/todo.md"}, itemIDs(items))
+
+	_, err = (&FileSystem{Dir: filepath.Join(dir, "missing")}).List(t.Context())
+	require.Error(t, err)
+}
+
+func TestGit(t *testing.T) {
+	dir := t.TempDir()
+	git := func(args ...string) {
+		out, err := exec.Command("git", append([]string{"-C", dir, "-c", "user.name=Test", "-c", "user.email=test@example.com"}, args...)...).CombinedOutput()
+		require.NoError(t, err, string(out))
+	}
+	git("init", "-q", "-b", "main")
+	writeFile(t, dir, "README.md", "# Readme\n")
+	writeFile(t, dir, "vendor/lib/lib.go", "package lib\n")
+	writeFile(t, dir, "big.txt", "0123456789\n")
+	writeFile(t, dir, "uncommitted.md", "Not yet\n")
+	git("add", "README.md", "vendor", "big.txt")
+	git("commit", "-q", "-m", "first")
+
+	repo := &Git{Path: dir, SkipDirs: []string{"vendor"}, MaxFileSize: 10}
+	items, err := repo.List(t.Context())
+	require.NoError(t, err)
+	require.Equal(t, []string{"README.md"}, itemIDs(items))
+	root, err := filepath.EvalSymlinks(dir)
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join(root, "README.md"), items[0].Name)
+
+	data, err := repo.Read(t.Context(), items[0])
+	require.NoError(t, err)
+	require.Equal(t, "# Readme\n", string(data))
+
+	version := items[0].Version
+	writeFile(t, dir, "README.md", "# Read me\n")
+	git("commit", "-q", "-am", "second")
+	items, err = repo.List(t.Context())
+	require.NoError(t, err)
+	require.NotEqual(t, version, items[0].Version)
+}
+
+func TestHTTP(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/etag":
+			w.Header().Set("ETag", `"v1"`)
+			w.Header().Set("Content-Type", "text/html")
+			fmt.Fprint(w, "<p>Tagged</p>")
+		case "/no-head":
+			if r.Method == http.MethodHead {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+				return
+			}
+			fmt.Fprint(w, "Always read")
+		case "/broken":
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	h := &HTTP{URLs: []string{srv.URL + "/etag", srv.URL + "/no-head", srv.URL + "/gone"}}
+	items, err := h.List(t.Context())
+	require.NoError(t, err)
+	// Missing pages aren't listed
+	require.Equal(t, []string{srv.URL + "/etag", srv.URL + "/no-head"}, itemIDs(items))
+	require.Equal(t, `"v1"`, items[0].Version)
+	require.Equal(t, "text/html", items[0].ContentType)
+	require.Empty(t, items[1].Version)
+
+	data, err := h.Read(t.Context(), items[1])
+	require.NoError(t, err)
+	require.Equal(t, "Always read", string(data))
+
+	// A failing page fails the listing, so its document isn't deleted
+	_, err = (&HTTP{URLs: []string{srv.URL + "/broken"}}).List(t.Context())
+	require.Error(t, err)
+}
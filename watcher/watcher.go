@@ -0,0 +1,286 @@
+// Package watcher keeps the documents of directory trees in sync with the
+// files on disk, processing files when they are created or modified and
+// deleting their documents when they are removed or renamed away.
+package watcher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/MaxIvanyshen/local-rag/extractor"
+	"github.com/MaxIvanyshen/local-rag/ignore"
+	"github.com/fsnotify/fsnotify"
+)
+
+// DefaultDebounce is how long a file has to stay unchanged before it is
+// processed when Options.Debounce is zero.
+const DefaultDebounce = 500 * time.Millisecond
+
+// Handler indexes the files reported by a Watcher. Documents are named by
+// the path of the file, joined to the root it was found under.
+type Handler interface {
+	ProcessFile(ctx context.Context, name string, data []byte) error
+	DeleteFile(ctx context.Context, name string) error
+}
+
+type Options struct {
+	// Debounce is how long a path has to go without events before it is
+	// handled, so a burst of saves processes the file once.
+	Debounce time.Duration
+	// Supports reports whether a binary file is in a format the handler
+	// extracts text from. Other binary files are skipped. When nil, all
+	// binary files are skipped.
+	Supports func(name string, data []byte) bool
+}
+
+// Watcher watches directory trees and calls its handler for the files that
+// changed. Like rag ingest, it skips the files ignore.Filter leaves out and
+// binary files in unsupported formats.
+type Watcher struct {
+	roots    []string
+	handler  Handler
+	debounce time.Duration
+	supports func(name string, data []byte) bool
+
+	fs *fsnotify.Watcher
+	// filters select the files of each root
+	filters map[string]*ignore.Filter
+	// dirs are the watched directories, to recognise a removed directory
+	// that can no longer be stat'ed.
+	dirs map[string]bool
+	// known are the files handed to the handler, whose documents are deleted
+	// when the file goes away.
+	known   map[string]bool
+	pending map[string]*time.Timer
+	ready   chan string
+	done    chan struct{}
+}
+
+// New starts watching the roots. Files already in them are treated as
+// indexed and handled once they change.
+func New(roots []string, handler Handler, opts Options) (*Watcher, error) {
+	if len(roots) == 0 {
+		return nil, errors.New("no directories to watch")
+	}
+	fsw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+
+	w := &Watcher{
+		handler:  handler,
+		debounce: opts.Debounce,
+		supports: opts.Supports,
+		fs:       fsw,
+		filters:  make(map[string]*ignore.Filter),
+		dirs:     make(map[string]bool),
+		known:    make(map[string]bool),
+		pending:  make(map[string]*time.Timer),
+		ready:    make(chan string),
+		done:     make(chan struct{}),
+	}
+	if w.debounce <= 0 {
+		w.debounce = DefaultDebounce
+	}
+
+	for _, root := range roots {
+		root = filepath.Clean(root)
+		info, err := os.Stat(root)
+		if err == nil && !info.IsDir() {
+			err = fmt.Errorf("%s is not a directory", root)
+		}
+		if err != nil {
+			fsw.Close()
+			return nil, err
+		}
+		w.roots = append(w.roots, root)
+		w.filters[root] = ignore.NewFilter(root, nil, nil)
+	}
+	for _, root := range w.roots {
+		w.addTree(root, false)
+	}
+	return w, nil
+}
+
+// Run handles file changes until the context is cancelled, then stops
+// watching.
+func (w *Watcher) Run(ctx context.Context) error {
+	defer w.close()
+	slog.Info("watching directories for changes", slog.Any("dirs", w.roots), slog.Int("watched_dirs", len(w.dirs)))
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-w.fs.Events:
+			if !ok {
+				return nil
+			}
+			w.handleEvent(event)
+		case err, ok := <-w.fs.Errors:
+			if !ok {
+				return nil
+			}
+			slog.Warn("file watcher error", slog.String("error", err.Error()))
+		case path := <-w.ready:
+			delete(w.pending, path)
+			w.sync(ctx, path)
+		}
+	}
+}
+
+func (w *Watcher) close() {
+	close(w.done)
+	for _, t := range w.pending {
+		t.Stop()
+	}
+	w.fs.Close()
+}
+
+func (w *Watcher) handleEvent(event fsnotify.Event) {
+	path := event.Name
+	if event.Has(fsnotify.Remove) || event.Has(fsnotify.Rename) {
+		if w.dirs[path] {
+			w.removeTree(path)
+			return
+		}
+	}
+	if event.Has(fsnotify.Create) {
+		if info, err := os.Lstat(path); err == nil && info.IsDir() {
+			// A directory moved in or created with files already in it
+			w.addTree(path, true)
+			return
+		}
+	}
+	if event.Op == fsnotify.Chmod {
+		return
+	}
+	w.schedule(path)
+}
+
+// schedule handles the path once it has gone without events for the debounce
+// interval.
+func (w *Watcher) schedule(path string) {
+	if t, ok := w.pending[path]; ok {
+		t.Reset(w.debounce)
+		return
+	}
+	w.pending[path] = time.AfterFunc(w.debounce, func() {
+		select {
+		case w.ready <- path:
+		case <-w.done:
+		}
+	})
+}
+
+// sync processes the file at path, or deletes its document when the file is
+// gone or no longer wanted. Looking at the file rather than at the events
+// makes renames and editors' atomic saves come out right.
+func (w *Watcher) sync(ctx context.Context, path string) {
+	info, err := os.Lstat(path)
+	if err == nil && info.Mode().IsRegular() && w.wanted(path, false) {
+		data, err := os.ReadFile(path)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			slog.Error("failed to read changed file", slog.String("error", err.Error()), slog.String("document_name", path))
+			return
+		}
+		if err == nil && (!extractor.IsBinary(data) || (w.supports != nil && w.supports(path, data))) {
+			w.known[path] = true
+			if err := w.handler.ProcessFile(ctx, path, data); err != nil {
+				slog.Error("failed to process changed file", slog.String("error", err.Error()), slog.String("document_name", path))
+				return
+			}
+			slog.Info("processed changed file", slog.String("document_name", path))
+			return
+		}
+	}
+
+	if !w.known[path] {
+		return
+	}
+	delete(w.known, path)
+	if err := w.handler.DeleteFile(ctx, path); err != nil {
+		slog.Error("failed to delete document of removed file", slog.String("error", err.Error()), slog.String("document_name", path))
+		return
+	}
+	slog.Info("deleted document of removed file", slog.String("document_name", path))
+}
+
+// addTree watches a directory and its subdirectories. Files in them are
+// scheduled when schedule is set, otherwise they are only remembered.
+func (w *Watcher) addTree(dir string, schedule bool) {
+	if !w.wanted(dir, true) {
+		return
+	}
+	root, _, _ := w.relative(dir)
+	err := w.filters[root].Walk(dir, func(path, rel string, d fs.DirEntry, err error) error {
+		if err != nil {
+			slog.Warn("failed to read directory to watch", slog.String("error", err.Error()), slog.String("path", path))
+			return nil
+		}
+		if !d.IsDir() {
+			if schedule {
+				w.schedule(path)
+			} else {
+				w.known[path] = true
+			}
+			return nil
+		}
+		if err := w.fs.Add(path); err != nil {
+			slog.Warn("failed to watch directory", slog.String("error", err.Error()), slog.String("path", path))
+			return nil
+		}
+		w.dirs[path] = true
+		return nil
+	})
+	if err != nil {
+		slog.Warn("failed to watch directory tree", slog.String("error", err.Error()), slog.String("path", dir))
+	}
+}
+
+// removeTree forgets a removed directory and schedules the files that were in
+// it, so their documents are deleted.
+func (w *Watcher) removeTree(dir string) {
+	prefix := dir + string(filepath.Separator)
+	for path := range w.dirs {
+		if path == dir || strings.HasPrefix(path, prefix) {
+			delete(w.dirs, path)
+			// Already gone when the directory was removed rather than renamed
+			_ = w.fs.Remove(path)
+		}
+	}
+	for path := range w.known {
+		if strings.HasPrefix(path, prefix) {
+			w.schedule(path)
+		}
+	}
+}
+
+// wanted reports whether a path passes the filter of its root.
+func (w *Watcher) wanted(path string, isDir bool) bool {
+	root, rel, ok := w.relative(path)
+	return ok && w.filters[root].Wanted(rel, isDir)
+}
+
+// relative returns the root a path is under and its slash-separated path
+// relative to it, "" for the root itself.
+func (w *Watcher) relative(path string) (string, string, bool) {
+	for _, root := range w.roots {
+		rel, err := filepath.Rel(root, path)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			continue
+		}
+		if rel == "." {
+			rel = ""
+		}
+		return root, filepath.ToSlash(rel), true
+	}
+	return "", "", false
+}
@@ -0,0 +1,187 @@
+package watcher
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// recorder is a Handler that records the calls it receives.
+type recorder struct {
+	mu        sync.Mutex
+	processed []string
+	deleted   []string
+	data      map[string]string
+}
+
+func (r *recorder) ProcessFile(_ context.Context, name string, data []byte) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.processed = append(r.processed, name)
+	r.data[name] = string(data)
+	return nil
+}
+
+func (r *recorder) DeleteFile(_ context.Context, name string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.deleted = append(r.deleted, name)
+	return nil
+}
+
+func (r *recorder) calls() ([]string, []string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return slices.Clone(r.processed), slices.Clone(r.deleted)
+}
+
+func (r *recorder) reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.processed, r.deleted = nil, nil
+}
+
+const testDebounce = 50 * time.Millisecond
+
+// startWatcher watches dir until the test ends.
+func startWatcher(t *testing.T, dir string, opts Options) *recorder {
+	rec := &recorder{data: make(map[string]string)}
+	opts.Debounce = testDebounce
+	w, err := New([]string{dir}, rec, opts)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		require.NoError(t, w.Run(ctx))
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+	return rec
+}
+
+func writeFile(t *testing.T, path, content string) {
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+}
+
+// waitFor waits until the recorder has the expected calls, then for a few
+// more debounce intervals to catch unexpected ones.
+func waitFor(t *testing.T, rec *recorder, processed, deleted []string) {
+	t.Helper()
+	require.Eventually(t, func() bool {
+		p, d := rec.calls()
+		return len(p) >= len(processed) && len(d) >= len(deleted)
+	}, 5*time.Second, 10*time.Millisecond)
+	time.Sleep(4 * testDebounce)
+	p, d := rec.calls()
+	require.ElementsMatch(t, processed, p)
+	require.ElementsMatch(t, deleted, d)
+	rec.reset()
+}
+
+func TestWatcher(t *testing.T) {
+	dir := t.TempDir()
+	existing := filepath.Join(dir, "existing.md")
+	writeFile(t, existing, "Existing\n")
+	rec := startWatcher(t, dir, Options{})
+
+	// A burst of saves processes the file once, with its last content
+	notes := filepath.Join(dir, "notes.md")
+	for i := range 5 {
+		writeFile(t, notes, "Version "+string(rune('0'+i))+"\n")
+		time.Sleep(testDebounce / 5)
+	}
+	waitFor(t, rec, []string{notes}, nil)
+	require.Equal(t, "Version 4\n", rec.data[notes])
+
+	// A rename deletes the old name and processes the new one
+	renamed := filepath.Join(dir, "renamed.md")
+	require.NoError(t, os.Rename(notes, renamed))
+	waitFor(t, rec, []string{renamed}, []string{notes})
+
+	// Files indexed before the watcher started are deleted too
+	require.NoError(t, os.Remove(existing))
+	waitFor(t, rec, nil, []string{existing})
+
+	// An atomic save through a temporary file only processes the target
+	tmp := filepath.Join(dir, "renamed.md.tmp")
+	writeFile(t, tmp, "Saved\n")
+	require.NoError(t, os.Rename(tmp, renamed))
+	waitFor(t, rec, []string{renamed}, nil)
+	require.Equal(t, "Saved\n", rec.data[renamed])
+}
+
+func TestWatcher_Directories(t *testing.T) {
+	dir := t.TempDir()
+	rec := startWatcher(t, dir, Options{})
+
+	// Files in new subdirectories are picked up
+	nested := filepath.Join(dir, "a", "b", "nested.md")
+	writeFile(t, nested, "Nested\n")
+	waitFor(t, rec, []string{nested}, nil)
+
+	// A directory moved in from outside is indexed
+	outside := t.TempDir()
+	writeFile(t, filepath.Join(outside, "moved", "one.md"), "One\n")
+	writeFile(t, filepath.Join(outside, "moved", "sub", "two.md"), "Two\n")
+	require.NoError(t, os.Rename(filepath.Join(outside, "moved"), filepath.Join(dir, "moved")))
+	one := filepath.Join(dir, "moved", "one.md")
+	two := filepath.Join(dir, "moved", "sub", "two.md")
+	waitFor(t, rec, []string{one, two}, nil)
+
+	// Removing a directory deletes the documents of its files
+	require.NoError(t, os.RemoveAll(filepath.Join(dir, "moved")))
+	waitFor(t, rec, nil, []string{one, two})
+
+	// New files in the remaining directories are still picked up
+	after := filepath.Join(dir, "a", "after.md")
+	writeFile(t, after, "After\n")
+	waitFor(t, rec, []string{after}, nil)
+}
+
+func TestWatcher_SkipsFiles(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, ".ragignore"), "*.log\nbuild/\n")
+	rec := startWatcher(t, dir, Options{})
+
+	writeFile(t, filepath.Join(dir, ".hidden.md"), "Hidden\n")
+	writeFile(t, filepath.Join(dir, ".git", "config"), "Hidden\n")
+	writeFile(t, filepath.Join(dir, "debug.log"), "Ignored\n")
+	writeFile(t, filepath.Join(dir, "build", "out.md"), "Ignored\n")
+	writeFile(t, filepath.Join(dir, "image.bin"), "\x00\x01\x02")
+	kept := filepath.Join(dir, "kept.md")
+	writeFile(t, kept, "Kept\n")
+	waitFor(t, rec, []string{kept}, nil)
+}
+
+func TestWatcher_SupportedBinary(t *testing.T) {
+	dir := t.TempDir()
+	rec := startWatcher(t, dir, Options{
+		Supports: func(name string, _ []byte) bool { return filepath.Ext(name) == ".pdf" },
+	})
+
+	doc := filepath.Join(dir, "doc.pdf")
+	writeFile(t, doc, "%PDF\x00")
+	writeFile(t, filepath.Join(dir, "image.bin"), "\x00\x01\x02")
+	waitFor(t, rec, []string{doc}, nil)
+}
+
+func TestNew_NotADirectory(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "file.md")
+	writeFile(t, file, "File\n")
+	_, err := New([]string{file}, &recorder{}, Options{})
+	require.Error(t, err)
+
+	_, err = New(nil, &recorder{}, Options{})
+	require.Error(t, err)
+}